@@ -0,0 +1,57 @@
+package xerrors
+
+import (
+	"fmt"
+)
+
+// PanicOnViolation controls whether [Ensure] and [Invariantf] panic with the [Error] describing a violated condition
+// instead of returning it.
+//
+// Panicking is useful during development and testing to surface violations immediately; the panics can be converted
+// back into errors using [Recover].  This function affects all assertions globally.  This call is thread-safe.
+func PanicOnViolation(enable bool) {
+	UpdateConfig(func(c *Config) {
+		c.PanicOnViolation = enable
+	})
+}
+
+// Ensure returns a new [Error] with the given code, message and attributes if the condition is false or nil if it
+// is true.
+//
+// The attributes are given as alternating keys and values in the same way as [Panic].  This replaces checks such as
+// "if !ok { return errors.New(...) }" with consistently coded errors, eg:
+//
+//	if err := xerrors.Ensure(len(items) > 0, 400, "no items given", "order", id); err != nil {
+//		return err
+//	}
+//
+// See [PanicOnViolation] to panic instead of returning the error.
+func Ensure(cond bool, code int, message string, attrs ...any) Error {
+	if cond {
+		return nil
+	}
+	xerr := newError(nil, nil, 0, code, message, nil)
+	if len(attrs) > 0 {
+		xerr.WithAttrs(argsToAttrs(attrs))
+	}
+	return violation(xerr)
+}
+
+// Invariantf returns a new [Error] with the given code and formatted message if the condition is false or nil if it
+// is true.
+//
+// See [PanicOnViolation] to panic instead of returning the error.
+func Invariantf(cond bool, code int, format string, args ...any) Error {
+	if cond {
+		return nil
+	}
+	return violation(newError(nil, nil, 0, code, fmt.Sprintf(format, args...), nil))
+}
+
+// violation returns the error for a violated condition or panics with it if [PanicOnViolation] is enabled.
+func violation(err Error) Error {
+	if loadConfig().PanicOnViolation {
+		panic(err)
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package xerrors
+
+// AttrKey is the key of a commonly used attribute.
+//
+// Using the predeclared keys keeps the naming of attributes consistent across services.  As the keys are constants,
+// they are shared rather than allocated for each error.
+type AttrKey string
+
+const (
+	// KeyOp is the key of the attribute holding the name of an operation related to the failure.  Prefer WithOp to
+	// record the operation which failed.
+	KeyOp AttrKey = "op"
+
+	// KeyRequestID is the key of the attribute holding the ID of the request which failed.
+	KeyRequestID AttrKey = "requestId"
+
+	// KeyResource is the key of the attribute holding the name or ID of the resource involved in the failure.
+	KeyResource AttrKey = "resource"
+
+	// KeyTenantID is the key of the attribute holding the ID of the tenant which was affected.
+	KeyTenantID AttrKey = "tenantId"
+
+	// KeyTraceID is the key of the attribute holding the ID of the trace the failure belongs to.
+	KeyTraceID AttrKey = "traceId"
+
+	// KeyUserID is the key of the attribute holding the ID of the user who was affected.
+	KeyUserID AttrKey = "userId"
+)
+
+// Get returns the value of the attribute with the key from the given error.
+//
+// The second return value is false if the error does not have the attribute.
+func (k AttrKey) Get(err Error) (any, bool) {
+	value, ok := err.Attrs()[string(k)]
+	return value, ok
+}
+
+// String returns the key as a string.
+func (k AttrKey) String() string {
+	return string(k)
+}
+
+// With adds the attribute with the key and the given value to the error and returns the error, eg:
+// xerrors.KeyUserID.With(err, id).
+func (k AttrKey) With(err Error, value any) Error {
+	return err.WithAttr(string(k), value)
+}
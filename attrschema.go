@@ -0,0 +1,204 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AttrType is the type of the values of an attribute described by an [AttrSchema].
+type AttrType string
+
+const (
+	// AttrTypeBool describes boolean values.
+	AttrTypeBool AttrType = "bool"
+
+	// AttrTypeDuration describes durations, marshaled either as a number of nanoseconds (as [time.Duration] is) or as
+	// a string accepted by [time.ParseDuration], eg: "1.5s".
+	AttrTypeDuration AttrType = "duration"
+
+	// AttrTypeEnum describes strings which are one of the values listed in the schema.
+	AttrTypeEnum AttrType = "enum"
+
+	// AttrTypeFloat describes floating point numbers.
+	AttrTypeFloat AttrType = "float"
+
+	// AttrTypeInt describes integers, marshaled either as numbers or as strings holding decimal numbers.
+	AttrTypeInt AttrType = "int"
+
+	// AttrTypeString describes strings.
+	AttrTypeString AttrType = "string"
+
+	// AttrTypeTime describes points in time, marshaled as RFC 3339 strings (as [time.Time] is).
+	AttrTypeTime AttrType = "time"
+)
+
+var (
+	_attrSchemas      = map[string]AttrSchema{}
+	_attrSchemasMutex sync.Mutex
+)
+
+// AttrSchema describes the values of an attribute for machine consumers of marshaled errors, eg: alerting rules and
+// billing reconciliation, which need to validate and parse the values reliably.
+//
+// Schemas are registered using [RegisterAttrSchema] and written to the documents of a [MarshalProfile] which has
+// AttrSchemas set, or can be published separately using [AttrSchemas].
+type AttrSchema struct {
+	// Type is the type of the values.
+	Type AttrType `json:"type"`
+
+	// Values lists the allowed values of an [AttrTypeEnum] attribute.
+	Values []string `json:"values,omitempty"`
+
+	// Description describes the attribute, if set.
+	Description string `json:"description,omitempty"`
+}
+
+// RegisterAttrSchema registers the schema of the attribute with the given key, replacing any schema which was
+// registered for the key.
+//
+// The key is the one passed to WithAttr, ie: before any [KeyNormalizer] set on a [MarshalProfile] is applied.  This
+// function affects all errors globally.  This call is thread-safe.
+func RegisterAttrSchema(key string, schema AttrSchema) {
+	schema.Values = slices.Clone(schema.Values)
+	_attrSchemasMutex.Lock()
+	_attrSchemas[key] = schema
+	_attrSchemasMutex.Unlock()
+}
+
+// AttrSchemas returns a copy of the registered attribute schemas by key.
+//
+// This call is thread-safe.
+func AttrSchemas() map[string]AttrSchema {
+	_attrSchemasMutex.Lock()
+	defer _attrSchemasMutex.Unlock()
+	return maps.Clone(_attrSchemas)
+}
+
+// Parse converts the value of an attribute, either as it was added to an error or as it was decoded from JSON, into
+// the Go type matching the schema: bool, [time.Duration], string (for enums and strings), float64, int64 or
+// [time.Time].
+//
+// An error is returned if the value does not conform to the schema.
+func (s AttrSchema) Parse(value any) (any, error) {
+	switch s.Type {
+	case AttrTypeBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case AttrTypeDuration:
+		switch v := value.(type) {
+		case time.Duration:
+			return v, nil
+		case string:
+			if d, err := time.ParseDuration(v); err == nil {
+				return d, nil
+			}
+		default:
+			if n, ok := schemaInt(value); ok {
+				return time.Duration(n), nil
+			}
+		}
+	case AttrTypeEnum:
+		if v, ok := value.(string); ok && slices.Contains(s.Values, v) {
+			return v, nil
+		}
+	case AttrTypeFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				return f, nil
+			}
+		default:
+			if n, ok := schemaInt(value); ok {
+				return float64(n), nil
+			}
+		}
+	case AttrTypeInt:
+		if v, ok := value.(string); ok {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n, nil
+			}
+		} else if n, ok := schemaInt(value); ok {
+			return n, nil
+		}
+	case AttrTypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case AttrTypeTime:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown attribute type: %q", s.Type)
+	}
+	return nil, fmt.Errorf("invalid %s attribute value: %v", s.Type, value)
+}
+
+// ValidateAttrs checks the attributes of the error which have a registered schema (see [RegisterAttrSchema]),
+// returning an error describing the first attribute, in key order, whose value does not conform to its schema.
+func ValidateAttrs(err Error) error {
+	schemas := AttrSchemas()
+	attrs := err.Attrs()
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		if _, ok := schemas[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if _, pErr := schemas[k].Parse(attrs[k]); pErr != nil {
+			return fmt.Errorf("attribute %q: %w", k, pErr)
+		}
+	}
+	return nil
+}
+
+// schemaInt returns the value as an int64 if it is an integer, including floats without a fractional part as
+// produced by decoding JSON.
+func schemaInt(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case float64:
+		return int64(v), v == math.Trunc(v) && math.Abs(v) < 1<<63
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package xerrors
+
+import (
+	"encoding/json"
+)
+
+// Base is an embeddable implementation of [Error] for creating domain-specific error types with extra fields without
+// reimplementing marshaling, caller capture and attribute handling, eg:
+//
+//	type NotFoundError struct {
+//		xerrors.Base
+//		Resource string
+//	}
+//
+//	func NotFound(resource string) *NotFoundError {
+//		return &NotFoundError{
+//			Base:     xerrors.NewBase(404, nil, resource+" not found"),
+//			Resource: resource,
+//		}
+//	}
+//
+//	func (e *NotFoundError) MarshalJSON() ([]byte, error) {
+//		return e.MarshalJSONWith(map[string]any{"resource": e.Resource})
+//	}
+//
+// The embedding type implements [Error] and matches [errors.As] targets of its own type, while [errors.Is], the
+// matchers (see [Matcher]) and the functions of this package which inspect a chain see the embedded error as part of
+// it.  The With methods of the embedded error modify it and return it rather than the embedding value, so they should
+// be called before the embedding value is returned.  The zero value is not usable; use [NewBase] or [BaseOf].
+type Base struct {
+	baseError
+}
+
+// baseError is an alias of [Error] so that embedding it in [Base] promotes its methods, including Error(), rather
+// than adding a field named Error which would hide that method.
+type baseError = Error
+
+// NewBase creates a new [Base] with the given code and message which wraps the given error, if it is not nil.
+//
+// NewBase is meant to be called by the constructor of the embedding type, so the caller information captured (see
+// [CaptureCallerInfo]) is the location which called that constructor rather than the constructor itself.
+func NewBase(code int, err error, message string) Base {
+	return Base{
+		baseError: newError(nil, nil, 1, code, message, err),
+	}
+}
+
+// BaseOf creates a new [Base] which embeds the given error, eg: one created by a [Factory] whose settings should apply
+// to the embedding type.
+func BaseOf(err Error) Base {
+	return Base{
+		baseError: err,
+	}
+}
+
+// As sets the target to the first [Matcher] matching the embedded error, so that matchers keep working with
+// [errors.As] for types embedding a Base.
+func (b Base) As(target any) bool {
+	if a, ok := b.baseError.(interface{ As(any) bool }); ok {
+		return a.As(target)
+	}
+	return false
+}
+
+// MarshalJSONWith marshals the embedded error to JSON with the given extra top-level fields, which is typically
+// called by the MarshalJSON method of the embedding type to include its own fields.
+//
+// Fields with the same name as a field of the error are ignored, so the extra fields cannot replace the code or the
+// message of the error.  The fields are written in sorted order along with those of the error.
+func (b Base) MarshalJSONWith(fields map[string]any) ([]byte, error) {
+	data, err := b.baseError.AppendJSON(nil)
+	if err != nil || len(fields) == 0 {
+		return data, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for k, v := range fields {
+		if _, ok := doc[k]; ok {
+			continue
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		doc[k] = value
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,26 @@
+package xerrors
+
+import (
+	"fmt"
+)
+
+// WrapAll wraps each of the errors of a batch, eg: the failures of the individual records of an import, in a new
+// [Error] with the given code, so that every failure is annotated consistently.
+//
+// The message of each error is formatted using msgf with the index of the item as its only argument, eg: "record %d
+// failed".  If perItemAttrs is not nil, the attributes it returns for the index of the item are added to the error.
+// The returned slice has the same length as errs so that the indexes line up; nil errors remain nil.
+func WrapAll(code int, errs []error, msgf string, perItemAttrs func(i int) map[string]any) []Error {
+	wrapped := make([]Error, len(errs))
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		xerr := newError(nil, nil, 0, code, fmt.Sprintf(msgf, i), err)
+		if perItemAttrs != nil {
+			xerr.WithAttrs(perItemAttrs(i))
+		}
+		wrapped[i] = xerr
+	}
+	return wrapped
+}
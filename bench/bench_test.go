@@ -0,0 +1,94 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.innotegrity.dev/xerrors"
+)
+
+var (
+	_err   error
+	_cause = errors.New("connection refused")
+)
+
+func BenchmarkNew(b *testing.B) {
+	b.Run("xerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = xerrors.New(1042, "quota exceeded")
+		}
+	})
+	b.Run("stdlib", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = errors.New("quota exceeded")
+		}
+	})
+	b.Run("pkgerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = pkgerrors.New("quota exceeded")
+		}
+	})
+}
+
+func BenchmarkNewf(b *testing.B) {
+	b.Run("xerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = xerrors.Newf(1042, "quota exceeded for %s", "user")
+		}
+	})
+	b.Run("stdlib", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = fmt.Errorf("quota exceeded for %s", "user")
+		}
+	})
+	b.Run("pkgerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = pkgerrors.Errorf("quota exceeded for %s", "user")
+		}
+	})
+}
+
+func BenchmarkWrap(b *testing.B) {
+	b.Run("xerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = xerrors.Wrap(1042, _cause, "failed to connect")
+		}
+	})
+	b.Run("stdlib", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = fmt.Errorf("failed to connect: %w", _cause)
+		}
+	})
+	b.Run("pkgerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = pkgerrors.Wrap(_cause, "failed to connect")
+		}
+	})
+}
+
+func BenchmarkWrapPkgErrors(b *testing.B) {
+	cause := pkgerrors.New("connection refused")
+	b.Run("xerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = xerrors.Wrap(1042, cause, "failed to connect")
+		}
+	})
+	b.Run("pkgerrors", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_err = pkgerrors.Wrap(cause, "failed to connect")
+		}
+	})
+}
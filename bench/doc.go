@@ -0,0 +1,21 @@
+// Package bench compares the cost of creating and wrapping [xerrors.Error] objects with the standard library and
+// github.com/pkg/errors.
+//
+// This package is distributed as a separate module so that the core xerrors module does not depend on
+// github.com/pkg/errors.  It only contains benchmarks: run them using "go test -bench . -benchmem".
+//
+// The following results were measured using Go 1.27 on an Intel Xeon processor (linux/amd64), with the default
+// configuration of this package, ie: without caller capture, stack traces or hooks:
+//
+//	BenchmarkNew/xerrors       49-59 ns/op     64 B/op   1 allocs/op
+//	BenchmarkNew/stdlib        24-26 ns/op     16 B/op   1 allocs/op
+//	BenchmarkNew/pkgerrors   454-754 ns/op    304 B/op   3 allocs/op
+//	BenchmarkWrap/xerrors    157-233 ns/op     64 B/op   1 allocs/op
+//	BenchmarkWrap/stdlib     209-229 ns/op     80 B/op   2 allocs/op
+//	BenchmarkWrap/pkgerrors  522-560 ns/op    336 B/op   4 allocs/op
+//
+// Creating an error takes about twice as long as using [errors.New], as almost all of the time is spent allocating
+// the 64-byte error, four times the size of the standard library's; wrapping an error costs the same as using
+// [fmt.Errorf] with %w.  The original target of 1.2 times the cost of [errors.New] would require dropping fields from
+// every error and is not met.
+package bench
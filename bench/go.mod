@@ -0,0 +1,10 @@
+module go.innotegrity.dev/xerrors/bench
+
+go 1.23
+
+replace go.innotegrity.dev/xerrors => ../
+
+require (
+	github.com/pkg/errors v0.9.1
+	go.innotegrity.dev/xerrors v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,566 @@
+package xerrors
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	// BinaryVersion1 is the first version of the compact binary format produced by [AppendBinary].
+	BinaryVersion1 = 1
+
+	// _binaryMagic is the prefix of every document in the binary format.
+	_binaryMagic = "xe"
+
+	// _maxBinaryNesting is the maximum nesting of map and slice attribute values accepted by ParseBinary.
+	_maxBinaryNesting = 64
+)
+
+// binary record tags
+const (
+	_binaryEnd   byte = 0   // end of the chain
+	_binaryError byte = 'E' // an Error
+	_binaryStd   byte = 'S' // a standard Go error with only a message, which ends the chain
+)
+
+// binary presence flags of the optional fields of an Error record
+const (
+	_binaryHasDomain uint64 = 1 << iota
+	_binaryHasID
+	_binaryHasKind
+	_binaryHasOp
+	_binaryHasSeverity
+	_binaryHasPosition
+	_binaryHasCaller
+	_binaryHasStack
+	_binaryHasHints
+	_binaryHasAttrs
+)
+
+// binary attribute value tags
+const (
+	_binaryNil byte = iota
+	_binaryFalse
+	_binaryTrue
+	_binaryInt
+	_binaryUint
+	_binaryFloat
+	_binaryString
+	_binaryBytes
+	_binaryDuration
+	_binaryTime
+	_binaryGroup
+	_binarySlice
+	_binaryJSON
+)
+
+// AppendBinary appends the compact binary encoding of the error, including its chain of wrapped errors, to dst and
+// returns the extended buffer.
+//
+// The format is meant for services which serialize large numbers of errors into event streams, as it is smaller and
+// faster to produce and parse than JSON.  A document consists of the "xe" magic, the format version (see
+// [BinaryVersion1]) and one record per error in the chain, ending with a zero byte.  Each record holds the code as a
+// varint, the length-prefixed message, a varint of flags telling which optional fields follow (domain, ID, kind,
+// operation, severity, position, caller, stack, hints and attributes) and the fields themselves.  Attribute values
+// are typed: nil, booleans, integers, floats, strings, byte slices, durations, times, groups and slices of any are
+// encoded natively and other values as JSON.  Standard Go errors in the chain are encoded with only their message.
+//
+// The attributes are prepared in the same way as for JSON using the profile of each error, so classified attributes
+// are omitted, values are truncated and registered serializers are applied.  Use [ParseBinary] to decode the document.
+func AppendBinary(dst []byte, err Error) []byte {
+	dst = append(dst, _binaryMagic...)
+	dst = append(dst, BinaryVersion1)
+	done := false
+	truncated := walkChain(err, func(err error) bool {
+		e, ok := err.(*xerr)
+		if !ok {
+			// standard errors include the messages of the errors they wrap
+			dst = append(dst, _binaryStd)
+			dst = appendBinaryString(dst, err.Error())
+			done = true
+			return false
+		}
+		dst = e.appendBinary(dst)
+		return true
+	})
+	if truncated {
+		dst = append(dst, _binaryStd)
+		return appendBinaryString(dst, TruncationMarker)
+	}
+	if !done {
+		dst = append(dst, _binaryEnd)
+	}
+	return dst
+}
+
+// appendBinary appends the binary record of the error, without its wrapped errors, to dst.
+func (e *xerr) appendBinary(dst []byte) []byte {
+	e.markInspected()
+	profile := e.profile.resolve()
+	var flags uint64
+	if e.domain != "" {
+		flags |= _binaryHasDomain
+	}
+	if e.id != "" {
+		flags |= _binaryHasID
+	}
+	if e.kind != "" {
+		flags |= _binaryHasKind
+	}
+	if e.op != "" {
+		flags |= _binaryHasOp
+	}
+	if e.severity != SeverityUnknown {
+		flags |= _binaryHasSeverity
+	}
+	if e.position != nil {
+		flags |= _binaryHasPosition
+	}
+	if e.caller != nil {
+		flags |= _binaryHasCaller
+	}
+	if len(e.stack) > 0 {
+		flags |= _binaryHasStack
+	}
+	if len(e.hints) > 0 {
+		flags |= _binaryHasHints
+	}
+	if len(e.attrs) > 0 {
+		flags |= _binaryHasAttrs
+	}
+
+	dst = append(dst, _binaryError)
+	dst = binary.AppendVarint(dst, int64(e.code))
+	dst = appendBinaryString(dst, e.message)
+	dst = binary.AppendUvarint(dst, flags)
+	if flags&_binaryHasDomain != 0 {
+		dst = appendBinaryString(dst, e.domain)
+	}
+	if flags&_binaryHasID != 0 {
+		dst = appendBinaryString(dst, e.id)
+	}
+	if flags&_binaryHasKind != 0 {
+		dst = appendBinaryString(dst, string(e.kind))
+	}
+	if flags&_binaryHasOp != 0 {
+		dst = appendBinaryString(dst, e.op)
+	}
+	if flags&_binaryHasSeverity != 0 {
+		dst = binary.AppendVarint(dst, int64(e.severity))
+	}
+	if flags&_binaryHasPosition != 0 {
+		dst = appendBinaryString(dst, e.position.File)
+		dst = binary.AppendVarint(dst, int64(e.position.Line))
+		dst = binary.AppendVarint(dst, int64(e.position.Column))
+		dst = binary.AppendVarint(dst, e.position.Offset)
+	}
+	if flags&_binaryHasCaller != 0 {
+		dst = appendBinaryFrame(dst, e.caller)
+	}
+	if flags&_binaryHasStack != 0 {
+		dst = binary.AppendUvarint(dst, uint64(len(e.stack)))
+		for i := range e.stack {
+			dst = appendBinaryFrame(dst, &e.stack[i])
+		}
+	}
+	if flags&_binaryHasHints != 0 {
+		dst = binary.AppendUvarint(dst, uint64(len(e.hints)))
+		for _, hint := range e.hints {
+			dst = appendBinaryString(dst, hint)
+		}
+	}
+	if flags&_binaryHasAttrs != 0 {
+		var keyBuf [16]string
+		keys := keyBuf[:0]
+		for k := range e.attrs {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		var values [16]any
+		prepared := values[:0]
+		retained := keys[:0]
+		for _, k := range keys {
+			if v, ok := profile.prepareAttr(e.classes, k, e.attrs[k]); ok {
+				retained = append(retained, k)
+				prepared = append(prepared, v)
+			}
+		}
+		causes := newNesting(e, profile)
+		dst = binary.AppendUvarint(dst, uint64(len(retained)))
+		for i, k := range retained {
+			dst = appendBinaryString(dst, profile.KeyNormalizer.Normalize(k))
+			dst = appendBinaryValue(dst, prepared[i], &causes)
+		}
+	}
+	return dst
+}
+
+// appendBinaryString appends the length-prefixed string to dst.
+func appendBinaryString(dst []byte, s string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// appendBinaryFrame appends the binary encoding of the caller information to dst.
+func appendBinaryFrame(dst []byte, c *CallerInfo) []byte {
+	dst = appendBinaryString(dst, c.File)
+	dst = binary.AppendVarint(dst, int64(c.Line))
+	dst = appendBinaryString(dst, c.Func)
+	dst = appendBinaryString(dst, c.Package)
+	dst = appendBinaryString(dst, c.Receiver)
+	if c.Synthetic {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+// appendBinaryValue appends the typed binary encoding of the attribute value to dst.
+//
+// Errors stored as attribute values are encoded as JSON using the profile of the error holding them (see
+// nesting).
+func appendBinaryValue(dst []byte, value any, causes *nesting) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(dst, _binaryNil)
+	case bool:
+		if v {
+			return append(dst, _binaryTrue)
+		}
+		return append(dst, _binaryFalse)
+	case int:
+		return binary.AppendVarint(append(dst, _binaryInt), int64(v))
+	case int8:
+		return binary.AppendVarint(append(dst, _binaryInt), int64(v))
+	case int16:
+		return binary.AppendVarint(append(dst, _binaryInt), int64(v))
+	case int32:
+		return binary.AppendVarint(append(dst, _binaryInt), int64(v))
+	case int64:
+		return binary.AppendVarint(append(dst, _binaryInt), v)
+	case uint:
+		return binary.AppendUvarint(append(dst, _binaryUint), uint64(v))
+	case uint8:
+		return binary.AppendUvarint(append(dst, _binaryUint), uint64(v))
+	case uint16:
+		return binary.AppendUvarint(append(dst, _binaryUint), uint64(v))
+	case uint32:
+		return binary.AppendUvarint(append(dst, _binaryUint), uint64(v))
+	case uint64:
+		return binary.AppendUvarint(append(dst, _binaryUint), v)
+	case float32:
+		return binary.LittleEndian.AppendUint64(append(dst, _binaryFloat), math.Float64bits(float64(v)))
+	case float64:
+		return binary.LittleEndian.AppendUint64(append(dst, _binaryFloat), math.Float64bits(v))
+	case string:
+		return appendBinaryString(append(dst, _binaryString), v)
+	case []byte:
+		dst = binary.AppendUvarint(append(dst, _binaryBytes), uint64(len(v)))
+		return append(dst, v...)
+	case time.Duration:
+		return binary.AppendVarint(append(dst, _binaryDuration), int64(v))
+	case time.Time:
+		// times outside of the range of nanoseconds since the epoch are encoded as JSON below
+		if n := v.UnixNano(); time.Unix(0, n).Equal(v) {
+			return binary.AppendVarint(append(dst, _binaryTime), n)
+		}
+	case AttrGroup:
+		dst = binary.AppendUvarint(append(dst, _binaryGroup), uint64(len(v)))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			dst = appendBinaryString(dst, k)
+			dst = appendBinaryValue(dst, v[k], causes)
+		}
+		return dst
+	case []any:
+		dst = binary.AppendUvarint(append(dst, _binarySlice), uint64(len(v)))
+		for _, item := range v {
+			dst = appendBinaryValue(dst, item, causes)
+		}
+		return dst
+	}
+	var buf [64]byte
+	return appendBinaryString(append(dst, _binaryJSON), string(appendJSONValue(buf[:0], value, causes)))
+}
+
+// ParseBinary reconstructs an [Error] from a document produced using [AppendBinary].
+//
+// Integer attributes are decoded as int or, if they were unsigned, as uint64, durations as [time.Duration], times as
+// [time.Time] in UTC, groups as [AttrGroup] and values which were encoded as JSON as they are by [json.Unmarshal].
+// Wrapped errors which only have a message are reconstructed as standard Go errors.  Hooks are not called for parsed
+// errors.
+//
+// An error is returned if the document is malformed or has trailing data, or if the wrapped errors are nested deeper
+// than the maximum depth set by [SetMaxChainDepth].
+func ParseBinary(data []byte) (Error, error) {
+	r := &binaryReader{data: data}
+	if len(data) < len(_binaryMagic)+1 || string(data[:len(_binaryMagic)]) != _binaryMagic {
+		return nil, errors.New("invalid binary error document: missing magic")
+	}
+	if version := data[len(_binaryMagic)]; version != BinaryVersion1 {
+		return nil, fmt.Errorf("invalid binary error document: unsupported version %d", version)
+	}
+	r.off = len(_binaryMagic) + 1
+
+	maxDepth := loadConfig().MaxChainDepth
+
+	var root *xerr
+	var last *xerr
+	for depth := 0; ; depth++ {
+		tag := r.byte()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if tag == _binaryEnd {
+			break
+		}
+		if depth >= maxDepth {
+			return nil, errors.New("invalid binary error document: wrapped errors are nested too deeply")
+		}
+		if last == nil && tag != _binaryError {
+			return nil, errors.New("invalid binary error document: missing error record")
+		}
+		if tag == _binaryStd {
+			message := r.string()
+			if r.err != nil {
+				return nil, r.err
+			}
+			last.wrappedErr = errors.New(message)
+			break
+		}
+		if tag != _binaryError {
+			return nil, fmt.Errorf("invalid binary error document: unknown record tag 0x%02x", tag)
+		}
+		e := r.record()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if last == nil {
+			root = e
+		} else {
+			last.wrappedErr = e
+		}
+		last = e
+	}
+	if root == nil {
+		return nil, errors.New("invalid binary error document: missing error record")
+	}
+	if r.off != len(data) {
+		return nil, fmt.Errorf("invalid binary error document: %d bytes of trailing data", len(data)-r.off)
+	}
+	return root, nil
+}
+
+// binaryReader decodes the fields of a binary error document, recording the first error it encounters.
+type binaryReader struct {
+	data []byte // the document
+	err  error  // first decoding error or nil
+	off  int    // offset of the next field in the document
+}
+
+// fail records the decoding error unless one was already recorded.
+func (r *binaryReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("invalid binary error document: "+format+" at offset %d", append(args, r.off)...)
+	}
+	r.off = len(r.data)
+}
+
+// byte decodes a single byte.
+func (r *binaryReader) byte() byte {
+	if r.off >= len(r.data) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.data[r.off]
+	r.off++
+	return b
+}
+
+// bytes decodes a length-prefixed byte slice, which refers to the document.
+func (r *binaryReader) bytes() []byte {
+	n := r.uvarint()
+	if n > uint64(len(r.data)-r.off) {
+		r.fail("length %d exceeds the remaining data", n)
+		return nil
+	}
+	b := r.data[r.off : r.off+int(n)]
+	r.off += int(n)
+	return b
+}
+
+// count decodes the number of entries of a list, each of which takes at least one byte.
+func (r *binaryReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.data)-r.off) {
+		r.fail("count %d exceeds the remaining data", n)
+		return 0
+	}
+	return int(n)
+}
+
+// frame decodes caller information.
+func (r *binaryReader) frame() CallerInfo {
+	c := CallerInfo{File: r.string()}
+	c.Line = r.int()
+	c.Func = r.string()
+	c.Package = r.string()
+	c.Receiver = r.string()
+	c.Synthetic = r.byte() != 0
+	return c
+}
+
+// int decodes a signed varint which must fit in an int.
+func (r *binaryReader) int() int {
+	v := r.varint()
+	if int64(int(v)) != v {
+		r.fail("integer %d overflows int", v)
+		return 0
+	}
+	return int(v)
+}
+
+// record decodes the fields of an Error record, without its tag.
+func (r *binaryReader) record() *xerr {
+	e := &xerr{xerrExt: &xerrExt{}, code: r.int()}
+	e.message = r.string()
+	flags := r.uvarint()
+	if flags&_binaryHasDomain != 0 {
+		e.domain = r.string()
+	}
+	if flags&_binaryHasID != 0 {
+		e.id = r.string()
+	}
+	if flags&_binaryHasKind != 0 {
+		e.kind = Kind(r.string())
+	}
+	if flags&_binaryHasOp != 0 {
+		e.op = r.string()
+	}
+	if flags&_binaryHasSeverity != 0 {
+		e.severity = Severity(r.int())
+	}
+	if flags&_binaryHasPosition != 0 {
+		e.position = &Position{File: r.string()}
+		e.position.Line = r.int()
+		e.position.Column = r.int()
+		e.position.Offset = r.varint()
+	}
+	if flags&_binaryHasCaller != 0 {
+		c := r.frame()
+		e.caller = &c
+	}
+	if flags&_binaryHasStack != 0 {
+		e.stack = make([]CallerInfo, r.count())
+		for i := range e.stack {
+			e.stack[i] = r.frame()
+		}
+	}
+	if flags&_binaryHasHints != 0 {
+		e.hints = make([]string, r.count())
+		for i := range e.hints {
+			e.hints[i] = r.string()
+		}
+	}
+	if flags&_binaryHasAttrs != 0 {
+		n := r.count()
+		e.attrs = make(map[string]any, n)
+		for range n {
+			k := r.string()
+			e.attrs[k] = r.value(0)
+		}
+	}
+	return e
+}
+
+// string decodes a length-prefixed string.
+func (r *binaryReader) string() string {
+	return string(r.bytes())
+}
+
+// uvarint decodes an unsigned varint.
+func (r *binaryReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[min(r.off, len(r.data)):])
+	if n <= 0 {
+		r.fail("invalid varint")
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+// value decodes a typed attribute value nested at the given depth.
+func (r *binaryReader) value(depth int) any {
+	if depth >= _maxBinaryNesting {
+		r.fail("attribute values are nested too deeply")
+		return nil
+	}
+	switch tag := r.byte(); tag {
+	case _binaryNil:
+		return nil
+	case _binaryFalse:
+		return false
+	case _binaryTrue:
+		return true
+	case _binaryInt:
+		return r.int()
+	case _binaryUint:
+		return r.uvarint()
+	case _binaryFloat:
+		if len(r.data)-r.off < 8 {
+			r.fail("unexpected end of data")
+			return nil
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.off:]))
+		r.off += 8
+		return v
+	case _binaryString:
+		return r.string()
+	case _binaryBytes:
+		return slices.Clone(r.bytes())
+	case _binaryDuration:
+		return time.Duration(r.varint())
+	case _binaryTime:
+		return time.Unix(0, r.varint()).UTC()
+	case _binaryGroup:
+		n := r.count()
+		group := make(AttrGroup, n)
+		for range n {
+			k := r.string()
+			group[k] = r.value(depth + 1)
+		}
+		return group
+	case _binarySlice:
+		items := make([]any, r.count())
+		for i := range items {
+			items[i] = r.value(depth + 1)
+		}
+		return items
+	case _binaryJSON:
+		var v any
+		if err := json.Unmarshal(r.bytes(), &v); err != nil && r.err == nil {
+			r.fail("invalid JSON attribute value: %v", err)
+		}
+		return v
+	default:
+		if r.err == nil {
+			r.fail("unknown attribute value tag 0x%02x", tag)
+		}
+		return nil
+	}
+}
+
+// varint decodes a signed varint.
+func (r *binaryReader) varint() int64 {
+	v, n := binary.Varint(r.data[min(r.off, len(r.data)):])
+	if n <= 0 {
+		r.fail("invalid varint")
+		return 0
+	}
+	r.off += n
+	return v
+}
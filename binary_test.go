@@ -0,0 +1,93 @@
+package xerrors
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// benchBinaryError returns an error with the fields typically set on errors serialized into event streams.
+func benchBinaryError() Error {
+	cause := errors.New("connection refused")
+	return Wrap(1042, cause, "failed to charge card").
+		WithID("01J9ZQ3V5X8Y2K6M4N7P0R1S3T").WithKind(KindDeadlineExceeded).WithOp("billing.Charge").
+		WithSeverity(SeverityCritical).WithHint("retry later").WithAttr("user", "alice").WithAttr("attempt", 3).
+		WithAttr("elapsed", 1500*time.Millisecond).WithGroup("db").WithAttr("query", "SELECT 1").WithAttr("rows", 0)
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	errs := append(CorpusErrors(), benchBinaryError())
+	for _, err := range errs {
+		parsed, pErr := ParseBinary(AppendBinary(nil, err))
+		if pErr != nil {
+			t.Errorf("%s: failed to parse: %v", err.Error(), pErr)
+			continue
+		}
+		// values encoded as JSON are decoded as maps, so the documents are compared once their keys are sorted
+		want, _ := err.MarshalJSON()
+		got, _ := parsed.MarshalJSON()
+		if want, got = canonicalJSON(t, want), canonicalJSON(t, got); !bytes.Equal(got, want) {
+			t.Errorf("binary round trip changed the error:\n got: %s\nwant: %s", got, want)
+		}
+	}
+}
+
+func TestParseBinaryRejectsTrailingData(t *testing.T) {
+	data := append(AppendBinary(nil, New(1, "trailing")), 0)
+	if _, err := ParseBinary(data); err == nil {
+		t.Error("expected an error for a document with trailing data")
+	}
+}
+
+func FuzzParseBinary(f *testing.F) {
+	for _, err := range CorpusErrors() {
+		f.Add(AppendBinary(nil, err))
+	}
+	f.Add(AppendBinary(nil, benchBinaryError()))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		err, pErr := ParseBinary(data)
+		if pErr != nil {
+			return
+		}
+		if _, pErr := ParseBinary(AppendBinary(nil, err)); pErr != nil {
+			t.Fatalf("failed to parse re-encoded document: %v", pErr)
+		}
+	})
+}
+
+func BenchmarkAppendBinary(b *testing.B) {
+	err := benchBinaryError()
+	buf := AppendBinary(nil, err)
+	b.ReportMetric(float64(len(buf)), "bytes/doc")
+	b.ReportAllocs()
+	for range b.N {
+		buf = AppendBinary(buf[:0], err)
+	}
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	err := benchBinaryError()
+	buf, _ := err.AppendJSON(nil)
+	b.ReportMetric(float64(len(buf)), "bytes/doc")
+	b.ReportAllocs()
+	for range b.N {
+		buf, _ = err.AppendJSON(buf[:0])
+	}
+}
+
+func BenchmarkParseBinary(b *testing.B) {
+	data := AppendBinary(nil, benchBinaryError())
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr, _ = ParseBinary(data)
+	}
+}
+
+func BenchmarkParseJSON(b *testing.B) {
+	data, _ := benchBinaryError().MarshalJSON()
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr, _ = ParseJSON(data)
+	}
+}
@@ -0,0 +1,234 @@
+package xerrors
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	_budgetBuckets = 10
+)
+
+// BudgetEvent describes a change in the health of a domain or code tracked by an [ErrorBudget].
+type BudgetEvent struct {
+	// Code is the error code whose health changed.  It is only meaningful if IsCode is true.
+	Code int
+
+	// Count is the number of errors recorded within the window when the change was detected.
+	Count int
+
+	// Domain is the domain whose health changed.  It is only meaningful if IsCode is false.
+	Domain string
+
+	// Healthy is true if the domain or code became healthy and false if it exceeded its threshold.
+	Healthy bool
+
+	// IsCode indicates that the event is for an error code rather than a domain.
+	IsCode bool
+
+	// Threshold is the number of errors allowed within the window.
+	Threshold int
+}
+
+// ErrorBudget tracks the number of errors recorded per domain and code over a sliding window of time.
+//
+// A domain or code is healthy while the number of errors recorded for it within the window is below its threshold.
+// The health can be checked directly (eg: to trip a circuit breaker) or observed by registering a callback using
+// [WithBudgetCallback].  Errors can be recorded as they are created by registering [ErrorBudget.Record] as a hook
+// using [RegisterHook].
+type ErrorBudget struct {
+	// unexported variables
+	callback         func(BudgetEvent)        // called when the health of a domain or code changes
+	codeThresholds   map[int]int              // per-code thresholds
+	codes            map[int]*budgetWindow    // windows by code
+	domainThresholds map[string]int           // per-domain thresholds
+	domains          map[string]*budgetWindow // windows by domain
+	mutex            sync.Mutex               // guards the budget
+	threshold        int                      // default threshold
+	window           time.Duration            // length of the sliding window
+}
+
+// ErrorBudgetOption is a function which configures an [ErrorBudget].
+type ErrorBudgetOption func(*ErrorBudget)
+
+// WithBudgetCallback sets a function which is called whenever a domain or code exceeds its threshold or becomes
+// healthy again.
+//
+// The callback is called synchronously from [ErrorBudget.Record] or the health check which detected the change.
+func WithBudgetCallback(callback func(BudgetEvent)) ErrorBudgetOption {
+	return func(b *ErrorBudget) {
+		b.callback = callback
+	}
+}
+
+// WithCodeThreshold overrides the threshold for the given error code.
+func WithCodeThreshold(code, threshold int) ErrorBudgetOption {
+	return func(b *ErrorBudget) {
+		b.codeThresholds[code] = threshold
+	}
+}
+
+// WithDomainThreshold overrides the threshold for the given domain.
+func WithDomainThreshold(domain string, threshold int) ErrorBudgetOption {
+	return func(b *ErrorBudget) {
+		b.domainThresholds[domain] = threshold
+	}
+}
+
+// NewErrorBudget creates a new [ErrorBudget] which allows up to threshold errors per domain and code within the
+// given sliding window.
+func NewErrorBudget(window time.Duration, threshold int, opts ...ErrorBudgetOption) *ErrorBudget {
+	b := &ErrorBudget{
+		codeThresholds:   make(map[int]int),
+		codes:            make(map[int]*budgetWindow),
+		domainThresholds: make(map[string]int),
+		domains:          make(map[string]*budgetWindow),
+		threshold:        threshold,
+		window:           window,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// CodeHealthy returns true if the number of errors with the given code recorded within the window is below its
+// threshold.
+//
+// This call is thread-safe.
+func (b *ErrorBudget) CodeHealthy(code int) bool {
+	now := time.Now()
+	b.mutex.Lock()
+	w := b.codes[code]
+	var event *BudgetEvent
+	healthy := true
+	if w != nil {
+		event = w.update(now, 0, BudgetEvent{Code: code, IsCode: true, Threshold: b.codeThreshold(code)})
+		healthy = w.healthy
+	}
+	b.mutex.Unlock()
+
+	b.notify(event)
+	return healthy
+}
+
+// Healthy returns true if the number of errors in the given domain recorded within the window is below its
+// threshold.
+//
+// This call is thread-safe.
+func (b *ErrorBudget) Healthy(domain string) bool {
+	now := time.Now()
+	b.mutex.Lock()
+	w := b.domains[domain]
+	var event *BudgetEvent
+	healthy := true
+	if w != nil {
+		event = w.update(now, 0, BudgetEvent{Domain: domain, Threshold: b.domainThreshold(domain)})
+		healthy = w.healthy
+	}
+	b.mutex.Unlock()
+
+	b.notify(event)
+	return healthy
+}
+
+// Record records the given error against its domain and code.
+//
+// This call is thread-safe.
+func (b *ErrorBudget) Record(err Error) {
+	if err == nil {
+		return
+	}
+	now := time.Now()
+	code, domain := err.Code(), err.Domain()
+
+	b.mutex.Lock()
+	cw, ok := b.codes[code]
+	if !ok {
+		cw = newBudgetWindow(b.window, now)
+		b.codes[code] = cw
+	}
+	codeEvent := cw.update(now, 1, BudgetEvent{Code: code, IsCode: true, Threshold: b.codeThreshold(code)})
+	dw, ok := b.domains[domain]
+	if !ok {
+		dw = newBudgetWindow(b.window, now)
+		b.domains[domain] = dw
+	}
+	domainEvent := dw.update(now, 1, BudgetEvent{Domain: domain, Threshold: b.domainThreshold(domain)})
+	b.mutex.Unlock()
+
+	b.notify(codeEvent)
+	b.notify(domainEvent)
+}
+
+// codeThreshold returns the threshold for the given code.
+func (b *ErrorBudget) codeThreshold(code int) int {
+	if threshold, ok := b.codeThresholds[code]; ok {
+		return threshold
+	}
+	return b.threshold
+}
+
+// domainThreshold returns the threshold for the given domain.
+func (b *ErrorBudget) domainThreshold(domain string) int {
+	if threshold, ok := b.domainThresholds[domain]; ok {
+		return threshold
+	}
+	return b.threshold
+}
+
+// notify calls the callback with the given event, if there is one.
+func (b *ErrorBudget) notify(event *BudgetEvent) {
+	if event != nil && b.callback != nil {
+		b.callback(*event)
+	}
+}
+
+// budgetWindow counts errors over a sliding window using a fixed number of buckets.
+type budgetWindow struct {
+	// unexported variables
+	bucketSize time.Duration       // length of time covered by each bucket
+	buckets    [_budgetBuckets]int // error counts per bucket
+	current    int                 // index of the current bucket
+	healthy    bool                // whether or not the count was below the threshold at the last update
+	started    time.Time           // when the current bucket started
+}
+
+// newBudgetWindow creates a new window of the given length starting at the given time.
+func newBudgetWindow(window time.Duration, now time.Time) *budgetWindow {
+	return &budgetWindow{
+		bucketSize: max(window/_budgetBuckets, 1),
+		healthy:    true,
+		started:    now,
+	}
+}
+
+// update advances the window to the given time, adds n errors to the current bucket and returns an event filled in
+// from the template if the health of the window changed.
+func (w *budgetWindow) update(now time.Time, n int, template BudgetEvent) *BudgetEvent {
+	// expire the buckets which have fallen out of the window
+	for elapsed := now.Sub(w.started); elapsed >= w.bucketSize; elapsed -= w.bucketSize {
+		w.current = (w.current + 1) % _budgetBuckets
+		w.buckets[w.current] = 0
+		w.started = w.started.Add(w.bucketSize)
+		if elapsed >= w.bucketSize*_budgetBuckets {
+			w.buckets = [_budgetBuckets]int{}
+			w.started = now
+			break
+		}
+	}
+	w.buckets[w.current] += n
+
+	count := 0
+	for _, c := range w.buckets {
+		count += c
+	}
+	healthy := count < template.Threshold
+	if healthy == w.healthy {
+		return nil
+	}
+	w.healthy = healthy
+	template.Count = count
+	template.Healthy = healthy
+	return &template
+}
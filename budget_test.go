@@ -0,0 +1,83 @@
+package xerrors
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestErrorBudgetTracksThresholds(t *testing.T) {
+	var events []BudgetEvent
+	budget := NewErrorBudget(time.Hour, 3, WithCodeThreshold(2, 1), WithDomainThreshold("db", 2),
+		WithBudgetCallback(func(event BudgetEvent) {
+			events = append(events, event)
+		}))
+	factory := NewFactory(WithDomain("db"))
+
+	budget.Record(factory.New(1, "failed"))
+	if !budget.Healthy("db") || !budget.CodeHealthy(1) {
+		t.Error("expected the domain and code to be healthy after one error")
+	}
+	budget.Record(factory.New(1, "failed"))
+	if budget.Healthy("db") || !budget.CodeHealthy(1) {
+		t.Error("expected only the domain to exceed its threshold of 2")
+	}
+	budget.Record(New(2, "failed"))
+	if budget.CodeHealthy(2) || !budget.Healthy("") {
+		t.Error("expected only code 2 to exceed its threshold of 1")
+	}
+	if !budget.Healthy("unknown") || !budget.CodeHealthy(3) {
+		t.Error("expected domains and codes without errors to be healthy")
+	}
+
+	want := []BudgetEvent{
+		{Domain: "db", Count: 2, Threshold: 2},
+		{Code: 2, IsCode: true, Count: 1, Threshold: 1},
+	}
+	if len(events) != len(want) || events[0] != want[0] || events[1] != want[1] {
+		t.Errorf("events = %+v, want %+v", events, want)
+	}
+}
+
+func TestErrorBudgetRecoversOnceErrorsExpire(t *testing.T) {
+	var mutex sync.Mutex
+	var events []BudgetEvent
+	budget := NewErrorBudget(50*time.Millisecond, 1, WithBudgetCallback(func(event BudgetEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	}))
+	budget.Record(New(1, "failed"))
+	if budget.CodeHealthy(1) {
+		t.Fatal("expected the code to exceed its threshold")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !budget.CodeHealthy(1) {
+		t.Error("expected the code to be healthy once the window has passed")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if last := events[len(events)-1]; !last.Healthy || !last.IsCode || last.Code != 1 || last.Count != 0 {
+		t.Errorf("unexpected recovery event: %+v", last)
+	}
+}
+
+func TestErrorBudgetIsThreadSafe(t *testing.T) {
+	budget := NewErrorBudget(time.Hour, 800)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				budget.Record(New(1, "failed"))
+				budget.Healthy("")
+			}
+		}()
+	}
+	wg.Wait()
+	if budget.CodeHealthy(1) {
+		t.Error("expected the code to exceed its threshold after 800 errors")
+	}
+}
@@ -1,19 +1,23 @@
 package xerrors
 
 import (
+	"encoding/json"
+	"fmt"
+	"path"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
-	"sync"
 )
 
 const (
-	_unknownString = "???"
-)
+	// _autogeneratedFile is the file name the runtime reports for compiler-generated functions, eg: wrapper methods.
+	_autogeneratedFile = "<autogenerated>"
+
+	// _maxSyntheticFrames is the maximum number of synthetic frames skipped when looking for the caller.
+	_maxSyntheticFrames = 8
 
-var (
-	_captureCaller      = false
-	_callerFilePrefixes = []string{}
-	_callerMutex        sync.Mutex
+	_unknownString = "???"
 )
 
 // CaptureCallerInfo controls whether the caller info should be captured when a new error is generated.
@@ -21,9 +25,19 @@ var (
 // This function enables or disables the capture of the caller information globally for this package.  This call is
 // thread-safe.
 func CaptureCallerInfo(enable bool) {
-	_callerMutex.Lock()
-	_captureCaller = enable
-	_callerMutex.Unlock()
+	UpdateConfig(func(c *Config) {
+		c.CaptureCaller = enable
+	})
+}
+
+// CaptureStackTrace controls the maximum number of stack frames captured when a new error is generated.
+//
+// A depth of 0 (the default) disables capturing stack traces.  Individual factories can override this setting using
+// [WithStackDepth].  This call is thread-safe.
+func CaptureStackTrace(depth int) {
+	UpdateConfig(func(c *Config) {
+		c.StackDepth = depth
+	})
 }
 
 // StripCallerFilePrefixes allows you to specify a list of file prefixes that should be stripped from the file path
@@ -33,9 +47,9 @@ func CaptureCallerInfo(enable bool) {
 //
 // This function affects all [CallerInfo] objects generated globally by this package.  This call is thread-safe.
 func StripCallerFilePrefixes(prefixes ...string) {
-	_callerMutex.Lock()
-	_callerFilePrefixes = prefixes
-	_callerMutex.Unlock()
+	UpdateConfig(func(c *Config) {
+		c.CallerFilePrefixes = slices.Clone(prefixes)
+	})
 }
 
 // CallerInfo holds information about the location from which the error was generated.
@@ -48,6 +62,97 @@ type CallerInfo struct {
 
 	// Func is the name of the function in which the error occurred.
 	Func string `json:"func"`
+
+	// Package is the import path of the package containing the function in which the error occurred.
+	Package string `json:"package,omitempty"`
+
+	// Receiver is the receiver type of the method in which the error occurred (eg: "*Server"), if any.
+	Receiver string `json:"receiver,omitempty"`
+
+	// PC is the program counter of the frame in which the error occurred.
+	PC uintptr `json:"-"`
+
+	// Entry is the entry address of the function in which the error occurred.
+	Entry uintptr `json:"-"`
+
+	// Synthetic is true if the frame does not correspond to a location in the source code, eg: a wrapper method
+	// generated by the compiler or a frame without symbol information inside cgo code.  The Func of frames without
+	// symbol information holds their program counter, eg: "0x4a1b2c".
+	Synthetic bool `json:"synthetic,omitempty"`
+}
+
+// String returns the caller information in a compact form suitable for logging, eg: "xerrors/caller.go:123
+// (GetCallerInfo)".
+func (c CallerInfo) String() string {
+	file := path.Base(c.File)
+	if c.Package != "" {
+		file = path.Base(c.Package) + "/" + file
+	}
+	fn := c.Func
+	if c.Package != "" && strings.HasPrefix(fn, c.Package+".") {
+		fn = fn[len(c.Package)+1:]
+	}
+	return fmt.Sprintf("%s:%d (%s)", file, c.Line, fn)
+}
+
+// Compact returns the caller information as a single string of the form "file.go:123 pkg.Func", as used when a
+// [MarshalProfile] has CompactCaller set.
+func (c CallerInfo) Compact() string {
+	return c.File + ":" + strconv.Itoa(c.Line) + " " + c.shortFunc()
+}
+
+// UnmarshalJSON unmarshals the caller information from either its structured JSON object or its compact string form
+// (see [CallerInfo.Compact]).
+//
+// The package of a caller parsed from the compact form is not known, so its function name keeps the package name.
+// The function entry address and offset written when a [MarshalProfile] has Symbolicate set are restored into the
+// Entry and PC fields.
+func (c *CallerInfo) UnmarshalJSON(data []byte) error {
+	var compact string
+	if err := json.Unmarshal(data, &compact); err != nil {
+		type structured CallerInfo
+		var doc struct {
+			*structured
+
+			// Entry is the hexadecimal address of the function entry.
+			Entry string `json:"entry"`
+
+			// Offset is the offset of the program counter from the function entry.
+			Offset uint64 `json:"offset"`
+		}
+		doc.structured = (*structured)(c)
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if doc.Entry != "" {
+			entry, err := strconv.ParseUint(strings.TrimPrefix(doc.Entry, "0x"), 16, 64)
+			if err != nil {
+				return fmt.Errorf("invalid caller entry: %q", doc.Entry)
+			}
+			c.Entry = uintptr(entry)
+			c.PC = uintptr(entry + doc.Offset)
+		}
+		return nil
+	}
+	location, fn, _ := strings.Cut(compact, " ")
+	i := strings.LastIndexByte(location, ':')
+	if i < 0 {
+		return fmt.Errorf("invalid caller: %q", compact)
+	}
+	line, err := strconv.Atoi(location[i+1:])
+	if err != nil {
+		return fmt.Errorf("invalid caller: %q", compact)
+	}
+	*c = CallerInfo{File: location[:i], Line: line, Func: fn}
+	return nil
+}
+
+// shortFunc returns the function name qualified with the last element of the package path, eg: "xerrors.New".
+func (c CallerInfo) shortFunc() string {
+	if c.Package != "" && strings.HasPrefix(c.Func, c.Package+".") {
+		return path.Base(c.Package) + c.Func[len(c.Package):]
+	}
+	return c.Func
 }
 
 // DefaultCallerInfo returns a default [CallerInfo] that indicates that no caller information was captured.
@@ -62,33 +167,166 @@ func DefaultCallerInfo() *CallerInfo {
 // GetCallerInfo retrieves the file path, line number, and function name of the caller, formatting the file path to
 // be relative to the package directory.
 //
-// If the caller information is not available, a default [CallerInfo] is returned.
+// Frames belonging to helper functions (see [MarkHelper]) and synthetic frames (see [CallerInfo]) are skipped.  If
+// only synthetic frames are found, the first one is returned.  If the caller information is not available at all, a
+// default [CallerInfo] is returned.
 //
 // The 'skip' parameter indicates how many stack frames to ascend with 0 being the immediate caller of this function.
 //
 // This function does not have to be called directly if you are using the [New], [Newf], [Wrap] or [Wrapf] functions
 // to generate errors and you have enabled caller capture using [CaptureCallerInfo].
 func GetCallerInfo(skip int) *CallerInfo {
-	// runtime.Caller returns the program counter (pc), file path, line number, and success status.
-	pc, file, line, ok := runtime.Caller(2 + skip)
-	if !ok {
+	// the program counters are resolved into logical frames, which expands any calls inlined by the compiler, so
+	// skipping frames counts functions rather than physical stack frames
+	var pcs [_maxSyntheticFrames + _maxHelperFrames]uintptr
+	n := runtime.Callers(3+skip, pcs[:])
+	if n == 0 {
 		return DefaultCallerInfo()
 	}
 
-	// get the full function name
-	fn := runtime.FuncForPC(pc).Name()
+	skipHelpers := hasHelpers()
+	var first *CallerInfo
+	frames := runtime.CallersFrames(pcs[:n])
+	for synthetic := 0; synthetic < _maxSyntheticFrames; {
+		frame, more := frames.Next()
+		if skipHelpers && isHelper(frame.Function) {
+			if !more {
+				break
+			}
+			continue
+		}
+		skipHelpers = false
+		info := newCallerInfo(frame.Function, frame.File, frame.Line, frame.PC, frame.Entry)
+		if !info.Synthetic {
+			return info
+		}
+		if first == nil {
+			first = info
+		}
+		synthetic++
+		if !more {
+			break
+		}
+	}
+	if first != nil {
+		return first
+	}
+	return DefaultCallerInfo()
+}
+
+// GetStackTrace retrieves up to depth stack frames starting from the caller, formatting each file path in the same
+// way as [GetCallerInfo].
+//
+// Any leading frames belonging to helper functions (see [MarkHelper]) are skipped.  Synthetic frames (see
+// [CallerInfo]) are kept and marked as such, since they may still help to explain how the failing code was reached.
+//
+// The 'skip' parameter indicates how many stack frames to ascend with 0 being the immediate caller of this function.
+//
+// This function does not have to be called directly if you are using the [New], [Newf], [Wrap] or [Wrapf] functions
+// to generate errors and you have enabled stack trace capture using [CaptureStackTrace].
+func GetStackTrace(skip, depth int) []CallerInfo {
+	if depth <= 0 {
+		return nil
+	}
+	// leave room for any leading helper frames which need to be skipped
+	skipHelpers := hasHelpers()
+	size := depth
+	if skipHelpers {
+		size += _maxHelperFrames
+	}
+	pcs := make([]uintptr, size)
+	n := runtime.Callers(3+skip, pcs)
+	if n == 0 {
+		return nil
+	}
 
-	// strip the first matching prefix from the file path
-	for _, prefix := range _callerFilePrefixes {
-		if strings.HasPrefix(file, prefix) {
-			file = file[len(prefix):]
+	stack := make([]CallerInfo, 0, min(n, depth))
+	frames := runtime.CallersFrames(pcs[:n])
+	for len(stack) < depth {
+		frame, more := frames.Next()
+		if skipHelpers && isHelper(frame.Function) {
+			if !more {
+				break
+			}
+			continue
+		}
+		skipHelpers = false
+		stack = append(stack, *newCallerInfo(frame.Function, frame.File, frame.Line, frame.PC, frame.Entry))
+		if !more {
 			break
 		}
 	}
+	return stack
+}
 
-	return &CallerInfo{
-		File: file,
-		Line: line,
-		Func: fn,
+// newCallerInfo creates a new [CallerInfo] from the details of a stack frame.
+//
+// Frames of compiler-generated functions and frames without a function name or file are marked as synthetic.
+func newCallerInfo(fn, file string, line int, pc, entry uintptr) *CallerInfo {
+	info := &CallerInfo{
+		File:      stripCallerFilePrefix(file),
+		Line:      line,
+		Func:      fn,
+		PC:        pc,
+		Entry:     entry,
+		Synthetic: fn == "" || file == "" || file == _autogeneratedFile,
+	}
+	if file == "" {
+		info.File = _unknownString
+	}
+	switch {
+	case fn != "":
+		info.Package = funcPackage(fn)
+		info.Receiver = funcReceiver(fn, info.Package)
+	case pc != 0:
+		info.Func = "0x" + strconv.FormatUint(uint64(pc), 16)
+	default:
+		info.Func = _unknownString
+	}
+	return info
+}
+
+// funcReceiver returns the receiver type from a fully-qualified method name or an empty string if the function is
+// not a method.
+func funcReceiver(fn, pkg string) string {
+	if len(fn) <= len(pkg)+1 {
+		return ""
+	}
+	name := fn[len(pkg)+1:]
+
+	// pointer receivers are wrapped in parentheses, eg: (*Server).Start
+	if strings.HasPrefix(name, "(") {
+		if end := strings.Index(name, ")"); end > 0 {
+			return name[1:end]
+		}
+		return ""
+	}
+
+	// value receivers are only separated by a dot, but so are closures (eg: main.func1) and init functions; the dot
+	// is searched for after the type arguments of generic types and functions, which are elided as "[...]"
+	offset := 0
+	if open := strings.IndexAny(name, ".["); open >= 0 && name[open] == '[' {
+		if end := strings.IndexByte(name[open:], ']'); end > 0 {
+			offset = open + end + 1
+		}
+	}
+	dot := strings.IndexByte(name[offset:], '.')
+	if dot < 0 {
+		return ""
+	}
+	recv, rest := name[:offset+dot], name[offset+dot+1:]
+	if strings.HasPrefix(rest, "func") || strings.HasPrefix(rest, "gowrap") || strings.Trim(rest, "0123456789") == "" {
+		return ""
+	}
+	return recv
+}
+
+// stripCallerFilePrefix strips the first matching prefix set by [StripCallerFilePrefixes] from the file path.
+func stripCallerFilePrefix(file string) string {
+	for _, prefix := range loadConfig().CallerFilePrefixes {
+		if strings.HasPrefix(file, prefix) {
+			return file[len(prefix):]
+		}
 	}
+	return file
 }
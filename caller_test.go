@@ -0,0 +1,145 @@
+package xerrors
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// currentLine returns the line which called it.
+//
+//go:noinline
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// captureCallers enables caller capture until the end of the test.
+func captureCallers(t *testing.T) {
+	t.Helper()
+	CaptureCallerInfo(true)
+	t.Cleanup(func() { CaptureCallerInfo(false) })
+}
+
+// genericNew creates an error inside a generic function.
+func genericNew[T any](value T) Error {
+	return New(1, "generic").WithAttr("value", value)
+}
+
+// genericStack is a generic type whose methods create errors.
+type genericStack[T any] struct {
+	items []T
+}
+
+func (s *genericStack[T]) pop() (T, Error) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, New(1, "empty stack")
+	}
+	item := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return item, nil
+}
+
+// callerSource returns the caller of its method, so that it can be called through compiler-generated wrappers.
+type callerSource struct{}
+
+func (callerSource) caller() *CallerInfo {
+	return GetCallerInfo(0)
+}
+
+// embeddedCallerSource promotes the caller method, which the compiler implements using a generated wrapper when it
+// is called through an interface.
+type embeddedCallerSource struct {
+	callerSource
+}
+
+func TestGetCallerInfoInGenericFunctions(t *testing.T) {
+	captureCallers(t)
+	caller := genericNew(42).Caller()
+	if caller.Synthetic || caller.Func != "go.innotegrity.dev/xerrors.genericNew[...]" ||
+		!strings.HasSuffix(caller.File, "caller_test.go") {
+		t.Errorf("unexpected caller of error created in generic function: %+v", caller)
+	}
+
+	var s genericStack[string]
+	_, err := s.pop()
+	caller = err.Caller()
+	if caller.Synthetic || caller.Receiver != "*genericStack[...]" {
+		t.Errorf("unexpected caller of error created in generic method: %+v", caller)
+	}
+}
+
+func TestGetCallerInfoThroughGeneratedWrappers(t *testing.T) {
+	var source interface{ caller() *CallerInfo } = &embeddedCallerSource{}
+	caller, line := source.caller(), currentLine()
+	if caller.Synthetic || caller.Line != line || !strings.HasSuffix(caller.File, "caller_test.go") {
+		t.Errorf("expected caller at caller_test.go:%d, got %+v", line, caller)
+	}
+	if caller.Func != "go.innotegrity.dev/xerrors.TestGetCallerInfoThroughGeneratedWrappers" {
+		t.Errorf("expected caller to be the test function, got %s", caller.Func)
+	}
+}
+
+func TestNewCallerInfo(t *testing.T) {
+	tests := map[string]struct {
+		fn, file  string
+		pc        uintptr
+		want      CallerInfo
+		synthetic bool
+	}{
+		"function": {
+			fn: "example.com/app/user.Create", file: "/src/user.go",
+			want: CallerInfo{Func: "example.com/app/user.Create", File: "/src/user.go", Package: "example.com/app/user"},
+		},
+		"pointer method": {
+			fn: "example.com/app/user.(*Service).Create", file: "/src/user.go",
+			want: CallerInfo{Func: "example.com/app/user.(*Service).Create", File: "/src/user.go",
+				Package: "example.com/app/user", Receiver: "*Service"},
+		},
+		"generic method": {
+			fn: "example.com/app/list.(*List[...]).Push", file: "/src/list.go",
+			want: CallerInfo{Func: "example.com/app/list.(*List[...]).Push", File: "/src/list.go",
+				Package: "example.com/app/list", Receiver: "*List[...]"},
+		},
+		"generic function": {
+			fn: "example.com/app/list.Map[...]", file: "/src/list.go",
+			want: CallerInfo{Func: "example.com/app/list.Map[...]", File: "/src/list.go", Package: "example.com/app/list"},
+		},
+		"generic value method": {
+			fn: "example.com/app/list.List[...].Len", file: "/src/list.go",
+			want: CallerInfo{Func: "example.com/app/list.List[...].Len", File: "/src/list.go",
+				Package: "example.com/app/list", Receiver: "List[...]"},
+		},
+		"closure in generic function": {
+			fn: "example.com/app/list.Map[...].func1", file: "/src/list.go",
+			want: CallerInfo{Func: "example.com/app/list.Map[...].func1", File: "/src/list.go",
+				Package: "example.com/app/list"},
+		},
+		"value method": {
+			fn: "example.com/app/user.Service.Create", file: "/src/user.go",
+			want: CallerInfo{Func: "example.com/app/user.Service.Create", File: "/src/user.go",
+				Package: "example.com/app/user", Receiver: "Service"},
+		},
+		"closure": {
+			fn: "example.com/app.main.func1", file: "/src/main.go",
+			want: CallerInfo{Func: "example.com/app.main.func1", File: "/src/main.go", Package: "example.com/app"},
+		},
+		"generated wrapper": {
+			fn: "example.com/app/user.(*Service).Close", file: _autogeneratedFile,
+			want: CallerInfo{Func: "example.com/app/user.(*Service).Close", File: _autogeneratedFile,
+				Package: "example.com/app/user", Receiver: "*Service", Synthetic: true},
+		},
+		"no symbols": {
+			pc:   0x4a1b2c,
+			want: CallerInfo{Func: "0x4a1b2c", File: _unknownString, PC: 0x4a1b2c, Synthetic: true},
+		},
+	}
+	for name, test := range tests {
+		got := *newCallerInfo(test.fn, test.file, 10, test.pc, 0)
+		test.want.Line = 10
+		if got != test.want {
+			t.Errorf("%s: got %+v, want %+v", name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package xerrors
+
+import (
+	"reflect"
+	"slices"
+)
+
+// HasCode returns true if any [Error] in the chain of the given error has the given code.
+//
+// Besides the wrapped errors, the causes added using WithCause (including those in attribute groups) and the errors
+// joined using [errors.Join] or a [MultiError] are searched as well, along with their own chains.  The search stops
+// at the maximum depth set by [SetMaxChainDepth].
+func HasCode(err error, code int) bool {
+	return hasCode(err, code, loadConfig().MaxChainDepth)
+}
+
+// hasCode returns true if any [Error] in the tree of the given error, up to the given depth, has the given code.
+func hasCode(err error, code, depth int) bool {
+	if depth < 1 {
+		return false
+	}
+	found := false
+	walkChain(err, func(err error) bool {
+		switch err := err.(type) {
+		case Error:
+			found = err.Code() == code || attrsHaveCode(err.Attrs(), code, depth-1)
+		case interface{ Unwrap() []error }:
+			for _, joined := range err.Unwrap() {
+				if found = hasCode(joined, code, depth-1); found {
+					break
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// attrsHaveCode returns true if any of the causes in the attributes has the given code.
+func attrsHaveCode(attrs map[string]any, code, depth int) bool {
+	for _, v := range attrs {
+		switch v := v.(type) {
+		case AttrGroup:
+			if attrsHaveCode(v, code, depth) {
+				return true
+			}
+		case error:
+			if hasCode(v, code, depth) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PreparedCause is an error created by this package which is stored as an attribute value, eg: a secondary cause added
+// using WithCause, in the form returned by [PrepareAttrs].
+//
+// Its attributes are prepared using the profile of the outermost error, so that marshaling the prepared attributes
+// (eg: in an RFC 9457 problem document) applies the same rules to the causes as [MarshalWithProfile].
+type PreparedCause struct {
+	// Attrs contains the prepared attributes of the cause or nil if there are none.
+	Attrs map[string]any
+
+	// Err is the cause itself.
+	Err Error
+
+	// unexported variables
+	profile *MarshalProfile // resolved profile of the outermost error
+	root    *xerr           // outermost error or nil if it was not created by this package
+}
+
+// Error returns the message of the cause.
+func (c *PreparedCause) Error() string {
+	return c.Err.Error()
+}
+
+// MarshalJSON marshals the cause to JSON using the profile of the outermost error.
+func (c *PreparedCause) MarshalJSON() ([]byte, error) {
+	cause := c.Err.(*xerr)
+	causes := newNesting(c.root, c.profile)
+	causes.enter(cause)
+	return cause.appendNestedJSON(nil, &causes), nil
+}
+
+// Unwrap returns the cause itself.
+func (c *PreparedCause) Unwrap() error {
+	return c.Err
+}
+
+// nesting tracks the errors stored as attribute values (eg: the secondary causes added using WithCause) and the maps
+// and slices which are being marshaled.
+//
+// The causes are marshaled using the profile of the outermost error, so that eg: a client-facing profile also omits
+// the classified attributes of the causes.  Causes which have already been marshaled (ie: which refer back to an
+// error containing them) or which are nested deeper than the maximum depth set by [SetMaxChainDepth] are replaced
+// with the [TruncationMarker], and maps and slices which contain themselves are replaced with a placeholder.
+type nesting struct {
+	// unexported variables
+	containers []container        // maps and slices which are being marshaled
+	depth      int                // number of levels of causes which can still be marshaled
+	profile    *MarshalProfile    // resolved profile of the outermost error or nil if no error is being marshaled
+	root       *xerr              // outermost error or nil if it was not created by this package
+	seen       map[*xerr]struct{} // causes which have already been marshaled or nil if there are none
+}
+
+// container identifies a map or a slice by its address and, for slices, its length, in the same way as
+// [json.Marshal] does to detect cycles.
+type container struct {
+	// unexported variables
+	len int     // length of the slice
+	ptr uintptr // address of the map or of the first element of the slice
+}
+
+// newNesting returns the state for marshaling the causes of the given error using the given resolved profile.
+func newNesting(root *xerr, profile *MarshalProfile) nesting {
+	return nesting{
+		depth:   loadConfig().MaxChainDepth,
+		profile: profile,
+		root:    root,
+	}
+}
+
+// enter records that the given cause is being marshaled and returns true, or returns false if it has already been
+// marshaled or the maximum depth has been reached.  Each call which returns true must be followed by a call to leave
+// once the cause has been marshaled.
+func (n *nesting) enter(cause *xerr) bool {
+	if n.depth <= 1 || cause == n.root {
+		return false
+	}
+	if _, ok := n.seen[cause]; ok {
+		return false
+	}
+	if n.seen == nil {
+		n.seen = make(map[*xerr]struct{})
+	}
+	n.seen[cause] = struct{}{}
+	n.depth--
+	return true
+}
+
+// leave records that the cause passed to the last successful call to enter has been marshaled.
+func (n *nesting) leave() {
+	n.depth++
+}
+
+// enterContainer records that the given map or slice is being marshaled and returns true, or returns false if it is
+// already being marshaled, ie: it contains itself.  Each call which returns true must be followed by a call to
+// leaveContainer once the value has been marshaled.
+func (n *nesting) enterContainer(value any) bool {
+	v := reflect.ValueOf(value)
+	c := container{ptr: v.Pointer()}
+	if v.Kind() == reflect.Slice {
+		c.len = v.Len()
+	}
+	if slices.Contains(n.containers, c) {
+		return false
+	}
+	n.containers = append(n.containers, c)
+	return true
+}
+
+// leaveContainer records that the map or slice passed to the last successful call to enterContainer has been
+// marshaled.
+func (n *nesting) leaveContainer() {
+	n.containers = n.containers[:len(n.containers)-1]
+}
@@ -0,0 +1,131 @@
+package xerrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cyclicErrors returns errors whose causes or attributes refer back to themselves.
+func cyclicErrors() map[string]Error {
+	a, b := New(1, "a"), New(2, "b")
+	a.WithCause("b", b)
+	b.WithCause("a", a)
+	self := New(3, "self")
+	self.WithAttr("self", self)
+	list := New(4, "list")
+	list.WithAttr("errors", []any{list})
+	grouped := New(5, "grouped")
+	grouped.WithGroup("db").WithAttr("self", grouped)
+	return map[string]Error{"mutual": a, "self": self, "list": list, "grouped": grouped}
+}
+
+func TestMarshalTruncatesCyclicCauses(t *testing.T) {
+	for name, err := range cyclicErrors() {
+		data, mErr := err.MarshalJSON()
+		if mErr != nil {
+			t.Errorf("%s: failed to marshal: %v", name, mErr)
+			continue
+		}
+		if !json.Valid(data) {
+			t.Errorf("%s: invalid JSON: %s", name, data)
+		}
+		if !bytes.Contains(data, []byte(`"`+TruncationMarker+`"`)) {
+			t.Errorf("%s: expected the truncation marker in %s", name, data)
+		}
+		if str := err.String(); str != string(data) {
+			t.Errorf("%s: String() = %s, MarshalJSON = %s", name, str, data)
+		}
+		if _, pErr := ParseBinary(AppendBinary(nil, err)); pErr != nil {
+			t.Errorf("%s: failed to parse the binary document: %v", name, pErr)
+		}
+	}
+}
+
+func TestMarshalTruncatesDeepCauses(t *testing.T) {
+	SetMaxChainDepth(3)
+	defer SetMaxChainDepth(DefaultMaxChainDepth)
+
+	err := New(0, "root")
+	last := err
+	for i := 1; i < 5; i++ {
+		cause := New(i, "cause")
+		last.WithCause("cause", cause)
+		last = cause
+	}
+	data, _ := err.MarshalJSON()
+	if got := bytes.Count(data, []byte(`"message":"cause"`)); got != 2 {
+		t.Errorf("expected 2 nested causes, got %d in %s", got, data)
+	}
+	if !bytes.Contains(data, []byte(`"cause":"`+TruncationMarker+`"`)) {
+		t.Errorf("expected the truncation marker in %s", data)
+	}
+}
+
+func TestMarshalUsesProfileOfOutermostError(t *testing.T) {
+	cause := New(2, "cause").WithClassifiedAttr("email", "alice@example.com", ClassificationPII)
+	err := New(1, "outer").WithCause("cause", cause)
+	profile := &MarshalProfile{OmitClassifications: []Classification{ClassificationPII}}
+
+	data, mErr := MarshalWithProfile(err, profile)
+	if mErr != nil {
+		t.Fatalf("failed to marshal: %v", mErr)
+	}
+	if strings.Contains(string(data), "alice@example.com") {
+		t.Errorf("the classified attribute of the cause was not omitted: %s", data)
+	}
+	if !strings.Contains(string(data), `"message":"cause"`) {
+		t.Errorf("the cause was not marshaled: %s", data)
+	}
+	if data, _ := err.MarshalJSON(); !strings.Contains(string(data), "alice@example.com") {
+		t.Errorf("the default profile omitted the attribute of the cause: %s", data)
+	}
+}
+
+func TestPrepareAttrsTruncatesCyclicCauses(t *testing.T) {
+	for name, err := range cyclicErrors() {
+		prepared := PrepareAttrs(err, nil)
+		data, mErr := json.Marshal(prepared)
+		if mErr != nil {
+			t.Errorf("%s: failed to marshal the prepared attributes: %v", name, mErr)
+			continue
+		}
+		if !bytes.Contains(data, []byte(`"`+TruncationMarker+`"`)) {
+			t.Errorf("%s: expected the truncation marker in %s", name, data)
+		}
+		if _, xErr := xml.Marshal(err); xErr != nil {
+			t.Errorf("%s: failed to marshal to XML: %v", name, xErr)
+		}
+	}
+}
+
+func TestPrepareAttrsUsesProfileOfOutermostError(t *testing.T) {
+	cause := New(2, "cause").WithClassifiedAttr("email", "alice@example.com", ClassificationPII).
+		WithAttr("user", "alice")
+	err := New(1, "outer").WithCause("cause", cause)
+	profile := &MarshalProfile{OmitClassifications: []Classification{ClassificationPII}}
+
+	prepared, ok := PrepareAttrs(err, profile)["cause"].(*PreparedCause)
+	if !ok {
+		t.Fatalf("expected the cause to be prepared, got %#v", PrepareAttrs(err, profile)["cause"])
+	}
+	if prepared.Err != cause {
+		t.Errorf("Err = %v, want %v", prepared.Err, cause)
+	}
+	if want := map[string]any{"user": "alice"}; len(prepared.Attrs) != 1 || prepared.Attrs["user"] != "alice" {
+		t.Errorf("Attrs = %v, want %v", prepared.Attrs, want)
+	}
+	data, mErr := json.Marshal(prepared)
+	if mErr != nil {
+		t.Fatalf("failed to marshal: %v", mErr)
+	}
+	if strings.Contains(string(data), "alice@example.com") {
+		t.Errorf("the classified attribute of the cause was not omitted: %s", data)
+	}
+	if prepared.Error() != "cause" || !errors.Is(prepared, cause) {
+		t.Errorf("the prepared cause does not behave as the cause: %v", prepared)
+	}
+}
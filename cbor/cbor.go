@@ -0,0 +1,21 @@
+// Package cbor encodes [xerrors.Error] objects using the Concise Binary Object Representation (CBOR) format.
+//
+// This package is distributed as a separate module so that the core xerrors module does not depend on a CBOR
+// library.
+package cbor
+
+import (
+	fxcbor "github.com/fxamacker/cbor/v2"
+	"go.innotegrity.dev/xerrors/internal/document"
+)
+
+// Marshal encodes the given error, including its chain of wrapped errors, as CBOR.
+//
+// Each error is encoded as a map containing its code, its own message (without the messages of the errors it wraps),
+// its attributes and its wrapped error, if any.  Errors which do not implement [xerrors.Error] are encoded with only
+// their message.  The attributes of each error are prepared using the marshal profile of the factory which created it
+// in the same way as for JSON (see [xerrors.PrepareAttrs]), so attributes omitted due to their classification are left
+// out, and the causes stored as attribute values are encoded without the errors they wrap.
+func Marshal(err error) ([]byte, error) {
+	return fxcbor.Marshal(document.New(err))
+}
@@ -0,0 +1,85 @@
+package cbor
+
+import (
+	"errors"
+	"testing"
+
+	fxcbor "github.com/fxamacker/cbor/v2"
+	"go.innotegrity.dev/xerrors"
+	"go.innotegrity.dev/xerrors/internal/document"
+)
+
+// roundTrip marshals the error and decodes the result into a document.
+func roundTrip(t *testing.T, err error) *document.Document {
+	t.Helper()
+	data, mErr := Marshal(err)
+	if mErr != nil {
+		t.Fatalf("failed to marshal: %v", mErr)
+	}
+	var doc document.Document
+	if uErr := fxcbor.Unmarshal(data, &doc); uErr != nil {
+		t.Fatalf("failed to unmarshal: %v", uErr)
+	}
+	return &doc
+}
+
+func TestMarshalKeepsZeroCode(t *testing.T) {
+	data, mErr := Marshal(xerrors.New(0, "zero"))
+	if mErr != nil {
+		t.Fatalf("failed to marshal: %v", mErr)
+	}
+	var doc map[string]any
+	if uErr := fxcbor.Unmarshal(data, &doc); uErr != nil {
+		t.Fatalf("failed to unmarshal: %v", uErr)
+	}
+	if _, ok := doc["code"]; !ok {
+		t.Errorf("the code was omitted: %v", doc)
+	}
+}
+
+func TestMarshalEncodesChain(t *testing.T) {
+	xerrors.ComposeMessages(true)
+	defer xerrors.ComposeMessages(false)
+
+	inner := xerrors.Wrap(2, errors.New("connection refused"), "failed to connect").WithOp("db.Connect")
+	outer := xerrors.Wrap(1, inner, "failed to load user").WithAttr("user", "alice")
+	doc := roundTrip(t, outer)
+	if doc.Code != 1 || doc.Message != "failed to load user" || doc.Attrs["user"] != "alice" {
+		t.Errorf("unexpected outer document: %+v", doc)
+	}
+	if doc.WrappedError == nil || doc.WrappedError.Code != 2 || doc.WrappedError.Message != "failed to connect" ||
+		doc.WrappedError.Op != "db.Connect" {
+		t.Fatalf("unexpected inner document: %+v", doc.WrappedError)
+	}
+	if leaf := doc.WrappedError.WrappedError; leaf == nil || leaf.Message != "connection refused" {
+		t.Errorf("unexpected wrapped error: %+v", leaf)
+	}
+}
+
+func TestMarshalTruncatesCyclicCauses(t *testing.T) {
+	a, b := xerrors.New(1, "a"), xerrors.New(2, "b")
+	a.WithCause("b", b)
+	b.WithCause("a", a)
+	doc := roundTrip(t, a)
+	cause, ok := doc.Attrs["b"].(map[any]any)
+	if !ok {
+		t.Fatalf("expected the cause to be a document, got %#v", doc.Attrs["b"])
+	}
+	attrs, _ := cause["attrs"].(map[any]any)
+	if cause["message"] != "b" || attrs["a"] != xerrors.TruncationMarker {
+		t.Errorf("unexpected cause document: %v", cause)
+	}
+}
+
+func TestMarshalOmitsClassifiedAttrsOfCauses(t *testing.T) {
+	factory := xerrors.NewFactory(xerrors.WithMarshalProfile(&xerrors.MarshalProfile{
+		OmitClassifications: []xerrors.Classification{xerrors.ClassificationPII},
+	}))
+	cause := xerrors.New(2, "cause").WithClassifiedAttr("email", "alice@example.com", xerrors.ClassificationPII).
+		WithAttr("user", "alice")
+	doc := roundTrip(t, factory.New(1, "outer").WithCause("cause", cause))
+	attrs, _ := doc.Attrs["cause"].(map[any]any)["attrs"].(map[any]any)
+	if _, ok := attrs["email"]; ok || attrs["user"] != "alice" {
+		t.Errorf("unexpected attributes of the cause: %v", attrs)
+	}
+}
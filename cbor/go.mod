@@ -0,0 +1,12 @@
+module go.innotegrity.dev/xerrors/cbor
+
+go 1.23
+
+replace go.innotegrity.dev/xerrors => ../
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.4
+	go.innotegrity.dev/xerrors v0.0.0-00010101000000-000000000000
+)
+
+require github.com/x448/float16 v0.8.4 // indirect
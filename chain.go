@@ -0,0 +1,83 @@
+package xerrors
+
+import (
+	"reflect"
+	"slices"
+)
+
+const (
+	// _chainScanSize is the number of errors at the start of a chain which are checked for cycles without a map.
+	_chainScanSize = 8
+
+	// DefaultMaxChainDepth is the default maximum number of errors visited when walking an error chain.
+	DefaultMaxChainDepth = 100
+
+	// TruncationMarker is rendered in place of the remainder of an error chain which exceeds the maximum depth or
+	// contains a cycle.
+	TruncationMarker = "[truncated]"
+)
+
+// SetMaxChainDepth sets the maximum number of errors visited when this package walks an error chain, eg: when
+// composing messages, collecting operations or marshaling.
+//
+// Together with cycle detection, this protects against chains which accidentally wrap themselves (eg: through
+// caching) or grow absurdly deep.  Chains which exceed the limit are truncated and rendered with the
+// [TruncationMarker].  A depth less than 1 restores the [DefaultMaxChainDepth].
+//
+// Note that the standard library [errors.Is] and [errors.As] functions are not protected by this limit.  This call
+// is thread-safe.
+func SetMaxChainDepth(depth int) {
+	UpdateConfig(func(c *Config) {
+		c.MaxChainDepth = depth
+	})
+}
+
+// Chain returns the errors in the chain of the given error, from the outermost to the innermost, by repeatedly
+// calling [errors.Unwrap] or, for errors created using github.com/pkg/errors, their Cause method.
+//
+// The walk stops once the maximum depth set by [SetMaxChainDepth] is reached or an error which has already been
+// visited is found again, in which case the second return value is true.
+func Chain(err error) ([]error, bool) {
+	var chain []error
+	truncated := walkChain(err, func(e error) bool {
+		chain = append(chain, e)
+		return true
+	})
+	return chain, truncated
+}
+
+// walkChain calls the function for each error in the chain of the given error until it returns false, returning
+// true if the chain was truncated because it was too deep or contained a cycle.
+func walkChain(err error, fn func(error) bool) bool {
+	maxDepth := loadConfig().MaxChainDepth
+
+	// short chains are checked for cycles without allocating, only longer ones need a map
+	var recent [_chainScanSize]error
+	var seen map[error]struct{}
+	for depth := 0; err != nil; depth++ {
+		if depth >= maxDepth {
+			return true
+		}
+		if reflect.TypeOf(err).Comparable() {
+			if slices.Contains(recent[:min(depth, _chainScanSize)], err) {
+				return true
+			}
+			if _, ok := seen[err]; ok {
+				return true
+			}
+			if depth < _chainScanSize {
+				recent[depth] = err
+			} else {
+				if seen == nil {
+					seen = make(map[error]struct{})
+				}
+				seen[err] = struct{}{}
+			}
+		}
+		if !fn(err) {
+			return false
+		}
+		err = unwrap(err)
+	}
+	return false
+}
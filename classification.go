@@ -0,0 +1,114 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Classification labels an attribute with the sensitivity of its value so that marshal profiles can decide whether
+// it is emitted.
+type Classification string
+
+const (
+	// ClassificationInternal labels an attribute which is only meant for internal consumers such as logs.
+	ClassificationInternal Classification = "internal"
+
+	// ClassificationPII labels an attribute containing personally identifiable information.
+	ClassificationPII Classification = "pii"
+
+	// ClassificationSecret labels an attribute containing a secret such as a credential or token.
+	ClassificationSecret Classification = "secret"
+)
+
+// MarshalWithProfile marshals the given error to JSON using the given profile instead of the profile of the factory
+// which created it.
+//
+// This allows the same error to be rendered differently for different consumers, eg: omitting attributes classified
+// as PII in client responses while keeping them in internal logs.  Errors which were not created by this package are
+// marshaled using their own MarshalJSON method.
+func MarshalWithProfile(err Error, profile *MarshalProfile) ([]byte, error) {
+	if xerr, ok := err.(*xerr); ok {
+		return xerr.marshalJSON(profile.resolve())
+	}
+	return err.MarshalJSON()
+}
+
+// PrepareAttrs returns the attributes of the given error in the form in which they are marshaled to JSON using the
+// given profile or, if it is nil, the profile of the factory which created the error, so that other formats (eg: XML,
+// CBOR or RFC 9457 problem documents) apply the same rules as [MarshalWithProfile].
+//
+// Attributes omitted due to their classification are left out, the registered serializers (see [RegisterAttrSerializer])
+// and the truncation limits of the profile are applied and the keys are normalized using the KeyNormalizer of the
+// profile.  Values which cannot be marshaled to JSON (eg: channels or functions) are replaced with the same
+// placeholder strings as in the JSON output.  Errors created by this package which are stored as attribute values (eg:
+// using WithCause) are returned as a [PreparedCause] whose own attributes are prepared using the same profile, while
+// those which refer back to an error containing them or which are nested too deeply are replaced with the
+// [TruncationMarker].  Nil is returned if the profile omits the attributes or none is left.
+func PrepareAttrs(err Error, profile *MarshalProfile) map[string]any {
+	root, ok := err.(*xerr)
+	if ok && profile == nil {
+		profile = root.profile
+	}
+	resolved := profile.resolve()
+	if resolved.OmitAttrs {
+		return nil
+	}
+	causes := newNesting(root, resolved)
+	return prepareAttrs(err.Attrs(), err.Classifications(), &causes)
+}
+
+// prepareAttrs returns the given attributes prepared using the profile of the outermost error (see [PrepareAttrs]) or
+// nil if none is left.
+func prepareAttrs(attrs map[string]any, classes map[string]Classification, causes *nesting) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	profile := causes.profile
+	prepared := make(map[string]any, len(attrs))
+	for k, v := range attrs {
+		if v, ok := profile.prepareAttr(classes, k, v); ok {
+			prepared[profile.KeyNormalizer.Normalize(k)] = marshalableAttr(v, causes)
+		}
+	}
+	if len(prepared) == 0 {
+		return nil
+	}
+	return prepared
+}
+
+// marshalableAttr returns the given prepared attribute value or, if it cannot be marshaled to JSON, its placeholder.
+//
+// Groups are prepared copies, so their values are replaced in place.  Errors created by this package are converted
+// into a [PreparedCause] or, if they have already been prepared or are nested too deeply, the [TruncationMarker].
+func marshalableAttr(value any, causes *nesting) any {
+	switch v := value.(type) {
+	case *xerr:
+		if !causes.enter(v) {
+			return TruncationMarker
+		}
+		cause := &PreparedCause{
+			Attrs:   prepareAttrs(v.attrs, v.classes, causes),
+			Err:     v,
+			profile: causes.profile,
+			root:    causes.root,
+		}
+		causes.leave()
+		return cause
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, error:
+		return value
+	case AttrGroup:
+		for k, gv := range v {
+			v[k] = marshalableAttr(gv, causes)
+		}
+		return v
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return unmarshalablePlaceholder(value, err)
+	}
+	return value
+}
+
+// omitsClassification returns true if attributes with the given classification should not be emitted.
+func (p *MarshalProfile) omitsClassification(class Classification) bool {
+	return class != "" && slices.Contains(p.OmitClassifications, class)
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// catalogEntry is the definition of a single error code in a catalog file.
+type catalogEntry struct {
+	// Code is the error code.
+	Code int `yaml:"code"`
+
+	// Name is the symbolic name of the code.
+	Name string `yaml:"name"`
+
+	// Message is the default message for errors with the code.
+	Message string `yaml:"message"`
+
+	// Deprecated indicates that the code should no longer be used.
+	Deprecated bool `yaml:"deprecated"`
+
+	// ReplacedBy is the code which replaces a deprecated code, if any.
+	ReplacedBy int `yaml:"replacedBy"`
+
+	// DocsURL is the URL of the documentation for the code, if any.
+	DocsURL string `yaml:"docsUrl"`
+
+	// HTTPStatus is the HTTP status code mapped to the code, if any.
+	HTTPStatus int `yaml:"httpStatus"`
+
+	// Parent is the code of the category the code belongs to, if any.
+	Parent int `yaml:"parent"`
+
+	// line is the line of the catalog file the entry starts on.
+	line int
+}
+
+// label returns the code and, if set, the name of the entry, eg: "1042 (QuotaExceeded)".
+func (e catalogEntry) label() string {
+	if e.Name == "" {
+		return strconv.Itoa(e.Code)
+	}
+	return strconv.Itoa(e.Code) + " (" + e.Name + ")"
+}
+
+// catalog is an error catalog file, which lists the definitions of the error codes of an application in its
+// "errors" key, eg:
+//
+//	errors:
+//	  - code: 1042
+//	    name: QuotaExceeded
+//	    message: quota exceeded
+//	    httpStatus: 429
+//	    docsUrl: https://docs.example.com/errors/1042
+//
+// JSON catalogs with the same structure are also accepted.
+type catalog struct {
+	// name is the name of the file.
+	name string
+
+	// entries are the entries in the order they appear in the file.
+	entries []catalogEntry
+}
+
+// loadCatalog reads and parses the catalog file with the given name.
+func loadCatalog(name string) (*catalog, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Errors []yaml.Node `yaml:"errors"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	c := &catalog{name: name}
+	for _, node := range doc.Errors {
+		var entry catalogEntry
+		if err := node.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, node.Line, err)
+		}
+		entry.line = node.Line
+		c.entries = append(c.entries, entry)
+	}
+	return c, nil
+}
+
+// byCode returns the first entry for each code.
+func (c *catalog) byCode() map[int]catalogEntry {
+	entries := make(map[int]catalogEntry, len(c.entries))
+	for _, entry := range c.entries {
+		if _, ok := entries[entry.Code]; !ok {
+			entries[entry.Code] = entry
+		}
+	}
+	return entries
+}
+
+// lint returns the problems found in the catalog, one "file:line: problem" line each.
+func (c *catalog) lint() []string {
+	var problems []string
+	report := func(entry catalogEntry, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("%s:%d: %s", c.name, entry.line, fmt.Sprintf(format, args...)))
+	}
+
+	codes := c.byCode()
+	names := map[string]catalogEntry{}
+	for _, entry := range c.entries {
+		if entry.Code <= 0 {
+			report(entry, "invalid code %d", entry.Code)
+		}
+		if first := codes[entry.Code]; first.line != entry.line {
+			report(entry, "duplicate code %d (first defined on line %d)", entry.Code, first.line)
+		}
+		if entry.Name == "" {
+			report(entry, "code %d is missing a name", entry.Code)
+		} else if first, ok := names[entry.Name]; ok {
+			report(entry, "duplicate name %q (first defined on line %d)", entry.Name, first.line)
+		} else {
+			names[entry.Name] = entry
+		}
+		if entry.Message == "" {
+			report(entry, "code %s is missing a message", entry.label())
+		}
+		if entry.ReplacedBy != 0 {
+			if !entry.Deprecated {
+				report(entry, "code %s has a replacement but is not deprecated", entry.label())
+			}
+			if _, ok := codes[entry.ReplacedBy]; !ok {
+				report(entry, "code %s is replaced by undefined code %d", entry.label(), entry.ReplacedBy)
+			}
+		}
+		if entry.Parent != 0 {
+			if _, ok := codes[entry.Parent]; !ok {
+				report(entry, "code %s belongs to undefined category %d", entry.label(), entry.Parent)
+			} else if c.inCategory(entry.Parent, entry.Code) {
+				report(entry, "code %s is its own category", entry.label())
+			}
+		}
+		if entry.HTTPStatus != 0 && (entry.HTTPStatus < 100 || entry.HTTPStatus > 599) {
+			report(entry, "code %s has invalid HTTP status %d", entry.label(), entry.HTTPStatus)
+		}
+		if entry.DocsURL != "" {
+			if u, err := url.Parse(entry.DocsURL); err != nil || !u.IsAbs() {
+				report(entry, "code %s has invalid docs URL %q", entry.label(), entry.DocsURL)
+			}
+		}
+	}
+	return problems
+}
+
+// inCategory returns true if the given code is the category code or one of the codes beneath it.
+func (c *catalog) inCategory(code, category int) bool {
+	codes := c.byCode()
+	for range len(codes) + 1 {
+		if code == category {
+			return true
+		}
+		entry, ok := codes[code]
+		if !ok || entry.Parent == 0 {
+			break
+		}
+		code = entry.Parent
+	}
+	return false
+}
+
+// catalogChange is a difference between two versions of a catalog.
+type catalogChange struct {
+	// breaking is true if the change may break clients of the old version.
+	breaking bool
+
+	// code is the code which changed.
+	code int
+
+	// description describes the change.
+	description string
+}
+
+// diffCatalogs returns the changes from the old to the new version of a catalog, sorted by code.
+//
+// Removing a code or changing its name, HTTP status or category is a breaking change; adding, deprecating or
+// documenting a code or changing its message is not.
+func diffCatalogs(prev, next *catalog) []catalogChange {
+	var changes []catalogChange
+	oldCodes, newCodes := prev.byCode(), next.byCode()
+	for code, o := range oldCodes {
+		n, ok := newCodes[code]
+		if !ok {
+			changes = append(changes, catalogChange{true, code, "code " + o.label() + " was removed"})
+			continue
+		}
+		if o.Name != n.Name {
+			changes = append(changes, catalogChange{true, code,
+				fmt.Sprintf("code %d was renamed from %q to %q", code, o.Name, n.Name)})
+		}
+		if o.HTTPStatus != n.HTTPStatus {
+			changes = append(changes, catalogChange{true, code,
+				fmt.Sprintf("HTTP status of code %s changed from %d to %d", n.label(), o.HTTPStatus, n.HTTPStatus)})
+		}
+		if o.Parent != n.Parent {
+			changes = append(changes, catalogChange{true, code,
+				fmt.Sprintf("category of code %s changed from %d to %d", n.label(), o.Parent, n.Parent)})
+		}
+		if !o.Deprecated && n.Deprecated {
+			description := "code " + n.label() + " was deprecated"
+			if n.ReplacedBy != 0 {
+				description += " in favor of code " + strconv.Itoa(n.ReplacedBy)
+			}
+			changes = append(changes, catalogChange{false, code, description})
+		} else if o.Deprecated && !n.Deprecated {
+			changes = append(changes, catalogChange{false, code, "code " + n.label() + " is no longer deprecated"})
+		}
+		if o.Message != n.Message {
+			changes = append(changes, catalogChange{false, code,
+				fmt.Sprintf("message of code %s changed from %q to %q", n.label(), o.Message, n.Message)})
+		}
+		if o.DocsURL != n.DocsURL {
+			changes = append(changes, catalogChange{false, code,
+				fmt.Sprintf("docs URL of code %s changed to %q", n.label(), n.DocsURL)})
+		}
+	}
+	for code, n := range newCodes {
+		if _, ok := oldCodes[code]; !ok {
+			changes = append(changes, catalogChange{false, code, "code " + n.label() + " was added"})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b catalogChange) int {
+		if c := cmp.Compare(a.code, b.code); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.description, b.description)
+	})
+	return changes
+}
+
+// runCatalog runs the catalog command.
+func runCatalog(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	usage := func() {
+		fmt.Fprintln(stderr, "Usage: xerrors catalog lint <file> ...")
+		fmt.Fprintln(stderr, "       xerrors catalog diff [flags] <old> <new>")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Lint reports duplicate codes and names, missing fields and invalid values in error catalogs.")
+		fmt.Fprintln(stderr, "Diff reports the changes between two versions of a catalog and fails if any of them are")
+		fmt.Fprintln(stderr, "breaking, eg: removed codes or changed HTTP mappings.")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "lint":
+		return runCatalogLint(args[1:], stdout, stderr, usage)
+	case "diff":
+		return runCatalogDiff(args[1:], stdout, stderr, usage)
+	}
+	usage()
+	return 2
+}
+
+// runCatalogLint runs the catalog lint command.
+func runCatalogLint(args []string, stdout, stderr io.Writer, usage func()) int {
+	flags := flag.NewFlagSet("catalog lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = usage
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		usage()
+		return 2
+	}
+	status := 0
+	for _, name := range flags.Args() {
+		c, err := loadCatalog(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "xerrors: %s\n", err)
+			return 1
+		}
+		for _, problem := range c.lint() {
+			fmt.Fprintln(stdout, problem)
+			status = 1
+		}
+	}
+	return status
+}
+
+// runCatalogDiff runs the catalog diff command.
+func runCatalogDiff(args []string, stdout, stderr io.Writer, usage func()) int {
+	flags := flag.NewFlagSet("catalog diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		usage()
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	allowBreaking := flags.Bool("allow-breaking", false, "succeed even if there are breaking changes")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	prev, err := loadCatalog(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "xerrors: %s\n", err)
+		return 1
+	}
+	next, err := loadCatalog(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "xerrors: %s\n", err)
+		return 1
+	}
+	status := 0
+	for _, change := range diffCatalogs(prev, next) {
+		prefix := "info: "
+		if change.breaking {
+			prefix = "breaking: "
+			if !*allowBreaking {
+				status = 1
+			}
+		}
+		fmt.Fprintln(stdout, prefix+change.description)
+	}
+	return status
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeCatalog writes a catalog file with the given content to a temporary directory and returns its name.
+func writeCatalog(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// _catalog is a valid catalog.
+const _catalog = `errors:
+  - code: 1042
+    name: QuotaExceeded
+    message: quota exceeded
+    httpStatus: 429
+    docsUrl: https://docs.example.com/errors/1042
+  - code: 1043
+    name: CardDeclined
+    message: card declined
+    httpStatus: 402
+`
+
+func TestCatalogLint(t *testing.T) {
+	valid := writeCatalog(t, "valid.yaml", _catalog)
+	if status, stdout, stderr := runCommand("", "catalog", "lint", valid); status != 0 {
+		t.Errorf("exit code %d for a valid catalog: %s%s", status, stdout, stderr)
+	}
+
+	path := writeCatalog(t, "invalid.yaml", `errors:
+  - code: 1042
+    name: QuotaExceeded
+    message: quota exceeded
+  - code: 1042
+    name: QuotaExceeded
+  - code: 0
+    message: unknown
+  - code: 7
+    name: Old
+    message: old
+    replacedBy: 99
+    httpStatus: 700
+    docsUrl: docs/7
+`)
+	status, stdout, _ := runCommand("", "catalog", "lint", path)
+	want := []string{
+		path + ":5: duplicate code 1042 (first defined on line 2)",
+		path + `:5: duplicate name "QuotaExceeded" (first defined on line 2)`,
+		path + ":5: code 1042 (QuotaExceeded) is missing a message",
+		path + ":7: invalid code 0",
+		path + ":7: code 0 is missing a name",
+		path + ":9: code 7 (Old) has a replacement but is not deprecated",
+		path + ":9: code 7 (Old) is replaced by undefined code 99",
+		path + ":9: code 7 (Old) has invalid HTTP status 700",
+		path + `:9: code 7 (Old) has invalid docs URL "docs/7"`,
+	}
+	if got := strings.TrimSpace(stdout); status != 1 || got != strings.Join(want, "\n") {
+		t.Errorf("exit code %d with problems:\n%s\nwant 1 with:\n%s", status, got, strings.Join(want, "\n"))
+	}
+}
+
+func TestCatalogLintAcceptsJSON(t *testing.T) {
+	path := writeCatalog(t, "catalog.json", `{"errors":[{"code":1,"name":"Internal","message":"internal error"}]}`)
+	if status, stdout, stderr := runCommand("", "catalog", "lint", path); status != 0 {
+		t.Errorf("exit code %d for a valid JSON catalog: %s%s", status, stdout, stderr)
+	}
+}
+
+func TestCatalogDiff(t *testing.T) {
+	prev := writeCatalog(t, "old.yaml", _catalog)
+	next := writeCatalog(t, "new.yaml", `errors:
+  - code: 1042
+    name: QuotaExceeded
+    message: too many requests
+    httpStatus: 503
+    docsUrl: https://docs.example.com/errors/quota
+    deprecated: true
+    replacedBy: 1044
+  - code: 1044
+    name: RateLimited
+    message: rate limited
+`)
+	status, stdout, _ := runCommand("", "catalog", "diff", prev, next)
+	want := []string{
+		"breaking: HTTP status of code 1042 (QuotaExceeded) changed from 429 to 503",
+		"info: code 1042 (QuotaExceeded) was deprecated in favor of code 1044",
+		`info: docs URL of code 1042 (QuotaExceeded) changed to "https://docs.example.com/errors/quota"`,
+		`info: message of code 1042 (QuotaExceeded) changed from "quota exceeded" to "too many requests"`,
+		"breaking: code 1043 (CardDeclined) was removed",
+		"info: code 1044 (RateLimited) was added",
+	}
+	if got := strings.TrimSpace(stdout); status != 1 || got != strings.Join(want, "\n") {
+		t.Errorf("exit code %d with changes:\n%s\nwant 1 with:\n%s", status, got, strings.Join(want, "\n"))
+	}
+
+	if status, _, _ := runCommand("", "catalog", "diff", "-allow-breaking", prev, next); status != 0 {
+		t.Errorf("exit code %d with -allow-breaking, want 0", status)
+	}
+	if status, stdout, _ := runCommand("", "catalog", "diff", prev, prev); status != 0 || stdout != "" {
+		t.Errorf("exit code %d with changes %q for the same catalog, want 0 and none", status, stdout)
+	}
+}
+
+func TestCatalogUsage(t *testing.T) {
+	tests := [][]string{{"catalog"}, {"catalog", "check"}, {"catalog", "lint"}, {"catalog", "diff", "old.yaml"}}
+	for _, args := range tests {
+		if status, _, stderr := runCommand("", args...); status != 2 || !strings.Contains(stderr, "Usage:") {
+			t.Errorf("%v: exit code %d with output %q, want the usage and 2", args, status, stderr)
+		}
+	}
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	status, _, stderr := runCommand("", "catalog", "lint", missing)
+	if status != 1 || !strings.Contains(stderr, missing) {
+		t.Errorf("exit code %d with output %q for a missing catalog, want 1", status, stderr)
+	}
+}
+
+func TestCatalogCategories(t *testing.T) {
+	path := writeCatalog(t, "categories.yaml", `errors:
+  - code: 1000
+    name: Billing
+    message: billing error
+  - code: 1042
+    name: QuotaExceeded
+    message: quota exceeded
+    parent: 1000
+  - code: 1043
+    name: CardDeclined
+    message: card declined
+    parent: 999
+  - code: 1050
+    name: Loop
+    message: loop
+    parent: 1051
+  - code: 1051
+    name: LoopParent
+    message: loop parent
+    parent: 1050
+`)
+	status, stdout, _ := runCommand("", "catalog", "lint", path)
+	want := []string{
+		path + ":9: code 1043 (CardDeclined) belongs to undefined category 999",
+		path + ":13: code 1050 (Loop) is its own category",
+		path + ":17: code 1051 (LoopParent) is its own category",
+	}
+	if got := strings.TrimSpace(stdout); status != 1 || got != strings.Join(want, "\n") {
+		t.Errorf("exit code %d with problems:\n%s\nwant 1 with:\n%s", status, got, strings.Join(want, "\n"))
+	}
+
+	prev := writeCatalog(t, "old.yaml", "errors:\n  - {code: 1000, name: A, message: a}\n"+
+		"  - {code: 1001, name: B, message: b}\n  - {code: 1042, name: C, message: c, parent: 1000}\n")
+	next := writeCatalog(t, "new.yaml", "errors:\n  - {code: 1000, name: A, message: a}\n"+
+		"  - {code: 1001, name: B, message: b}\n  - {code: 1042, name: C, message: c, parent: 1001}\n")
+	_, stdout, _ = runCommand("", "catalog", "diff", prev, next)
+	if got := strings.TrimSpace(stdout); got != "breaking: category of code 1042 (C) changed from 1000 to 1001" {
+		t.Errorf("changes = %q, want the breaking category change", got)
+	}
+}
@@ -0,0 +1,10 @@
+module go.innotegrity.dev/xerrors/cmd/xerrors
+
+go 1.23
+
+replace go.innotegrity.dev/xerrors => ../../
+
+require (
+	go.innotegrity.dev/xerrors v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// _maxLineSize is the maximum size of a single log line.
+	_maxLineSize = 16 << 20
+)
+
+// inspectFilter selects the errors reported by the inspect command.
+type inspectFilter struct {
+	// code is the code of the errors to report or 0 for any code.
+	code int
+
+	// domain is the domain of the errors to report or an empty string for any domain.
+	domain string
+
+	// fingerprint is the fingerprint of the errors to report or an empty string for any fingerprint.
+	fingerprint string
+}
+
+// match returns true if the error passes the filter.
+func (f inspectFilter) match(err xerrors.Error) bool {
+	return (f.code == 0 || err.Code() == f.code) &&
+		(f.domain == "" || err.Domain() == f.domain) &&
+		(f.fingerprint == "" || xerrors.Fingerprint(err) == f.fingerprint)
+}
+
+// inspectGroup counts the occurrences of errors with the same fingerprint.
+type inspectGroup struct {
+	// count is the number of occurrences.
+	count int
+
+	// err is the first occurrence.
+	err xerrors.Error
+
+	// fingerprint is the fingerprint shared by the occurrences.
+	fingerprint string
+}
+
+// runInspect runs the inspect command.
+func runInspect(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: xerrors inspect [flags] [file ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Reads NDJSON logs from the files (or standard input) and prints the error documents found in")
+		fmt.Fprintln(stderr, "them, either as whole lines or in any top-level field of a line.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	var filter inspectFilter
+	flags.IntVar(&filter.code, "code", 0, "only report errors with the given `code`")
+	flags.StringVar(&filter.domain, "domain", "", "only report errors in the given `domain`")
+	flags.StringVar(&filter.fingerprint, "fingerprint", "", "only report errors with the given `fingerprint`")
+	field := flags.String("field", "", "only look for error documents in the given top-level `field`")
+	summary := flags.Bool("summary", false, "print the most frequent errors instead of each error")
+	top := flags.Int("top", 10, "number of errors printed with -summary or 0 for all")
+	width := flags.Int("width", 0, "wrap the output to the given `width` or 0 to disable wrapping")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	readers := []namedReader{{name: "<stdin>", r: stdin}}
+	if flags.NArg() > 0 {
+		readers = readers[:0]
+		for _, name := range flags.Args() {
+			file, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintf(stderr, "xerrors: %s\n", err)
+				return 1
+			}
+			defer file.Close()
+			readers = append(readers, namedReader{name: name, r: file})
+		}
+	}
+
+	formatter := xerrors.NewTextFormatter(xerrors.WithAutoColor(stdout), xerrors.WithWidth(*width))
+	groups := map[string]*inspectGroup{}
+	for _, reader := range readers {
+		err := scanErrors(reader, *field, func(line int, err xerrors.Error) {
+			if !filter.match(err) {
+				return
+			}
+			if !*summary {
+				fmt.Fprintf(stdout, "%s:%d: fingerprint %s\n", reader.name, line, xerrors.Fingerprint(err))
+				fmt.Fprintln(stdout, formatter.Format(err))
+				return
+			}
+			fingerprint := xerrors.Fingerprint(err)
+			if group, ok := groups[fingerprint]; ok {
+				group.count++
+			} else {
+				groups[fingerprint] = &inspectGroup{count: 1, err: err, fingerprint: fingerprint}
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "xerrors: %s: %s\n", reader.name, err)
+			return 1
+		}
+	}
+	if *summary {
+		writeSummary(stdout, groups, *top)
+	}
+	return 0
+}
+
+// namedReader is a source of log lines.
+type namedReader struct {
+	// name is the name of the source used in messages.
+	name string
+
+	// r reads the lines.
+	r io.Reader
+}
+
+// scanErrors calls fn with the 1-based line number of each error document found in the lines read from the reader.
+//
+// A line may either be an error document itself or contain error documents in its top-level fields, or only in the
+// given field if it is not empty.  Lines which are not JSON objects are skipped.
+func scanErrors(reader namedReader, field string, fn func(line int, err xerrors.Error)) error {
+	scanner := bufio.NewScanner(reader.r)
+	scanner.Buffer(nil, _maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(scanner.Bytes(), &fields) != nil {
+			continue
+		}
+		if field == "" && isErrorDocument(fields) {
+			if err, pErr := xerrors.ParseJSON(scanner.Bytes()); pErr == nil {
+				fn(line, err)
+			}
+			continue
+		}
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			if field == "" || k == field {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			var nested map[string]json.RawMessage
+			if json.Unmarshal(fields[k], &nested) != nil || !isErrorDocument(nested) {
+				continue
+			}
+			if err, pErr := xerrors.ParseJSON(fields[k]); pErr == nil {
+				fn(line, err)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// isErrorDocument returns true if the fields look like those of an error document, ie: a numeric code and a string
+// message.
+func isErrorDocument(fields map[string]json.RawMessage) bool {
+	code, message := fields["code"], fields["message"]
+	if len(code) == 0 || len(message) == 0 || message[0] != '"' {
+		return false
+	}
+	_, err := strconv.Atoi(string(code))
+	return err == nil
+}
+
+// writeSummary writes a table of the most frequent errors, up to top groups (or all groups if top is 0).
+func writeSummary(w io.Writer, groups map[string]*inspectGroup, top int) {
+	sorted := make([]*inspectGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	slices.SortFunc(sorted, func(a, b *inspectGroup) int {
+		if c := cmp.Compare(b.count, a.count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.fingerprint, b.fingerprint)
+	})
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COUNT\tCODE\tDOMAIN\tFINGERPRINT\tMESSAGE")
+	for _, group := range sorted {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\n", group.count, group.err.Code(), group.err.Domain(), group.fingerprint,
+			xerrors.Summarize(group.err, 80))
+	}
+	tw.Flush()
+}
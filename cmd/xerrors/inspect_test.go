@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// _inspectLog is an NDJSON log with errors as whole lines and in fields, and lines which are not errors.
+const _inspectLog = `{"code":7,"message":"card declined","domain":"billing"}
+not json
+{"level":"error","msg":"charge failed","err":{"code":7,"message":"card declined","domain":"billing"}}
+{"level":"info","msg":"started","code":"x"}
+{"level":"error","cause":{"code":12,"message":"invalid configuration"},"err":{"code":7,"message":"card declined"}}
+`
+
+func TestInspectPrintsErrors(t *testing.T) {
+	status, stdout, stderr := runCommand(_inspectLog, "inspect")
+	if status != 0 {
+		t.Fatalf("exit code %d: %s", status, stderr)
+	}
+	for _, want := range []string{"<stdin>:1: fingerprint ", "<stdin>:3: fingerprint ", "<stdin>:5: fingerprint "} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in the output:\n%s", want, stdout)
+		}
+	}
+	if n := strings.Count(stdout, "fingerprint "); n != 4 {
+		t.Errorf("%d errors were reported, want 4:\n%s", n, stdout)
+	}
+	if !strings.Contains(stdout, "invalid configuration") || strings.Contains(stdout, ":2:") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+}
+
+func TestInspectFiltersErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{args: []string{"-code", "7"}, want: 3},
+		{args: []string{"-domain", "billing"}, want: 2},
+		{args: []string{"-field", "cause"}, want: 1},
+		{args: []string{"-code", "12", "-domain", "billing"}, want: 0},
+	}
+	for _, test := range tests {
+		status, stdout, stderr := runCommand(_inspectLog, append([]string{"inspect"}, test.args...)...)
+		if n := strings.Count(stdout, "fingerprint "); status != 0 || n != test.want {
+			t.Errorf("%v: exit code %d with %d errors, want %d: %s", test.args, status, n, test.want, stderr)
+		}
+	}
+}
+
+func TestInspectSummarizesErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(_inspectLog), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	status, stdout, stderr := runCommand("", "inspect", "-summary", path)
+	if status != 0 {
+		t.Fatalf("exit code %d: %s", status, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "COUNT") {
+		t.Fatalf("unexpected summary:\n%s", stdout)
+	}
+	// the groups are sorted by decreasing count
+	if fields := strings.Fields(lines[1]); fields[0] != "2" || fields[1] != "7" || fields[2] != "billing" {
+		t.Errorf("unexpected first group %q", lines[1])
+	}
+
+	if _, stdout, _ = runCommand("", "inspect", "-summary", "-top", "1", path); strings.Count(stdout, "\n") != 2 {
+		t.Errorf("the summary was not limited to the top group:\n%s", stdout)
+	}
+}
+
+func TestInspectReportsMissingFiles(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.log")
+	if status, _, stderr := runCommand("", "inspect", missing); status != 1 || !strings.Contains(stderr, missing) {
+		t.Errorf("exit code %d with output %q, want 1 and the file name", status, stderr)
+	}
+	if status, _, _ := runCommand("", "inspect", "-unknown"); status != 2 {
+		t.Errorf("exit code %d for an unknown flag, want 2", status)
+	}
+}
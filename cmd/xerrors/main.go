@@ -0,0 +1,68 @@
+// Command xerrors analyzes the structured error documents produced by the xerrors package, eg: in NDJSON logs.
+//
+// Usage:
+//
+//	xerrors <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	catalog    lint error catalogs and report breaking changes between versions of them
+//	inspect    filter, pretty-print and summarize the errors in NDJSON logs
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	// name is the name of the command.
+	name string
+
+	// summary is a one-line description of the command.
+	summary string
+
+	// run runs the command with the given arguments and returns the exit code.
+	run func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var (
+	_commands = []command{
+		{name: "catalog", summary: "lint error catalogs and report breaking changes between versions", run: runCatalog},
+		{name: "inspect", summary: "filter, pretty-print and summarize the errors in NDJSON logs", run: runInspect},
+	}
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command named by the first argument and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+	for _, cmd := range _commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "xerrors: unknown command %q\n\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// usage writes the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: xerrors <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range _commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "xerrors <command> -h" for the flags of a command.`)
+}
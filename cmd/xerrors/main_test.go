@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// runCommand runs the CLI with the given arguments and standard input and returns the exit code and the output.
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestRunPrintsUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"help"}, {"-h"}, {"frobnicate"}} {
+		status, _, stderr := runCommand("", args...)
+		if status != 2 || !strings.Contains(stderr, "Usage: xerrors <command>") ||
+			!strings.Contains(stderr, "inspect") {
+			t.Errorf("%v: exit code %d with output %q, want the usage and 2", args, status, stderr)
+		}
+	}
+	if _, _, stderr := runCommand("", "frobnicate"); !strings.Contains(stderr, `unknown command "frobnicate"`) {
+		t.Errorf("the unknown command was not reported: %q", stderr)
+	}
+}
@@ -0,0 +1,150 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Code is an error code.
+//
+// Declaring the codes of an application as constants of this type documents them, gives them a readable String()
+// (see [SetCodeRegistry]) and lets linters check that switches over them are exhaustive, eg:
+//
+//	const (
+//		ErrUserNotFound  xerrors.Code = 1041
+//		ErrQuotaExceeded xerrors.Code = 1042
+//	)
+//	...
+//	switch xerrors.CodeOf(err) {
+//	case ErrUserNotFound:
+//		...
+//	case ErrQuotaExceeded:
+//		...
+//	}
+//
+// The functions of this package keep taking and returning plain int codes, so that existing callers and [Error]
+// implementations keep compiling, and a Code is converted using int(c) or passed to the constructors using its New,
+// Newf, Wrap and Wrapf methods, eg: ErrQuotaExceeded.New("quota exceeded").  The code field of marshaled errors is
+// written in the same way as a Code (see [MarshalSymbolicCodes]).
+type Code int
+
+// SetCodeRegistry sets the registry which the name of a [Code] is looked up in when it is formatted or marshaled and
+// which symbolic names are resolved in when a Code is unmarshaled.  Passing nil removes the registry.
+//
+// This call is thread-safe.
+func SetCodeRegistry(registry *Registry) {
+	UpdateConfig(func(c *Config) {
+		c.CodeRegistry = registry
+	})
+}
+
+// MarshalSymbolicCodes controls whether a [Code], including the code field of marshaled errors, is marshaled to JSON
+// as its symbolic name from the registry set by [SetCodeRegistry] (eg: "QuotaExceeded") instead of its number.  Codes
+// without a name are always marshaled as numbers and [ParseJSON] resolves the names in the same registry.  A
+// [MarshalProfile] with a Translator writes the translated codes instead.
+//
+// This function enables or disables symbolic codes globally for this package.  This call is thread-safe.
+func MarshalSymbolicCodes(enable bool) {
+	UpdateConfig(func(c *Config) {
+		c.SymbolicCodes = enable
+	})
+}
+
+// CodeOf returns the code of the first [Error] in the chain of the given error or 0 if there is none.
+func CodeOf(err error) Code {
+	var xerr Error
+	if !errors.As(err, &xerr) {
+		return 0
+	}
+	return Code(xerr.Code())
+}
+
+// Is returns true if the first [Error] in the chain of the given error has the code.
+func (c Code) Is(err error) bool {
+	var xerr Error
+	return errors.As(err, &xerr) && xerr.Code() == int(c)
+}
+
+// MarshalJSON marshals the code as a number or, if enabled using [MarshalSymbolicCodes], as its symbolic name.
+func (c Code) MarshalJSON() ([]byte, error) {
+	return c.appendJSON(nil), nil
+}
+
+// appendJSON appends the JSON encoding of the code to dst as a number or, if enabled using [MarshalSymbolicCodes], as
+// its symbolic name.
+func (c Code) appendJSON(dst []byte) []byte {
+	if cfg := loadConfig(); cfg.SymbolicCodes && cfg.CodeRegistry != nil {
+		if def, ok := cfg.CodeRegistry.Lookup(int(c)); ok && def.Name != "" {
+			return appendJSONString(dst, def.Name)
+		}
+	}
+	return strconv.AppendInt(dst, int64(c), 10)
+}
+
+// Name returns the symbolic name of the code from the registry set by [SetCodeRegistry] or an empty string if it has
+// no name.
+func (c Code) Name() string {
+	registry := loadConfig().CodeRegistry
+	if registry == nil {
+		return ""
+	}
+	def, _ := registry.Lookup(int(c))
+	return def.Name
+}
+
+// New creates a new [Error] with the code and the given message.
+func (c Code) New(message string) Error {
+	return newError(nil, nil, 0, int(c), message, nil)
+}
+
+// Newf creates a new [Error] with the code and the given formatted message.
+func (c Code) Newf(format string, args ...any) Error {
+	return newError(nil, nil, 0, int(c), fmt.Sprintf(format, args...), nil)
+}
+
+// String returns the symbolic name of the code (see [Code.Name]) or, if it has no name, its number.
+func (c Code) String() string {
+	if name := c.Name(); name != "" {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// UnmarshalJSON unmarshals the code from either a number or a symbolic name, which is resolved in the registry set by
+// [SetCodeRegistry].  Strings holding numbers are also accepted.
+func (c *Code) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var code int
+		if err := json.Unmarshal(data, &code); err != nil {
+			return fmt.Errorf("invalid error code: %s", data)
+		}
+		*c = Code(code)
+		return nil
+	}
+	if code, err := strconv.Atoi(name); err == nil {
+		*c = Code(code)
+		return nil
+	}
+
+	registry := loadConfig().CodeRegistry
+	if registry != nil {
+		if def, ok := registry.LookupName(name); ok {
+			*c = Code(def.Code)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error code name: %q", name)
+}
+
+// Wrap wraps the given error in a new [Error] with the code and the given message.
+func (c Code) Wrap(err error, message string) Error {
+	return newError(nil, nil, 0, int(c), message, err)
+}
+
+// Wrapf wraps the given error in a new [Error] with the code and the given formatted message.
+func (c Code) Wrapf(err error, format string, args ...any) Error {
+	return newError(nil, nil, 0, int(c), fmt.Sprintf(format, args...), err)
+}
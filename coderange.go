@@ -0,0 +1,283 @@
+package xerrors
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// LocalCodeAttr is the name of the attribute holding a local code which is outside of the range of its domain (see
+// [CodeDomain.New]).
+const LocalCodeAttr = "localCode"
+
+// CodeRange is an inclusive range of error codes reserved by an owner (eg: a team or service).
+type CodeRange struct {
+	// Owner is the name of the owner of the range.
+	Owner string `json:"owner"`
+
+	// Low is the lowest code in the range.
+	Low int `json:"low"`
+
+	// High is the highest code in the range.
+	High int `json:"high"`
+}
+
+// Contains returns true if the given code is within the range.
+func (r CodeRange) Contains(code int) bool {
+	return code >= r.Low && code <= r.High
+}
+
+// Code returns the code at the given offset from the start of the range, panicking if the resulting code is outside
+// of the range.
+//
+// This is intended for declaring code constants relative to a reserved block, eg: ErrNotFound = billing.Code(4).
+func (r CodeRange) Code(offset int) int {
+	code := r.Low + offset
+	if !r.Contains(code) {
+		panic(fmt.Sprintf("code offset %d is outside of the range reserved by %s (%d-%d)", offset, r.Owner, r.Low,
+			r.High))
+	}
+	return code
+}
+
+// InRange returns true if the first [Error] in the chain of the given error has a code within the inclusive range
+// from lo to hi.
+func InRange(err error, lo, hi int) bool {
+	var xerr Error
+	if !errors.As(err, &xerr) {
+		return false
+	}
+	code := xerr.Code()
+	return code >= lo && code <= hi
+}
+
+// RangeRegistry keeps track of the ranges of error codes reserved by different owners so that codes do not collide
+// across a large codebase.
+type RangeRegistry struct {
+	// unexported variables
+	mutex  sync.Mutex  // guards the registry
+	ranges []CodeRange // reserved ranges sorted by their lowest code
+}
+
+// NewRangeRegistry creates a new empty [RangeRegistry].
+func NewRangeRegistry() *RangeRegistry {
+	return &RangeRegistry{}
+}
+
+// MustReserve is like [RangeRegistry.Reserve] but panics if the range cannot be reserved.
+//
+// This is intended for reserving ranges in package-level variable declarations.
+func (r *RangeRegistry) MustReserve(owner string, lo, hi int) CodeRange {
+	cr, err := r.Reserve(owner, lo, hi)
+	if err != nil {
+		panic(err.Error())
+	}
+	return cr
+}
+
+// Owner returns the owner of the range containing the given code, if any.
+//
+// This call is thread-safe.
+func (r *RangeRegistry) Owner(code int) (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, cr := range r.ranges {
+		if cr.Contains(code) {
+			return cr.Owner, true
+		}
+	}
+	return "", false
+}
+
+// Ranges returns the reserved ranges sorted by their lowest code.
+//
+// This call is thread-safe.
+func (r *RangeRegistry) Ranges() []CodeRange {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Clone(r.ranges)
+}
+
+// Reserve reserves the inclusive range of codes from lo to hi for the given owner.
+//
+// An error is returned if the range is invalid or overlaps a range which has already been reserved.  This call is
+// thread-safe.
+func (r *RangeRegistry) Reserve(owner string, lo, hi int) (CodeRange, error) {
+	if lo > hi {
+		return CodeRange{}, fmt.Errorf("invalid code range %d-%d for %s", lo, hi, owner)
+	}
+	cr := CodeRange{
+		Owner: owner,
+		Low:   lo,
+		High:  hi,
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, existing := range r.ranges {
+		if lo <= existing.High && hi >= existing.Low {
+			return CodeRange{}, fmt.Errorf("code range %d-%d for %s overlaps range %d-%d reserved by %s", lo, hi,
+				owner, existing.Low, existing.High, existing.Owner)
+		}
+	}
+	r.ranges = append(r.ranges, cr)
+	slices.SortFunc(r.ranges, func(a, b CodeRange) int {
+		return cmp.Compare(a.Low, b.Low)
+	})
+	return cr, nil
+}
+
+// CodeDomain composes the codes of the errors of a domain from the base assigned to the domain and the local codes
+// defined within it, eg: local code 4 of a domain with base 20000 is code 20004.
+//
+// Errors created by a CodeDomain belong to the domain (see [WithDomain]), so each domain can define its local codes
+// independently while the composed codes stay unique across services.
+type CodeDomain struct {
+	// unexported variables
+	factory *Factory  // factory which creates the errors of the domain
+	rng     CodeRange // range of composed codes reserved for the domain
+}
+
+// AssignDomain reserves the range of size codes starting at base for the given domain (see [RangeRegistry.Reserve])
+// and returns a [CodeDomain] which composes its codes, eg:
+//
+//	var billing = ranges.MustAssignDomain("billing", 20000, 1000)
+//	...
+//	return billing.New(4, "card declined") // code 20004 in the billing domain
+//
+// The options are applied to the factory which creates the errors of the domain.  An error is returned if the size
+// is not positive or the range overlaps a range which has already been reserved.  This call is thread-safe.
+func (r *RangeRegistry) AssignDomain(domain string, base, size int, opts ...FactoryOption) (*CodeDomain, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid code range size %d for %s", size, domain)
+	}
+	cr, err := r.Reserve(domain, base, base+size-1)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]FactoryOption{WithDomain(domain)}, opts...)
+	return &CodeDomain{
+		factory: NewFactory(opts...).CallerSkip(1),
+		rng:     cr,
+	}, nil
+}
+
+// Domain returns the domain whose range contains the given code (see [RangeRegistry.AssignDomain]), if any.
+//
+// This call is thread-safe.
+func (r *RangeRegistry) Domain(code int) (string, bool) {
+	return r.Owner(code)
+}
+
+// Local returns the local code of the given code within the range which contains it, ie: its offset from the base
+// of the range, if the code is in a reserved range.
+//
+// This call is thread-safe.
+func (r *RangeRegistry) Local(code int) (int, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, cr := range r.ranges {
+		if cr.Contains(code) {
+			return code - cr.Low, true
+		}
+	}
+	return 0, false
+}
+
+// MustAssignDomain is like [RangeRegistry.AssignDomain] but panics if the range cannot be reserved.
+//
+// This is intended for assigning domains in package-level variable declarations.
+func (r *RangeRegistry) MustAssignDomain(domain string, base, size int, opts ...FactoryOption) *CodeDomain {
+	d, err := r.AssignDomain(domain, base, size, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return d
+}
+
+// Base returns the base code of the domain.
+func (d *CodeDomain) Base() int {
+	return d.rng.Low
+}
+
+// Code returns the composed code of the given local code, panicking if the code is outside of the range of the
+// domain.
+//
+// This is intended for declaring code constants, eg: ErrCardDeclined = billing.Code(4).
+func (d *CodeDomain) Code(local int) int {
+	return d.rng.Code(local)
+}
+
+// Contains returns true if the given composed code belongs to the domain.
+func (d *CodeDomain) Contains(code int) bool {
+	return d.rng.Contains(code)
+}
+
+// Local returns the local code of the given composed code, if it belongs to the domain.
+func (d *CodeDomain) Local(code int) (int, bool) {
+	if !d.rng.Contains(code) {
+		return 0, false
+	}
+	return code - d.rng.Low, true
+}
+
+// Name returns the name of the domain.
+func (d *CodeDomain) Name() string {
+	return d.rng.Owner
+}
+
+// New creates a new [Error] in the domain with the composed code of the given local code and the given message.
+//
+// Unlike [CodeDomain.Code], New does not panic if the local code is outside of the range of the domain, as it is
+// typically called on error paths: the error is created with the base code of the domain instead and the local code
+// is added in the [LocalCodeAttr] attribute.  The same applies to [CodeDomain.Newf], [CodeDomain.Wrap] and
+// [CodeDomain.Wrapf].
+func (d *CodeDomain) New(local int, message string) Error {
+	code, ok := d.compose(local)
+	return withLocalCode(d.factory.New(code, message), local, ok)
+}
+
+// Newf creates a new [Error] in the domain with the composed code of the given local code and the given formatted
+// message.
+func (d *CodeDomain) Newf(local int, format string, args ...any) Error {
+	code, ok := d.compose(local)
+	return withLocalCode(d.factory.Newf(code, format, args...), local, ok)
+}
+
+// Range returns the range of composed codes reserved for the domain.
+func (d *CodeDomain) Range() CodeRange {
+	return d.rng
+}
+
+// Wrap wraps the given error in a new [Error] in the domain with the composed code of the given local code and the
+// given message.
+func (d *CodeDomain) Wrap(local int, err error, message string) Error {
+	code, ok := d.compose(local)
+	return withLocalCode(d.factory.Wrap(code, err, message), local, ok)
+}
+
+// Wrapf wraps the given error in a new [Error] in the domain with the composed code of the given local code and the
+// given formatted message.
+func (d *CodeDomain) Wrapf(local int, err error, format string, args ...any) Error {
+	code, ok := d.compose(local)
+	return withLocalCode(d.factory.Wrapf(code, err, format, args...), local, ok)
+}
+
+// compose returns the composed code of the given local code or, if it is outside of the range of the domain, the base
+// code of the domain and false.
+func (d *CodeDomain) compose(local int) (int, bool) {
+	if code := d.rng.Low + local; d.rng.Contains(code) {
+		return code, true
+	}
+	return d.rng.Low, false
+}
+
+// withLocalCode adds the given local code to the error in the [LocalCodeAttr] attribute unless it is valid.
+func withLocalCode(err Error, local int, valid bool) Error {
+	if !valid {
+		err.WithAttr(LocalCodeAttr, local)
+	}
+	return err
+}
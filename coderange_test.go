@@ -0,0 +1,98 @@
+package xerrors
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCodeDomainComposesCodes(t *testing.T) {
+	ranges := NewRangeRegistry()
+	billing := ranges.MustAssignDomain("billing", 20000, 1000)
+
+	if got := billing.Code(4); got != 20004 {
+		t.Errorf("Code(4) = %d, want 20004", got)
+	}
+	err := billing.New(4, "card declined")
+	if err.Code() != 20004 || err.Domain() != "billing" {
+		t.Errorf("New(4) created code %d in domain %q, want 20004 in billing", err.Code(), err.Domain())
+	}
+	if _, ok := err.Attrs()[LocalCodeAttr]; ok {
+		t.Errorf("unexpected %s attribute for a valid local code", LocalCodeAttr)
+	}
+	if err := billing.Wrapf(999, errors.New("timeout"), "gateway %s", "down"); err.Code() != 20999 {
+		t.Errorf("Wrapf(999) created code %d, want 20999", err.Code())
+	}
+}
+
+func TestCodeDomainDecomposesCodes(t *testing.T) {
+	ranges := NewRangeRegistry()
+	billing := ranges.MustAssignDomain("billing", 20000, 1000)
+	ranges.MustAssignDomain("users", 21000, 1000)
+
+	tests := []struct {
+		code   int
+		local  int
+		domain string
+		ok     bool
+	}{
+		{code: 20000, local: 0, domain: "billing", ok: true},
+		{code: 20999, local: 999, domain: "billing", ok: true},
+		{code: 21000, local: 0, domain: "users", ok: true},
+		{code: 19999},
+		{code: 22000},
+	}
+	for _, test := range tests {
+		if local, ok := ranges.Local(test.code); local != test.local || ok != test.ok {
+			t.Errorf("Local(%d) = %d, %t, want %d, %t", test.code, local, ok, test.local, test.ok)
+		}
+		if domain, ok := ranges.Domain(test.code); domain != test.domain || ok != test.ok {
+			t.Errorf("Domain(%d) = %q, %t, want %q, %t", test.code, domain, ok, test.domain, test.ok)
+		}
+		inBilling := test.domain == "billing"
+		if got := billing.Contains(test.code); got != inBilling {
+			t.Errorf("billing.Contains(%d) = %t, want %t", test.code, got, inBilling)
+		}
+		if local, ok := billing.Local(test.code); ok != inBilling || (ok && local != test.local) {
+			t.Errorf("billing.Local(%d) = %d, %t", test.code, local, ok)
+		}
+	}
+}
+
+func TestAssignDomainRejectsCollisions(t *testing.T) {
+	ranges := NewRangeRegistry()
+	ranges.MustAssignDomain("billing", 20000, 1000)
+	if _, err := ranges.AssignDomain("users", 20999, 1000); err == nil {
+		t.Error("expected an error for a range overlapping the billing range")
+	}
+	if _, err := ranges.AssignDomain("users", 21000, 0); err == nil {
+		t.Error("expected an error for an empty range")
+	}
+	if _, err := ranges.AssignDomain("users", 21000, 1000); err != nil {
+		t.Errorf("failed to assign an adjacent range: %v", err)
+	}
+}
+
+func TestCodeDomainHandlesOutOfRangeLocalCodes(t *testing.T) {
+	billing := NewRangeRegistry().MustAssignDomain("billing", 20000, 1000)
+
+	for _, local := range []int{-1, 1000} {
+		errs := []Error{
+			billing.New(local, "card declined"),
+			billing.Newf(local, "card %s", "declined"),
+			billing.Wrap(local, errors.New("timeout"), "gateway down"),
+			billing.Wrapf(local, errors.New("timeout"), "gateway %s", "down"),
+		}
+		for _, err := range errs {
+			if err.Code() != 20000 || err.Attrs()[LocalCodeAttr] != local {
+				t.Errorf("local code %d: got code %d and attributes %v", local, err.Code(), err.Attrs())
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Code to panic for an out-of-range local code")
+		}
+	}()
+	billing.Code(1000)
+}
@@ -0,0 +1,108 @@
+package xerrors
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// CountAttr is the name of the attribute holding the number of errors received by [Collect].
+	CountAttr = "count"
+
+	// FirstErrorAttr is the name of the attribute holding the time at which [Collect] received the first error.
+	FirstErrorAttr = "firstErrorAt"
+
+	// LastErrorAttr is the name of the attribute holding the time at which [Collect] received the last error.
+	LastErrorAttr = "lastErrorAt"
+)
+
+// collector holds the configuration of [Collect].
+type collector struct {
+	// unexported variables
+	code    int                // code of the collecting error
+	message string             // message of the collecting error
+	opts    []MultiErrorOption // options of the aggregated errors
+}
+
+// CollectOption is a function which configures [Collect].
+type CollectOption func(*collector)
+
+// WithCollectCode sets the code of the error returned by [Collect].  The default code is 0.
+func WithCollectCode(code int) CollectOption {
+	return func(c *collector) {
+		c.code = code
+	}
+}
+
+// WithCollectMessage sets the message of the error returned by [Collect].  The default message is "errors occurred".
+func WithCollectMessage(message string) CollectOption {
+	return func(c *collector) {
+		c.message = message
+	}
+}
+
+// WithCollectedErrorOptions sets the options of the [MultiError] holding the errors received by [Collect], eg:
+// [WithMaxRenderedErrors].
+func WithCollectedErrorOptions(opts ...MultiErrorOption) CollectOption {
+	return func(c *collector) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// Collect drains the given channel of errors, eg: the failures of a pool of concurrent workers, until it is closed or
+// the context is done.
+//
+// The received errors (ignoring nil errors) are aggregated into a [MultiError] which is wrapped by the returned error.
+// The returned error holds the number of errors received and the times at which the first and last errors were
+// received in the [CountAttr], [FirstErrorAttr] and [LastErrorAttr] attributes.  If the context is done before the
+// channel is closed, the context's error is added to the aggregated errors as well and the context details are added
+// in the same way as [WrapContext].
+//
+// When the returned error is marshaled to JSON or XML, the aggregated errors are kept as a list (see
+// [MultiError.WriteJSON]) rather than reduced to their combined message.
+//
+// Nil is returned if the channel is closed without receiving any errors.
+func Collect(ctx context.Context, errs <-chan error, opts ...CollectOption) Error {
+	c := &collector{
+		message: "errors occurred",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	multi := NewMultiError(c.opts...)
+	var first, last time.Time
+	count := 0
+	for done := false; !done; {
+		select {
+		case err, ok := <-errs:
+			if !ok {
+				done = true
+				break
+			}
+			if err == nil {
+				continue
+			}
+			last = time.Now()
+			if count == 0 {
+				first = last
+			}
+			count++
+			multi.Add(err)
+		case <-ctx.Done():
+			multi.Add(ctx.Err())
+			done = true
+		}
+	}
+	if multi.Len() == 0 {
+		return nil
+	}
+
+	xerr := newError(ctx, nil, 0, c.code, c.message, multi)
+	xerr.WithAttr(CountAttr, count)
+	if count > 0 {
+		xerr.WithAttr(FirstErrorAttr, first.Format(time.RFC3339Nano))
+		xerr.WithAttr(LastErrorAttr, last.Format(time.RFC3339Nano))
+	}
+	return xerr
+}
@@ -0,0 +1,100 @@
+package xerrors
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// collectErrors returns the error returned by Collect for the given errors.
+func collectErrors(errs ...error) Error {
+	ch := make(chan error, len(errs))
+	for _, err := range errs {
+		ch <- err
+	}
+	close(ch)
+	return Collect(context.Background(), ch, WithCollectCode(9))
+}
+
+func TestCollect(t *testing.T) {
+	if err := collectErrors(nil, nil); err != nil {
+		t.Errorf("Collect() = %v without errors, want nil", err)
+	}
+
+	first, second := New(1, "first"), errors.New("second")
+	err := collectErrors(first, nil, second)
+	if err.Code() != 9 || err.Message() != "errors occurred" || err.Attrs()[CountAttr] != 2 {
+		t.Errorf("Collect() = %d %q with attributes %v", err.Code(), err.Message(), err.Attrs())
+	}
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("the collected errors are not wrapped: %v", err)
+	}
+}
+
+func TestCollectStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Collect(ctx, make(chan error))
+	if !errors.Is(err, context.Canceled) || err.Attrs()[CountAttr] != 0 {
+		t.Errorf("Collect() = %v with attributes %v, want the context error", err, err.Attrs())
+	}
+}
+
+func TestCollectMarshalsErrorsAsArray(t *testing.T) {
+	err := collectErrors(New(1, "first").WithAttr("user", "alice"), errors.New("second"))
+	data, mErr := json.Marshal(err)
+	if mErr != nil {
+		t.Fatalf("Marshal() failed: %v", mErr)
+	}
+	var doc struct {
+		WrappedError struct {
+			Errors []map[string]any `json:"errors"`
+		} `json:"wrappedError"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid document %s: %v", data, err)
+	}
+	errs := doc.WrappedError.Errors
+	if len(errs) != 2 || errs[0]["code"] != 1.0 || errs[0]["message"] != "first" || errs[1]["message"] != "second" {
+		t.Fatalf("the collected errors were not marshaled as a list: %s", data)
+	}
+
+	parsed, pErr := ParseJSON(data)
+	if pErr != nil {
+		t.Fatalf("ParseJSON(%s) failed: %v", data, pErr)
+	}
+	var multi *MultiError
+	if !errors.As(parsed, &multi) || multi.Len() != 2 {
+		t.Fatalf("the collected errors were not parsed: %v", parsed)
+	}
+	var xerr Error
+	if parsedFirst := multi.Errors()[0]; !errors.As(parsedFirst, &xerr) || xerr.Code() != 1 ||
+		xerr.Attrs()["user"] != "alice" {
+		t.Errorf("unexpected first collected error: %v", parsedFirst)
+	}
+	if got := multi.Errors()[1].Error(); got != "second" {
+		t.Errorf("second collected error = %q, want %q", got, "second")
+	}
+}
+
+func TestCollectMarshalsErrorsToXML(t *testing.T) {
+	ch := make(chan error, 3)
+	ch <- New(1, "first")
+	ch <- errors.New("second")
+	ch <- errors.New("third")
+	close(ch)
+	err := Collect(context.Background(), ch, WithCollectedErrorOptions(WithMaxRenderedErrors(2)))
+
+	data, mErr := xml.Marshal(err)
+	if mErr != nil {
+		t.Fatalf("Marshal() failed: %v", mErr)
+	}
+	want := `<cause omitted="1"><error code="1"><message>first</message></error>` +
+		`<error><message>second</message></error></cause>`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("document = %s, want it to contain %s", data, want)
+	}
+}
@@ -0,0 +1,192 @@
+package xerrors
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+const (
+	// CompressionGzip is the name of the built-in compressor which compresses documents using gzip.
+	CompressionGzip = "gzip"
+
+	// MaxDecompressedSize is the maximum size of a document decompressed by [DecompressJSON], which protects
+	// consumers against compression bombs.
+	MaxDecompressedSize = 64 << 20
+
+	// _compressedMarker is the start of every compressed envelope, which lets decoders detect envelopes cheaply.
+	_compressedMarker = `{"$compressed":`
+)
+
+var (
+	_compressors = map[string]Compressor{
+		CompressionGzip: &gzipCompressor{},
+	}
+	_compressorsMutex sync.RWMutex
+)
+
+// Compressor compresses and decompresses the documents of large errors (see [CompressJSON]), eg: using zstd.
+//
+// Compressors are registered by name using [RegisterCompressor].
+type Compressor interface {
+	// Compress should return the compressed form of the given data.
+	Compress(data []byte) ([]byte, error)
+
+	// Decompress should return the original form of the given compressed data, failing if it would be longer than
+	// limit bytes.
+	Decompress(data []byte, limit int) ([]byte, error)
+}
+
+// compressedEnvelope is the JSON document which holds a compressed document.
+type compressedEnvelope struct {
+	// Compressed is the name of the compressor which compressed the document.
+	Compressed string `json:"$compressed"`
+
+	// Data is the base64-encoded compressed document.
+	Data []byte `json:"data"`
+
+	// Size is the length of the original document.
+	Size int `json:"size"`
+}
+
+// RegisterCompressor registers the compressor with the given name, replacing any compressor (including the built-in
+// one) already registered with that name.  Passing a nil compressor removes the compressor.
+//
+// The same compressors must be registered by the producers and the consumers of compressed documents.  This call is
+// thread-safe.
+func RegisterCompressor(name string, compressor Compressor) {
+	_compressorsMutex.Lock()
+	if compressor == nil {
+		delete(_compressors, name)
+	} else {
+		_compressors[name] = compressor
+	}
+	_compressorsMutex.Unlock()
+}
+
+// LookupCompressor returns the compressor registered with the given name.
+//
+// The second return value is false if no compressor has been registered with the name.  This call is thread-safe.
+func LookupCompressor(name string) (Compressor, bool) {
+	_compressorsMutex.RLock()
+	defer _compressorsMutex.RUnlock()
+	compressor, ok := _compressors[name]
+	return compressor, ok
+}
+
+// CompressJSON compresses the JSON document using the compressor registered with the given name if it is longer
+// than threshold bytes, so that errors with huge attributes or thousands of aggregated errors stay within the size
+// limits of message buses.  Shorter documents are returned unchanged.
+//
+// The compressed document is wrapped in an envelope of the form {"$compressed":"gzip","data":"...","size":1234},
+// where data is the base64-encoded compressed document and size is the length of the original document.  Envelopes
+// are decoded transparently by [ParseJSON] and can be decoded explicitly using [DecompressJSON].
+//
+// An error is returned if no compressor has been registered with the name or if compressing fails.
+func CompressJSON(data []byte, threshold int, name string) ([]byte, error) {
+	if len(data) <= threshold {
+		return data, nil
+	}
+	compressor, ok := LookupCompressor(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown compressor: %q", name)
+	}
+	compressed, err := compressor.Compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress error document: %w", err)
+	}
+
+	enc := base64.StdEncoding
+	dst := make([]byte, 0, len(_compressedMarker)+len(name)+enc.EncodedLen(len(compressed))+32)
+	dst = append(dst, _compressedMarker...)
+	dst = appendJSONString(dst, name)
+	dst = append(dst, `,"data":"`...)
+	dst = enc.AppendEncode(dst, compressed)
+	dst = append(dst, `","size":`...)
+	dst = strconv.AppendInt(dst, int64(len(data)), 10)
+	return append(dst, '}'), nil
+}
+
+// DecompressJSON returns the original document held in the envelope produced by [CompressJSON].  Documents which are
+// not envelopes are returned unchanged.
+//
+// An error is returned if the compressor named in the envelope has not been registered, if the data is corrupt or
+// if the original document is longer than [MaxDecompressedSize].
+func DecompressJSON(data []byte) ([]byte, error) {
+	if !isCompressedJSON(data) {
+		return data, nil
+	}
+	var envelope compressedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid compressed error document: %w", err)
+	}
+	if envelope.Size < 0 || envelope.Size > MaxDecompressedSize {
+		return nil, fmt.Errorf("invalid compressed error document: size %d exceeds the limit of %d bytes",
+			envelope.Size, MaxDecompressedSize)
+	}
+	compressor, ok := LookupCompressor(envelope.Compressed)
+	if !ok {
+		return nil, fmt.Errorf("invalid compressed error document: unknown compressor %q", envelope.Compressed)
+	}
+	decompressed, err := compressor.Decompress(envelope.Data, envelope.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed error document: %w", err)
+	}
+	if len(decompressed) != envelope.Size {
+		return nil, fmt.Errorf("invalid compressed error document: expected %d bytes but got %d", envelope.Size,
+			len(decompressed))
+	}
+	return decompressed, nil
+}
+
+// isCompressedJSON returns true if the document is an envelope produced by [CompressJSON].
+func isCompressedJSON(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte(_compressedMarker))
+}
+
+// gzipCompressor is the built-in [Compressor] which uses gzip.
+type gzipCompressor struct {
+	// unexported variables
+	writers sync.Pool // reusable writers, which are expensive to allocate
+}
+
+// Compress returns the gzip-compressed form of the data.
+func (c *gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, ok := c.writers.Get().(*gzip.Writer)
+	if ok {
+		w.Reset(&buf)
+	} else {
+		w = gzip.NewWriter(&buf)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress returns the original form of the gzip-compressed data.
+func (c *gzipCompressor) Decompress(data []byte, limit int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	decompressed, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > limit {
+		return nil, fmt.Errorf("decompressed data exceeds %d bytes", limit)
+	}
+	return decompressed, nil
+}
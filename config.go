@@ -0,0 +1,137 @@
+package xerrors
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
+)
+
+var (
+	_config        atomic.Pointer[Config] // current settings or nil until the first update
+	_defaultConfig = Config{
+		MaxChainDepth:    DefaultMaxChainDepth,
+		ReportSampleRate: 1,
+	}
+)
+
+// Config is a snapshot of the global settings of this package.
+//
+// The settings are read without locking whenever an error is created or marshaled and are replaced as a whole, so
+// settings which are changed together using [UpdateConfig] (eg: enabling caller capture along with the prefixes
+// stripped from the callers) are never observed half-applied.  The individual setters of this package, such as
+// [CaptureCallerInfo] and [SetMaxChainDepth], update a single setting of the snapshot.
+type Config struct {
+	// CaptureCaller controls whether the caller information is captured (see [CaptureCallerInfo]).
+	CaptureCaller bool
+
+	// CallerFilePrefixes are the prefixes stripped from the files of captured callers (see
+	// [StripCallerFilePrefixes]).
+	CallerFilePrefixes []string
+
+	// CollapseDuplicateWraps controls whether messages which repeat the messages they wrap are dropped from composed
+	// messages (see [CollapseDuplicateWraps]).
+	CollapseDuplicateWraps bool
+
+	// CodeRegistry is the registry used to name codes (see [SetCodeRegistry]).
+	CodeRegistry *Registry
+
+	// ComposeMessages controls whether Error() includes the messages of wrapped errors (see [ComposeMessages]).
+	ComposeMessages bool
+
+	// DuplicateWrapHandler is called for each new error whose message duplicates the message it wraps (see
+	// [DetectDuplicateWraps]).
+	DuplicateWrapHandler func(err Error)
+
+	// IDGenerator generates the unique IDs of new errors (see [SetIDGenerator]).
+	IDGenerator IDGenerator
+
+	// KeyNormalizer normalizes the keys of attributes as they are added (see [SetKeyNormalizer]).
+	KeyNormalizer *KeyNormalizer
+
+	// MaxChainDepth is the maximum number of errors visited when walking a chain (see [SetMaxChainDepth]).
+	MaxChainDepth int
+
+	// PanicOnViolation controls whether assertions panic (see [PanicOnViolation]).
+	PanicOnViolation bool
+
+	// ReportMinSeverity is the lowest severity of the errors reported by a [Dispatcher] or a [Sink] used as a
+	// [Reporter].  Errors with a lower severity, including errors without one if it is above [SeverityUnknown], are
+	// dropped.
+	ReportMinSeverity Severity
+
+	// ReportSampleRate is the fraction of the errors reported by a [Dispatcher] or a [Sink] used as a [Reporter],
+	// between 0 and 1 (the default).  The remaining errors are dropped.
+	ReportSampleRate float64
+
+	// StackDepth is the maximum number of stack frames captured (see [CaptureStackTrace]).
+	StackDepth int
+
+	// StringFormatter renders the String() of errors without a formatter of their own (see [SetStringFormatter]).
+	StringFormatter StringFormatter
+
+	// SymbolicCodes controls whether a [Code] and the code field of marshaled errors are marshaled as names (see
+	// [MarshalSymbolicCodes]).
+	SymbolicCodes bool
+
+	// TraceWrapSites controls whether the creation sites of errors are recorded when caller capture is disabled (see
+	// [TraceWrapSites]).
+	TraceWrapSites bool
+}
+
+// CurrentConfig returns a copy of the current global settings.
+//
+// This call is thread-safe.
+func CurrentConfig() Config {
+	c := *loadConfig()
+	c.CallerFilePrefixes = slices.Clone(c.CallerFilePrefixes)
+	return c
+}
+
+// UpdateConfig atomically replaces the global settings with a copy of the current settings modified by the given
+// function, eg:
+//
+//	xerrors.UpdateConfig(func(c *xerrors.Config) {
+//		c.CaptureCaller = true
+//		c.CallerFilePrefixes = []string{"/build/src/"}
+//	})
+//
+// Updates never block errors from being created.  If another update happens concurrently, the function is called
+// again with the newer settings, so it should only modify the given settings.  A stack depth less than 0 is treated
+// as 0, a report sample rate outside of [0, 1] is clamped and a maximum chain depth less than 1 restores the
+// [DefaultMaxChainDepth].  This call is thread-safe.
+func UpdateConfig(fn func(*Config)) {
+	for {
+		current := _config.Load()
+		updated := _defaultConfig
+		if current != nil {
+			updated = *current
+		}
+		updated.CallerFilePrefixes = slices.Clone(updated.CallerFilePrefixes)
+		fn(&updated)
+		updated.ReportSampleRate = min(max(updated.ReportSampleRate, 0), 1)
+		updated.StackDepth = max(updated.StackDepth, 0)
+		if updated.MaxChainDepth < 1 {
+			updated.MaxChainDepth = DefaultMaxChainDepth
+		}
+		if _config.CompareAndSwap(current, &updated) {
+			return
+		}
+	}
+}
+
+// reportable returns true if the error passes the minimum severity and sample rate of the reports (see [Config]).
+func reportable(err Error) bool {
+	cfg := loadConfig()
+	if err.Severity() < cfg.ReportMinSeverity {
+		return false
+	}
+	return cfg.ReportSampleRate >= 1 || rand.Float64() < cfg.ReportSampleRate
+}
+
+// loadConfig returns the current global settings, which must not be modified.
+func loadConfig() *Config {
+	if c := _config.Load(); c != nil {
+		return c
+	}
+	return &_defaultConfig
+}
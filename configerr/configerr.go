@@ -0,0 +1,202 @@
+// Package configerr builds a single [xerrors.Error] which reports every problem found while validating a
+// configuration, rather than stopping at the first one.
+package configerr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+var (
+	// _factory attributes the error to the code which called Err.
+	_factory = xerrors.NewFactory(xerrors.WithCallerSkip(1))
+)
+
+// Problem is a single problem found in a configuration.
+type Problem struct {
+	// Path is the dotted path of the setting with the problem, eg: "server.tls.cert_file".
+	Path string `json:"path"`
+
+	// Message describes the problem, eg: "file does not exist".
+	Message string `json:"message"`
+
+	// Expected describes the value which was expected, if given.
+	Expected string `json:"expected,omitempty"`
+
+	// Actual is the value which was found, if given.
+	Actual string `json:"actual,omitempty"`
+
+	// Suggestion tells the user how to fix the problem, if given.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// String returns the problem on a single line, eg:
+//
+//	server.port: out of range (expected 1-65535, got 70000); use a port number below 65536
+func (p Problem) String() string {
+	var sb strings.Builder
+	if p.Path != "" {
+		sb.WriteString(p.Path)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(p.Message)
+	switch {
+	case p.Expected != "" && p.Actual != "":
+		sb.WriteString(" (expected " + p.Expected + ", got " + p.Actual + ")")
+	case p.Expected != "":
+		sb.WriteString(" (expected " + p.Expected + ")")
+	case p.Actual != "":
+		sb.WriteString(" (got " + p.Actual + ")")
+	}
+	if p.Suggestion != "" {
+		sb.WriteString("; " + p.Suggestion)
+	}
+	return sb.String()
+}
+
+// Problems is the list of problems found in a configuration.
+//
+// The error created by [Builder.Err] wraps the list, so it can be retrieved using [errors.As].
+type Problems []Problem
+
+// Error returns the problems, one per line.
+func (p Problems) Error() string {
+	lines := make([]string, len(p))
+	for i, problem := range p {
+		lines[i] = problem.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ProblemOption is a function which sets an optional detail of a [Problem].
+type ProblemOption func(*Problem)
+
+// WithActual sets the value which was found.  Strings are quoted, any other value is formatted using the %v verb.
+func WithActual(value any) ProblemOption {
+	return func(p *Problem) {
+		p.Actual = formatValue(value)
+	}
+}
+
+// WithExpected sets a description of the value which was expected, eg: "an absolute path".
+func WithExpected(expected string) ProblemOption {
+	return func(p *Problem) {
+		p.Expected = expected
+	}
+}
+
+// WithSuggestion sets a hint on how to fix the problem, eg: `did you mean "cert_file"?`.
+func WithSuggestion(suggestion string) ProblemOption {
+	return func(p *Problem) {
+		p.Suggestion = suggestion
+	}
+}
+
+// problemList is the list of problems shared by a [Builder] and the builders derived from it.
+type problemList struct {
+	// unexported variables
+	mutex    sync.Mutex // guards problems
+	problems Problems   // problems found so far
+}
+
+// Builder collects the problems found while validating a configuration.
+//
+// Builders derived using At and Index add their problems to the same list, under a longer path, so nested sections
+// can be validated by functions which only know about their own keys.  A Builder is safe for concurrent use.
+type Builder struct {
+	// unexported variables
+	list *problemList // list the problems are added to
+	path string       // dotted path of the section the builder validates
+}
+
+// New creates a new [Builder] for the top level of a configuration.
+func New() *Builder {
+	return &Builder{list: &problemList{}}
+}
+
+// Add adds a problem with the setting with the given key, relative to the section of the builder.  An empty key
+// refers to the section itself.
+func (b *Builder) Add(key, message string, opts ...ProblemOption) {
+	problem := Problem{
+		Path:    b.join(key),
+		Message: message,
+	}
+	for _, opt := range opts {
+		opt(&problem)
+	}
+	b.list.mutex.Lock()
+	b.list.problems = append(b.list.problems, problem)
+	b.list.mutex.Unlock()
+}
+
+// Addf adds a problem with the setting with the given key, formatting the message according to the format specifier.
+func (b *Builder) Addf(key, format string, args ...any) {
+	b.Add(key, fmt.Sprintf(format, args...))
+}
+
+// At returns a builder for the section with the given key, relative to the section of the builder, eg:
+// b.At("server").At("tls") for the "server.tls" section.  Keys may also be dotted paths, eg: "server.tls".
+func (b *Builder) At(key string) *Builder {
+	return &Builder{list: b.list, path: b.join(key)}
+}
+
+// Index returns a builder for the element at the given index of the list held by the section of the builder, eg:
+// b.At("servers").Index(2) for the "servers[2]" section.
+func (b *Builder) Index(i int) *Builder {
+	return &Builder{list: b.list, path: b.path + "[" + strconv.Itoa(i) + "]"}
+}
+
+// Err returns a single [xerrors.Error] with the given code reporting every problem which has been added to the
+// builder or any builder derived from it, or nil if there are none.
+//
+// The message summarizes the number of problems, eg: "invalid configuration: 3 problems", and the error wraps the
+// [Problems], whose message lists them one per line, so text renderings such as [xerrors.TextFormatter] show the
+// full report.
+func (b *Builder) Err(code int) xerrors.Error {
+	problems := b.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	noun := "problems"
+	if len(problems) == 1 {
+		noun = "problem"
+	}
+	return _factory.Wrapf(code, problems, "invalid configuration: %d %s", len(problems), noun)
+}
+
+// Len returns the number of problems which have been added.
+func (b *Builder) Len() int {
+	b.list.mutex.Lock()
+	defer b.list.mutex.Unlock()
+	return len(b.list.problems)
+}
+
+// Problems returns a copy of the problems which have been added, in the order they were added.
+func (b *Builder) Problems() Problems {
+	b.list.mutex.Lock()
+	defer b.list.mutex.Unlock()
+	return append(Problems(nil), b.list.problems...)
+}
+
+// join returns the path of the given key, relative to the section of the builder.
+func (b *Builder) join(key string) string {
+	switch {
+	case key == "":
+		return b.path
+	case b.path == "":
+		return key
+	}
+	return b.path + "." + key
+}
+
+// formatValue formats a value found in a configuration.
+func formatValue(value any) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprintf("%v", value)
+}
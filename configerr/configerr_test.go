@@ -0,0 +1,114 @@
+package configerr
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestProblemString(t *testing.T) {
+	tests := []struct {
+		problem Problem
+		want    string
+	}{
+		{
+			problem: Problem{Path: "server.port", Message: "out of range", Expected: "1-65535", Actual: "70000",
+				Suggestion: "use a port number below 65536"},
+			want: "server.port: out of range (expected 1-65535, got 70000); use a port number below 65536",
+		},
+		{
+			problem: Problem{Path: "server.tls", Message: "missing file", Expected: "an absolute path"},
+			want:    "server.tls: missing file (expected an absolute path)",
+		},
+		{
+			problem: Problem{Path: "mode", Message: "unknown mode", Actual: `"fast"`},
+			want:    `mode: unknown mode (got "fast")`,
+		},
+		{
+			problem: Problem{Message: "empty configuration"},
+			want:    "empty configuration",
+		},
+	}
+	for _, test := range tests {
+		if got := test.problem.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestBuilderPaths(t *testing.T) {
+	b := New()
+	b.Add("name", "is required")
+	server := b.At("server")
+	server.At("tls").Add("cert_file", "file does not exist", WithActual("/etc/cert.pem"))
+	server.Add("", "is deprecated", WithSuggestion(`use "listeners"`))
+	b.At("servers").Index(2).Addf("port", "must be below %d", 65536)
+	b.At("a.b").Add("c", "is invalid", WithActual(42), WithExpected("a string"))
+
+	want := []string{
+		"name: is required",
+		`server.tls.cert_file: file does not exist (got "/etc/cert.pem")`,
+		`server: is deprecated; use "listeners"`,
+		"servers[2].port: must be below 65536",
+		"a.b.c: is invalid (expected a string, got 42)",
+	}
+	problems := b.Problems()
+	if len(problems) != len(want) || b.Len() != len(want) {
+		t.Fatalf("problems = %v, want %d problems", problems, len(want))
+	}
+	for i, problem := range problems {
+		if got := problem.String(); got != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestBuilderErr(t *testing.T) {
+	b := New()
+	if err := b.Err(12); err != nil {
+		t.Errorf("Err() = %v without problems, want nil", err)
+	}
+
+	b.Add("port", "is required")
+	if err := b.Err(12); err == nil || err.Message() != "invalid configuration: 1 problem" {
+		t.Errorf("Err() = %v, want a single problem", err)
+	}
+
+	b.Add("host", "is required")
+	err := b.Err(12)
+	if err.Code() != 12 || err.Message() != "invalid configuration: 2 problems" {
+		t.Errorf("Err() = %d %q, want 12 and 2 problems", err.Code(), err.Message())
+	}
+	var problems Problems
+	if !errors.As(err, &problems) || len(problems) != 2 {
+		t.Fatalf("the error does not wrap the problems: %v", err)
+	}
+	if got := problems.Error(); got != "port: is required\nhost: is required" {
+		t.Errorf("Problems.Error() = %q", got)
+	}
+
+	// the problems returned are a copy
+	problems[0].Message = "changed"
+	if got := b.Problems()[0].Message; got != "is required" {
+		t.Errorf("the problems of the builder were modified: %q", got)
+	}
+}
+
+func TestBuilderIsSafeForConcurrentUse(t *testing.T) {
+	b := New()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			section := b.At("servers").Index(i)
+			for range 100 {
+				section.Add("port", "is required")
+			}
+		}()
+	}
+	wg.Wait()
+	if b.Len() != 800 {
+		t.Errorf("Len() = %d, want 800", b.Len())
+	}
+}
@@ -0,0 +1,176 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	// _maxConfigBodySize is the maximum size of a request body accepted by the handler created by NewConfigHandler.
+	_maxConfigBodySize = 64 << 10
+)
+
+// configDocument is the JSON representation of the settings exposed by the handler created by [NewConfigHandler].
+//
+// Nil fields are left unchanged when the settings are updated.
+type configDocument struct {
+	// CallerFilePrefixes are the prefixes stripped from the files of captured callers.
+	CallerFilePrefixes *[]string `json:"callerFilePrefixes,omitempty"`
+
+	// CaptureCaller controls whether the caller information is captured.
+	CaptureCaller *bool `json:"captureCaller,omitempty"`
+
+	// CollapseDuplicateWraps controls whether repeated messages are dropped from composed messages.
+	CollapseDuplicateWraps *bool `json:"collapseDuplicateWraps,omitempty"`
+
+	// ComposeMessages controls whether Error() includes the messages of wrapped errors.
+	ComposeMessages *bool `json:"composeMessages,omitempty"`
+
+	// MaxChainDepth is the maximum number of errors visited when walking a chain.
+	MaxChainDepth *int `json:"maxChainDepth,omitempty"`
+
+	// PanicOnViolation controls whether assertions panic.
+	PanicOnViolation *bool `json:"panicOnViolation,omitempty"`
+
+	// ReportMinSeverity is the lowest severity of the errors which are reported.
+	ReportMinSeverity *Severity `json:"reportMinSeverity,omitempty"`
+
+	// ReportSampleRate is the fraction of the errors which are reported.
+	ReportSampleRate *float64 `json:"reportSampleRate,omitempty"`
+
+	// StackDepth is the maximum number of stack frames captured.
+	StackDepth *int `json:"stackDepth,omitempty"`
+
+	// SymbolicCodes controls whether codes are marshaled as their names.
+	SymbolicCodes *bool `json:"symbolicCodes,omitempty"`
+
+	// TraceWrapSites controls whether the creation sites of errors are recorded.
+	TraceWrapSites *bool `json:"traceWrapSites,omitempty"`
+}
+
+// configHandler is the [http.Handler] created by [NewConfigHandler].
+type configHandler struct {
+	// unexported variables
+	authorize func(*http.Request) bool // authorizes updates or nil to reject every update
+}
+
+// ConfigHandlerOption is a function which configures the handler created by [NewConfigHandler].
+type ConfigHandlerOption func(*configHandler)
+
+// WithConfigAuthorizer sets the function which decides whether a request may update the settings, eg: by checking a
+// bearer token or the address of the client.  Without an authorizer, the handler is read-only.
+func WithConfigAuthorizer(authorize func(r *http.Request) bool) ConfigHandlerOption {
+	return func(h *configHandler) {
+		h.authorize = authorize
+	}
+}
+
+// NewConfigHandler creates an [http.Handler] which exposes the global settings of this package (see [Config]) so
+// that the errors of a misbehaving instance can be made more detailed while it is being debugged, eg:
+//
+//	mux.Handle("/debug/xerrors", xerrors.NewConfigHandler(xerrors.WithConfigAuthorizer(isAdmin)))
+//
+// A GET request returns the settings as a JSON object with the fields callerFilePrefixes, captureCaller,
+// collapseDuplicateWraps, composeMessages, maxChainDepth, panicOnViolation, reportMinSeverity, reportSampleRate,
+// stackDepth, symbolicCodes and traceWrapSites.  A PATCH, POST or PUT request with a JSON object holding any of those
+// fields changes them all at once (see [UpdateConfig]) and returns the updated settings.  Updates are rejected with
+// 403 Forbidden unless they are allowed by the authorizer set using [WithConfigAuthorizer] and with 400 Bad Request if
+// the object contains unknown fields.
+func NewConfigHandler(opts ...ConfigHandlerOption) http.Handler {
+	h := &configHandler{}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP serves the settings or updates them.
+func (h *configHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPatch, http.MethodPost, http.MethodPut:
+		if h.authorize == nil || !h.authorize(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		var doc configDocument
+		dec := json.NewDecoder(io.LimitReader(r.Body, _maxConfigBodySize))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&doc); err != nil {
+			http.Error(w, "invalid settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		UpdateConfig(doc.apply)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PATCH, POST, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := json.Marshal(newConfigDocument(CurrentConfig()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// newConfigDocument returns the document exposing the given settings.
+func newConfigDocument(c Config) *configDocument {
+	if c.CallerFilePrefixes == nil {
+		c.CallerFilePrefixes = []string{}
+	}
+	return &configDocument{
+		CallerFilePrefixes:     &c.CallerFilePrefixes,
+		CaptureCaller:          &c.CaptureCaller,
+		CollapseDuplicateWraps: &c.CollapseDuplicateWraps,
+		ComposeMessages:        &c.ComposeMessages,
+		MaxChainDepth:          &c.MaxChainDepth,
+		PanicOnViolation:       &c.PanicOnViolation,
+		ReportMinSeverity:      &c.ReportMinSeverity,
+		ReportSampleRate:       &c.ReportSampleRate,
+		StackDepth:             &c.StackDepth,
+		SymbolicCodes:          &c.SymbolicCodes,
+		TraceWrapSites:         &c.TraceWrapSites,
+	}
+}
+
+// apply changes the settings which are set in the document.
+func (d *configDocument) apply(c *Config) {
+	if d.CallerFilePrefixes != nil {
+		c.CallerFilePrefixes = *d.CallerFilePrefixes
+	}
+	if d.CaptureCaller != nil {
+		c.CaptureCaller = *d.CaptureCaller
+	}
+	if d.CollapseDuplicateWraps != nil {
+		c.CollapseDuplicateWraps = *d.CollapseDuplicateWraps
+	}
+	if d.ComposeMessages != nil {
+		c.ComposeMessages = *d.ComposeMessages
+	}
+	if d.MaxChainDepth != nil {
+		c.MaxChainDepth = *d.MaxChainDepth
+	}
+	if d.PanicOnViolation != nil {
+		c.PanicOnViolation = *d.PanicOnViolation
+	}
+	if d.ReportMinSeverity != nil {
+		c.ReportMinSeverity = *d.ReportMinSeverity
+	}
+	if d.ReportSampleRate != nil {
+		c.ReportSampleRate = *d.ReportSampleRate
+	}
+	if d.StackDepth != nil {
+		c.StackDepth = *d.StackDepth
+	}
+	if d.SymbolicCodes != nil {
+		c.SymbolicCodes = *d.SymbolicCodes
+	}
+	if d.TraceWrapSites != nil {
+		c.TraceWrapSites = *d.TraceWrapSites
+	}
+}
@@ -0,0 +1,131 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveConfig sends a request with the given method and body to a handler created with the given options.
+func serveConfig(method, body string, opts ...ConfigHandlerOption) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	NewConfigHandler(opts...).ServeHTTP(rec, httptest.NewRequest(method, "/debug/xerrors", strings.NewReader(body)))
+	return rec
+}
+
+// restoreConfig restores the current global settings once the test completes.
+func restoreConfig(t *testing.T) {
+	saved := CurrentConfig()
+	t.Cleanup(func() {
+		UpdateConfig(func(c *Config) {
+			*c = saved
+		})
+	})
+}
+
+// allowAll authorizes every update.
+func allowAll(*http.Request) bool {
+	return true
+}
+
+func TestConfigHandlerServesSettings(t *testing.T) {
+	restoreConfig(t)
+	UpdateConfig(func(c *Config) {
+		c.MaxChainDepth = 42
+		c.StackDepth = 8
+	})
+
+	rec := serveConfig(http.MethodGet, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" ||
+		rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("unexpected response %d with headers %v", rec.Code, rec.Header())
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid settings document: %v", err)
+	}
+	fields := []string{
+		"callerFilePrefixes", "captureCaller", "collapseDuplicateWraps", "composeMessages", "maxChainDepth",
+		"panicOnViolation", "reportMinSeverity", "reportSampleRate", "stackDepth", "symbolicCodes", "traceWrapSites",
+	}
+	if len(doc) != len(fields) {
+		t.Errorf("document = %v, want the fields %v", doc, fields)
+	}
+	for _, field := range fields {
+		if _, ok := doc[field]; !ok {
+			t.Errorf("missing field %s in %v", field, doc)
+		}
+	}
+	if doc["maxChainDepth"] != 42.0 || doc["stackDepth"] != 8.0 {
+		t.Errorf("document = %v, want maxChainDepth 42 and stackDepth 8", doc)
+	}
+	if prefixes, ok := doc["callerFilePrefixes"].([]any); !ok || len(prefixes) != 0 {
+		t.Errorf("callerFilePrefixes = %v, want an empty list", doc["callerFilePrefixes"])
+	}
+}
+
+func TestConfigHandlerUpdatesSettings(t *testing.T) {
+	restoreConfig(t)
+	for _, method := range []string{http.MethodPatch, http.MethodPost, http.MethodPut} {
+		UpdateConfig(func(c *Config) {
+			c.ComposeMessages = false
+			c.StackDepth = 0
+		})
+		rec := serveConfig(method, `{"composeMessages":true,"stackDepth":16}`, WithConfigAuthorizer(allowAll))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d: %s", method, rec.Code, rec.Body)
+			continue
+		}
+		if cfg := CurrentConfig(); !cfg.ComposeMessages || cfg.StackDepth != 16 {
+			t.Errorf("%s: the settings were not updated: %+v", method, cfg)
+		}
+		if !strings.Contains(rec.Body.String(), `"stackDepth":16`) {
+			t.Errorf("%s: the updated settings were not returned: %s", method, rec.Body)
+		}
+	}
+}
+
+func TestConfigHandlerRejectsUnauthorizedUpdates(t *testing.T) {
+	restoreConfig(t)
+	before := CurrentConfig().StackDepth
+	tests := map[string][]ConfigHandlerOption{
+		"no authorizer": nil,
+		"denied":        {WithConfigAuthorizer(func(*http.Request) bool { return false })},
+	}
+	for name, opts := range tests {
+		if rec := serveConfig(http.MethodPost, `{"stackDepth":64}`, opts...); rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusForbidden)
+		}
+	}
+	if got := CurrentConfig().StackDepth; got != before {
+		t.Errorf("StackDepth = %d after rejected updates, want %d", got, before)
+	}
+}
+
+func TestConfigHandlerRejectsInvalidUpdates(t *testing.T) {
+	restoreConfig(t)
+	before := CurrentConfig().StackDepth
+	tests := map[string]string{
+		"unknown field": `{"stackDepth":64,"verbose":true}`,
+		"wrong type":    `{"stackDepth":"64"}`,
+		"too large":     `{"stackDepth":64,"callerFilePrefixes":["` + strings.Repeat("a", _maxConfigBodySize) + `"]}`,
+	}
+	for name, body := range tests {
+		rec := serveConfig(http.MethodPatch, body, WithConfigAuthorizer(allowAll))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if got := CurrentConfig().StackDepth; got != before {
+		t.Errorf("StackDepth = %d after invalid updates, want %d", got, before)
+	}
+}
+
+func TestConfigHandlerRejectsOtherMethods(t *testing.T) {
+	rec := serveConfig(http.MethodDelete, "", WithConfigAuthorizer(allowAll))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD, PATCH, POST, PUT" {
+		t.Errorf("unexpected response %d with headers %v", rec.Code, rec.Header())
+	}
+}
@@ -0,0 +1,203 @@
+package xerrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+const (
+	// DeadlineAttr is the name of the attribute holding the context deadline when an error is created with a
+	// context which is done.
+	DeadlineAttr = "deadline"
+
+	// ElapsedAttr is the name of the attribute holding the time elapsed since [WithStartTime] was called when an
+	// error is created with a context which is done.
+	ElapsedAttr = "elapsed"
+)
+
+var (
+	_enrichers      = []ContextEnricher{}
+	_enrichersMutex sync.Mutex
+)
+
+// scopeKey is the context key holding the attributes set by [WithScope].
+type scopeKey struct{}
+
+// startTimeKey is the context key holding the time set by [WithStartTime].
+type startTimeKey struct{}
+
+// ContextEnricher is a function which returns attributes to add to an error created with a context, eg: the trace
+// and span IDs of the active span.
+//
+// The function may return nil if the context does not contain anything of interest.
+type ContextEnricher func(ctx context.Context) map[string]any
+
+// RegisterContextEnricher adds an enricher which is applied to every error created with a context using
+// [NewContext], [NewContextf], [WrapContext], [WrapContextf] or the equivalent [Factory] methods.
+//
+// This function affects all errors generated globally by this package.  This call is thread-safe.
+func RegisterContextEnricher(enricher ContextEnricher) {
+	_enrichersMutex.Lock()
+	_enrichers = append(_enrichers, enricher)
+	_enrichersMutex.Unlock()
+}
+
+// WithScope returns a copy of the context which carries the given request-scoped attributes (eg: the request ID,
+// route and tenant), merged with those of any enclosing scope.  Attributes of the inner scope take precedence.
+//
+// The attributes are added to every error created with the context (or a context derived from it) using the
+// context-aware constructors and to the errors passed to [Enrich], so middleware can stamp them onto any error
+// returned by a handler, eg:
+//
+//	func middleware(next func(ctx context.Context) error) func(ctx context.Context) error {
+//		return func(ctx context.Context) error {
+//			ctx = xerrors.WithScope(ctx, map[string]any{"route": "/users"})
+//			return xerrors.Enrich(ctx, next(ctx))
+//		}
+//	}
+func WithScope(ctx context.Context, attrs map[string]any) context.Context {
+	scope, _ := ctx.Value(scopeKey{}).(map[string]any)
+	merged := make(map[string]any, len(scope)+len(attrs))
+	maps.Copy(merged, scope)
+	maps.Copy(merged, attrs)
+	return context.WithValue(ctx, scopeKey{}, merged)
+}
+
+// Enrich adds the attributes of the scopes of the context (see [WithScope]) and those returned by the enrichers
+// registered using [RegisterContextEnricher] to the given error, for errors which were not created with the context,
+// eg: because deep code used [New] rather than [NewContext].
+//
+// Attributes which the error already has are kept.  If the error is an [Error], it is modified and returned;
+// otherwise it is wrapped in a new [Error] with code 0 and the same message.  A nil error returns nil.
+func Enrich(ctx context.Context, err error) Error {
+	if err == nil {
+		return nil
+	}
+	xerr, ok := err.(Error)
+	if !ok {
+		// the context attributes are added below, so the wrapper is created without one
+		xerr = newError(nil, nil, 0, 0, err.Error(), err)
+	}
+	existing := xerr.Attrs()
+	for k, v := range contextAttrs(ctx, nil) {
+		if _, ok := existing[normalizeKey(k)]; !ok {
+			xerr.WithAttr(k, v)
+		}
+	}
+	return xerr
+}
+
+// WithStartTime returns a copy of the context which records the current time as the start of an operation.
+//
+// If an error is created with the returned context (or a context derived from it) after the context is done, the
+// time elapsed since the start is added to the error.
+func WithStartTime(ctx context.Context) context.Context {
+	return context.WithValue(ctx, startTimeKey{}, time.Now())
+}
+
+// NewContext creates a new [Error] with the given code and message, enriched with attributes from the context.
+func NewContext(ctx context.Context, code int, message string) Error {
+	return newError(ctx, nil, 0, code, message, nil)
+}
+
+// NewContextf creates a new [Error] with the given code and formatted message, enriched with attributes from the
+// context.
+func NewContextf(ctx context.Context, code int, format string, args ...any) Error {
+	return newError(ctx, nil, 0, code, fmt.Sprintf(format, args...), nil)
+}
+
+// WrapContext wraps the given error in a new [Error] with the given code and message, enriched with attributes from
+// the context.
+//
+// If the context has been canceled or its deadline has been exceeded (or the wrapped error is [context.Canceled] or
+// [context.DeadlineExceeded]), the error's kind is set to [KindCanceled] or [KindDeadlineExceeded] and the context
+// deadline and elapsed time (see [WithStartTime]) are added as attributes.  The same applies to all of the other
+// context-aware constructors.
+func WrapContext(ctx context.Context, code int, err error, message string) Error {
+	return newError(ctx, nil, 0, code, message, err)
+}
+
+// WrapContextf wraps the given error in a new [Error] with the given code and formatted message, enriched with
+// attributes from the context.
+func WrapContextf(ctx context.Context, code int, err error, format string, args ...any) Error {
+	return newError(ctx, nil, 0, code, fmt.Sprintf(format, args...), err)
+}
+
+// enrich annotates the error if the context is done and adds the attributes of the scopes of the context and those
+// returned by the global enrichers followed by the factory's enrichers to the error.
+func enrich(ctx context.Context, f *Factory, xerr *xerr) {
+	annotateDone(ctx, xerr)
+
+	if attrs := contextAttrs(ctx, f); len(attrs) > 0 {
+		// the keys are normalized as they are added, as if they were added using WithAttrs
+		xerr.WithAttrs(attrs)
+	}
+}
+
+// contextAttrs returns the attributes of the scopes of the context followed by those returned by the global enrichers
+// and the factory's enrichers, with later attributes taking precedence.
+func contextAttrs(ctx context.Context, f *Factory) map[string]any {
+	_enrichersMutex.Lock()
+	enrichers := _enrichers
+	_enrichersMutex.Unlock()
+	if f != nil {
+		enrichers = append(enrichers[:len(enrichers):len(enrichers)], f.enrichers...)
+	}
+
+	scope, _ := ctx.Value(scopeKey{}).(map[string]any)
+	if len(enrichers) == 0 {
+		return scope
+	}
+	attrs := maps.Clone(scope)
+	for _, enricher := range enrichers {
+		if enriched := enricher(ctx); len(enriched) > 0 {
+			if attrs == nil {
+				attrs = make(map[string]any, len(enriched))
+			}
+			maps.Copy(attrs, enriched)
+		}
+	}
+	return attrs
+}
+
+// annotateDone sets the kind and adds the deadline and elapsed time attributes to the error if the context is done
+// or the wrapped error indicates that a context was done.
+func annotateDone(ctx context.Context, xerr *xerr) {
+	cause := ctx.Err()
+	if cause == nil {
+		cause = xerr.wrappedErr
+	}
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded):
+		xerr.mutable().kind = KindDeadlineExceeded
+	case errors.Is(cause, context.Canceled):
+		xerr.mutable().kind = KindCanceled
+	default:
+		return
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		xerr.WithAttr(DeadlineAttr, deadline.Format(time.RFC3339Nano))
+	}
+	if start, ok := ctx.Value(startTimeKey{}).(time.Time); ok {
+		xerr.mutable().elapsed = time.Since(start)
+		xerr.WithAttr(ElapsedAttr, xerr.elapsed.String())
+	}
+}
+
+// Elapsed returns the time elapsed since [WithStartTime] was called when the given error was created with a done
+// context, as also recorded in its [ElapsedAttr] attribute.
+//
+// False is returned if the error was not created by this package with a done context and a start time.  The errors
+// it wraps are not searched, so that each elapsed time is only found once, eg: by a hook.  Errors reconstructed in
+// another process, eg: by [ParseJSON], only have the attribute.
+func Elapsed(err error) (time.Duration, bool) {
+	if xerr, ok := err.(*xerr); ok && xerr.elapsed > 0 {
+		return xerr.elapsed, true
+	}
+	return 0, false
+}
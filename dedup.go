@@ -0,0 +1,84 @@
+package xerrors
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDedupTTL is the time for which repeated occurrences of an error are suppressed by [ShouldReport].
+	DefaultDedupTTL = time.Minute
+)
+
+var (
+	_dedup = NewDeduplicator(DefaultDedupTTL)
+)
+
+// ShouldReport returns true if no error with the same [Fingerprint] has been reported within the last
+// [DefaultDedupTTL], using a package-level [Deduplicator].
+//
+// This is intended to keep tight retry loops from emitting thousands of identical logs, eg:
+//
+//	if xerrors.ShouldReport(err) {
+//		log.Print(err)
+//	}
+func ShouldReport(err error) bool {
+	return _dedup.ShouldReport(err)
+}
+
+// Deduplicator suppresses repeated occurrences of errors with the same [Fingerprint] within a time window.
+type Deduplicator struct {
+	// unexported variables
+	lastSweep time.Time            // when expired entries were last removed
+	mutex     sync.Mutex           // guards the deduplicator
+	seen      map[string]time.Time // when each fingerprint was last reported
+	ttl       time.Duration        // time for which repeated occurrences are suppressed
+}
+
+// NewDeduplicator creates a new [Deduplicator] which suppresses repeated occurrences of an error for the given
+// time-to-live after it is reported.
+func NewDeduplicator(ttl time.Duration) *Deduplicator {
+	return &Deduplicator{
+		lastSweep: time.Now(),
+		seen:      make(map[string]time.Time),
+		ttl:       ttl,
+	}
+}
+
+// Hook returns a [Hook] which only calls the given hook for the first occurrence of each error within the
+// time-to-live, eg: xerrors.RegisterHook(dedup.Hook(logError)).
+func (d *Deduplicator) Hook(hook Hook) Hook {
+	return func(err Error) {
+		if d.ShouldReport(err) {
+			hook(err)
+		}
+	}
+}
+
+// ShouldReport returns true if no error with the same fingerprint as the given error has been reported within the
+// time-to-live, recording the given error as reported if so.
+//
+// Nil errors are never reported.  This call is thread-safe.
+func (d *Deduplicator) ShouldReport(err error) bool {
+	if err == nil {
+		return false
+	}
+	fingerprint := Fingerprint(err)
+	now := time.Now()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if now.Sub(d.lastSweep) >= d.ttl {
+		for k, reported := range d.seen {
+			if now.Sub(reported) >= d.ttl {
+				delete(d.seen, k)
+			}
+		}
+		d.lastSweep = now
+	}
+	if reported, ok := d.seen[fingerprint]; ok && now.Sub(reported) < d.ttl {
+		return false
+	}
+	d.seen[fingerprint] = now
+	return true
+}
@@ -0,0 +1,289 @@
+package xerrors
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrDispatcherClosed is returned when an error is reported to a [Dispatcher] which has been closed.
+	ErrDispatcherClosed = errors.New("dispatcher is closed")
+
+	// ErrQueueFull is returned when an error is reported to a [Dispatcher] whose queue is full.
+	ErrQueueFull = errors.New("dispatcher queue is full")
+)
+
+// BatchReporter is an optional interface implemented by a [Reporter] which can send multiple errors at once.
+type BatchReporter interface {
+	Reporter
+
+	// ReportBatch should send the given errors to the service, returning an error if they could not be sent.
+	//
+	// The slice is not reused by the [Dispatcher] once the call returns, so it can be kept, eg: for sending it
+	// asynchronously.
+	ReportBatch(ctx context.Context, errs []Error) error
+}
+
+// Dispatcher is a [Reporter] which queues errors and sends them to another [Reporter] in the background so that
+// reporting never blocks the caller.
+//
+// Queued errors are sent in batches once the batch is full or the flush interval elapses, whichever comes first.
+// If the underlying reporter implements [BatchReporter], each batch is sent in a single call.  Failed sends are
+// retried with an exponential backoff.
+type Dispatcher struct {
+	// unexported variables
+	backoff       time.Duration        // delay before the first retry
+	batchSize     int                  // maximum number of errors sent at once
+	cancel        context.CancelFunc   // cancels in-flight sends when closing
+	closed        bool                 // whether or not the dispatcher has been closed
+	ctx           context.Context      // context used for sending errors
+	dropped       atomic.Uint64        // number of errors which could not be sent
+	errorHandler  func(error, []Error) // called when errors could not be sent
+	flushInterval time.Duration        // maximum time an error waits in the queue
+	flushReq      chan chan struct{}   // flush requests
+	maxBackoff    time.Duration        // maximum delay between retries
+	mutex         sync.RWMutex         // guards closing the queue
+	queue         chan Error           // the queued errors
+	queueSize     int                  // maximum number of queued errors
+	reporter      Reporter             // reporter to which errors are sent
+	retries       int                  // number of times a failed send is retried
+	stopped       chan struct{}        // closed once the background goroutine exits
+}
+
+// DispatcherOption is a function which configures a [Dispatcher].
+type DispatcherOption func(*Dispatcher)
+
+// WithBatchSize sets the maximum number of errors sent at once.  The default is 100.
+func WithBatchSize(size int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.batchSize = max(size, 1)
+	}
+}
+
+// WithDispatchErrorHandler sets a function which is called with the last error returned by the reporter and the
+// errors which could not be sent once all retries have been exhausted.
+func WithDispatchErrorHandler(handler func(error, []Error)) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.errorHandler = handler
+	}
+}
+
+// WithFlushInterval sets the maximum amount of time an error waits in the queue before being sent.  The default is
+// 5 seconds.
+func WithFlushInterval(interval time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		if interval > 0 {
+			d.flushInterval = interval
+		}
+	}
+}
+
+// WithQueueSize sets the maximum number of errors which can be queued.  Errors reported while the queue is full are
+// dropped.  The default is 1000.
+func WithQueueSize(size int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.queueSize = max(size, 1)
+	}
+}
+
+// WithRetries sets the number of times a failed send is retried along with the delay before the first retry and the
+// maximum delay between retries.  The delay doubles after each retry.  The default is 3 retries starting at 100
+// milliseconds with a maximum of 5 seconds.
+func WithRetries(retries int, backoff, maxBackoff time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.retries = max(retries, 0)
+		d.backoff = backoff
+		d.maxBackoff = max(maxBackoff, backoff)
+	}
+}
+
+// NewDispatcher creates a new [Dispatcher] which sends errors to the given reporter and starts its background
+// goroutine.
+//
+// Call [Dispatcher.Close] to send any queued errors and stop the dispatcher.
+func NewDispatcher(reporter Reporter, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		backoff:       100 * time.Millisecond,
+		batchSize:     100,
+		flushInterval: 5 * time.Second,
+		flushReq:      make(chan chan struct{}),
+		maxBackoff:    5 * time.Second,
+		queueSize:     1000,
+		reporter:      reporter,
+		retries:       3,
+		stopped:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.queue = make(chan Error, d.queueSize)
+	go d.run()
+	return d
+}
+
+// Close stops accepting new errors, sends any queued errors and stops the background goroutine.
+//
+// If the context is done before all queued errors have been sent, any in-flight sends are canceled and the context's
+// error is returned.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mutex.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mutex.Unlock()
+
+	select {
+	case <-d.stopped:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.stopped
+		return ctx.Err()
+	}
+}
+
+// Dropped returns the number of errors which were dropped because the queue was full or they could not be sent.
+func (d *Dispatcher) Dropped() uint64 {
+	return d.dropped.Load()
+}
+
+// Flush sends all of the errors which are currently queued, returning once they have been sent or the context is
+// done.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case d.flushReq <- ack:
+	case <-d.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Report queues a copy of the given error to be sent in the background, after applying the registered transformers
+// (see [Transform]).  The copy is taken when the error is queued, so the error can still be modified safely; changes
+// made afterwards are not sent.
+//
+// This function never blocks.  It returns [ErrQueueFull] if the queue is full or [ErrDispatcherClosed] if the
+// dispatcher has been closed, in which case the error is dropped.  Errors below the ReportMinSeverity or outside of
+// the ReportSampleRate of the [Config] are dropped without returning an error.
+func (d *Dispatcher) Report(ctx context.Context, err Error) error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+	if !reportable(err) {
+		return nil
+	}
+	select {
+	case d.queue <- snapshotError(Transform(err)):
+		return nil
+	default:
+		d.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// run is the background goroutine which sends queued errors in batches.
+func (d *Dispatcher) run() {
+	defer close(d.stopped)
+	ticker := time.NewTicker(d.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Error, 0, d.batchSize)
+	add := func(err Error) {
+		batch = append(batch, err)
+		if len(batch) >= d.batchSize {
+			d.send(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case err, ok := <-d.queue:
+			if !ok {
+				d.send(batch)
+				return
+			}
+			add(err)
+		case <-ticker.C:
+			d.send(batch)
+			batch = batch[:0]
+		case ack := <-d.flushReq:
+			for drained := false; !drained; {
+				select {
+				case err, ok := <-d.queue:
+					if !ok {
+						d.send(batch)
+						close(ack)
+						return
+					}
+					add(err)
+				default:
+					drained = true
+				}
+			}
+			d.send(batch)
+			batch = batch[:0]
+			close(ack)
+		}
+	}
+}
+
+// send sends the given batch of errors to the reporter, retrying any failures.
+func (d *Dispatcher) send(batch []Error) {
+	if len(batch) == 0 {
+		return
+	}
+	if br, ok := d.reporter.(BatchReporter); ok {
+		// the batch is reused by the background goroutine, so the reporter gets its own copy
+		errs := slices.Clone(batch)
+		d.retry(errs, func(ctx context.Context) error {
+			return br.ReportBatch(ctx, errs)
+		})
+		return
+	}
+	for _, err := range batch {
+		d.retry([]Error{err}, func(ctx context.Context) error {
+			return d.reporter.Report(ctx, err)
+		})
+	}
+}
+
+// retry calls the given function until it succeeds, the retries have been exhausted or the dispatcher is forcibly
+// closed.
+func (d *Dispatcher) retry(errs []Error, fn func(context.Context) error) {
+	backoff := d.backoff
+	for attempt := 0; ; attempt++ {
+		err := fn(d.ctx)
+		if err == nil {
+			return
+		}
+		if attempt >= d.retries || d.ctx.Err() != nil {
+			d.dropped.Add(uint64(len(errs)))
+			if d.errorHandler != nil {
+				d.errorHandler(err, append([]Error{}, errs...))
+			}
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-d.ctx.Done():
+		}
+		backoff = min(backoff*2, d.maxBackoff)
+	}
+}
@@ -0,0 +1,240 @@
+package xerrors
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingReporter records the errors reported to it, failing the first calls if requested.
+type recordingReporter struct {
+	// unexported variables
+	calls    int        // number of calls to Report or ReportBatch
+	codes    []int      // codes of the errors which were sent
+	failures int        // number of calls which fail before the reporter succeeds
+	mutex    sync.Mutex // guards the reporter
+}
+
+// Report records the given error.
+func (r *recordingReporter) Report(ctx context.Context, err Error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("service unavailable")
+	}
+	r.codes = append(r.codes, err.Code())
+	return nil
+}
+
+// sent returns the codes of the errors which were sent.
+func (r *recordingReporter) sent() []int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Clone(r.codes)
+}
+
+// retainingBatchReporter keeps the batches it is given, as a reporter sending them asynchronously would.
+type retainingBatchReporter struct {
+	recordingReporter
+
+	// unexported variables
+	batches [][]Error // batches which were sent
+}
+
+// ReportBatch keeps the given batch.
+func (r *retainingBatchReporter) ReportBatch(ctx context.Context, errs []Error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls++
+	r.batches = append(r.batches, errs)
+	return nil
+}
+
+// blockingReporter blocks each call until it is released or the context is done.
+type blockingReporter struct {
+	// unexported variables
+	release chan struct{} // closed to let the calls return
+	started chan struct{} // receives a value whenever a call starts
+}
+
+// Report blocks until the reporter is released or the context is done.
+func (r *blockingReporter) Report(ctx context.Context, err Error) error {
+	r.started <- struct{}{}
+	select {
+	case <-r.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestDispatcherSendsBatches(t *testing.T) {
+	reporter := &retainingBatchReporter{}
+	d := NewDispatcher(reporter, WithBatchSize(2), WithFlushInterval(time.Hour))
+	for code := range 5 {
+		if err := d.Report(context.Background(), New(code, "failed")); err != nil {
+			t.Fatalf("failed to report: %v", err)
+		}
+	}
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	// the batches are kept by the reporter, so they must not have been overwritten by the later ones
+	var got [][]int
+	for _, batch := range reporter.batches {
+		var codes []int
+		for _, err := range batch {
+			codes = append(codes, err.Code())
+		}
+		got = append(got, codes)
+	}
+	want := [][]int{{0, 1}, {2, 3}, {4}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("batches = %v, want %v", got, want)
+	}
+}
+
+func TestDispatcherSendsErrorsIndividually(t *testing.T) {
+	reporter := &recordingReporter{}
+	d := NewDispatcher(reporter, WithFlushInterval(time.Millisecond))
+	for code := range 3 {
+		d.Report(context.Background(), New(code, "failed"))
+	}
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	if got := reporter.sent(); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("sent %v, want [0 1 2]", got)
+	}
+}
+
+func TestDispatcherRetriesFailedSends(t *testing.T) {
+	reporter := &recordingReporter{failures: 2}
+	d := NewDispatcher(reporter, WithRetries(2, time.Millisecond, 2*time.Millisecond))
+	d.Report(context.Background(), New(1, "failed"))
+	d.Close(context.Background())
+
+	if got := reporter.sent(); !slices.Equal(got, []int{1}) {
+		t.Errorf("sent %v, want [1]", got)
+	}
+	if reporter.calls != 3 || d.Dropped() != 0 {
+		t.Errorf("got %d calls and %d dropped errors, want 3 and 0", reporter.calls, d.Dropped())
+	}
+}
+
+func TestDispatcherDropsErrorsOnceRetriesAreExhausted(t *testing.T) {
+	reporter := &recordingReporter{failures: 10}
+	var handled []Error
+	var handlerErr error
+	d := NewDispatcher(reporter, WithRetries(1, time.Millisecond, time.Millisecond),
+		WithDispatchErrorHandler(func(err error, errs []Error) {
+			handlerErr = err
+			handled = append(handled, errs...)
+		}))
+	d.Report(context.Background(), New(1, "failed"))
+	d.Report(context.Background(), New(2, "failed"))
+	d.Close(context.Background())
+
+	if reporter.calls != 4 || d.Dropped() != 2 {
+		t.Errorf("got %d calls and %d dropped errors, want 4 and 2", reporter.calls, d.Dropped())
+	}
+	if handlerErr == nil || len(handled) != 2 || handled[0].Code() != 1 || handled[1].Code() != 2 {
+		t.Errorf("the error handler was called with %v and %v", handlerErr, handled)
+	}
+}
+
+func TestDispatcherDropsErrorsWhenQueueIsFull(t *testing.T) {
+	reporter := &blockingReporter{release: make(chan struct{}), started: make(chan struct{}, 10)}
+	d := NewDispatcher(reporter, WithBatchSize(1), WithQueueSize(1))
+	d.Report(context.Background(), New(1, "sending"))
+	<-reporter.started
+
+	if err := d.Report(context.Background(), New(2, "queued")); err != nil {
+		t.Errorf("failed to queue: %v", err)
+	}
+	if err := d.Report(context.Background(), New(3, "dropped")); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Report() = %v, want %v", err, ErrQueueFull)
+	}
+	if d.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", d.Dropped())
+	}
+	close(reporter.release)
+	if err := d.Close(context.Background()); err != nil {
+		t.Errorf("failed to close: %v", err)
+	}
+}
+
+func TestDispatcherAfterClose(t *testing.T) {
+	reporter := &recordingReporter{}
+	d := NewDispatcher(reporter, WithFlushInterval(time.Hour))
+	d.Report(context.Background(), New(1, "queued"))
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	if got := reporter.sent(); !slices.Equal(got, []int{1}) {
+		t.Errorf("Close sent %v, want [1]", got)
+	}
+	if err := d.Report(context.Background(), New(2, "late")); !errors.Is(err, ErrDispatcherClosed) {
+		t.Errorf("Report() = %v, want %v", err, ErrDispatcherClosed)
+	}
+	if err := d.Flush(context.Background()); err != nil {
+		t.Errorf("Flush() = %v, want nil", err)
+	}
+	if err := d.Close(context.Background()); err != nil {
+		t.Errorf("second Close() = %v, want nil", err)
+	}
+}
+
+func TestDispatcherCloseCancelsInFlightSends(t *testing.T) {
+	reporter := &blockingReporter{release: make(chan struct{}), started: make(chan struct{}, 10)}
+	d := NewDispatcher(reporter, WithBatchSize(1), WithRetries(0, 0, 0))
+	d.Report(context.Background(), New(1, "stuck"))
+	<-reporter.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", d.Dropped())
+	}
+}
+
+func TestDispatcherCloseDuringReport(t *testing.T) {
+	reporter := &recordingReporter{}
+	d := NewDispatcher(reporter, WithQueueSize(10000))
+
+	const reporters, reports = 8, 100
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	rejected := 0
+	for range reporters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for code := range reports {
+				if err := d.Report(context.Background(), New(code, "failed")); err != nil {
+					mutex.Lock()
+					rejected++
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	if err := d.Close(context.Background()); err != nil {
+		t.Errorf("failed to close: %v", err)
+	}
+	wg.Wait()
+	if sent := len(reporter.sent()); sent+rejected != reporters*reports {
+		t.Errorf("sent %d errors and rejected %d, want %d in total", sent, rejected, reporters*reports)
+	}
+}
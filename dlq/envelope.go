@@ -0,0 +1,240 @@
+// Package dlq standardizes the payloads written to dead-letter queues by message consumers, eg: Kafka and NATS
+// consumers, which combine the metadata of the message that could not be processed with the structured error.
+//
+// The envelopes only hold plain values, so this package does not depend on any broker client library.
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// BrokerKafka is the broker of envelopes created by [NewKafkaEnvelope].
+	BrokerKafka = "kafka"
+
+	// BrokerNATS is the broker of envelopes created by [NewNATSEnvelope].
+	BrokerNATS = "nats"
+
+	// EnvelopeVersion is the format version of the envelopes produced by [Envelope.MarshalJSON].
+	EnvelopeVersion = 1
+)
+
+var (
+	// _factory attributes the errors wrapped by the envelope constructors to their callers.
+	_factory = xerrors.NewFactory(xerrors.WithCallerSkip(2))
+
+	// _profile marshals the error of an envelope in the format accepted by xerrors.ParseJSON.
+	_profile = &xerrors.MarshalProfile{Version: xerrors.LatestMarshalVersion}
+)
+
+// Envelope is the payload written to a dead-letter queue for a message which could not be processed.
+type Envelope struct {
+	// Broker is the kind of broker the message was consumed from, eg: [BrokerKafka].
+	Broker string `json:"broker"`
+
+	// Topic is the Kafka topic the message was consumed from, if any.
+	Topic string `json:"topic,omitempty"`
+
+	// Partition is the Kafka partition the message was consumed from.
+	Partition int32 `json:"partition,omitempty"`
+
+	// Offset is the offset of the message in its Kafka partition.
+	Offset int64 `json:"offset,omitempty"`
+
+	// Subject is the NATS subject the message was published to, if any.
+	Subject string `json:"subject,omitempty"`
+
+	// Stream is the NATS JetStream stream the message was consumed from, if any.
+	Stream string `json:"stream,omitempty"`
+
+	// Sequence is the sequence number of the message in its JetStream stream.
+	Sequence uint64 `json:"sequence,omitempty"`
+
+	// Consumer is the name of the consumer group or durable consumer which failed to process the message, if known.
+	Consumer string `json:"consumer,omitempty"`
+
+	// Attempts is the number of times processing the message was attempted, if known.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Key is the key of the message, if any.
+	Key []byte `json:"key,omitempty"`
+
+	// Headers are the headers of the message, if any.
+	Headers map[string][]string `json:"headers,omitempty"`
+
+	// Payload is the original body of the message, if it is kept.
+	Payload []byte `json:"payload,omitempty"`
+
+	// FailedAt is the time at which processing the message failed.
+	FailedAt time.Time `json:"failedAt"`
+
+	// Error is the error which caused the message to be dead-lettered.
+	Error xerrors.Error `json:"-"`
+}
+
+// Option is a function which sets optional metadata on an [Envelope].
+type Option func(*Envelope)
+
+// WithAttempts sets the number of times processing the message was attempted.
+func WithAttempts(attempts int) Option {
+	return func(e *Envelope) {
+		e.Attempts = attempts
+	}
+}
+
+// WithConsumer sets the name of the consumer group or durable consumer which failed to process the message.
+func WithConsumer(consumer string) Option {
+	return func(e *Envelope) {
+		e.Consumer = consumer
+	}
+}
+
+// WithHeaders sets the headers of the message.
+func WithHeaders(headers map[string][]string) Option {
+	return func(e *Envelope) {
+		e.Headers = headers
+	}
+}
+
+// WithKey sets the key of the message.
+func WithKey(key []byte) Option {
+	return func(e *Envelope) {
+		e.Key = key
+	}
+}
+
+// WithPayload keeps the original body of the message in the envelope, so that it can be replayed.
+func WithPayload(payload []byte) Option {
+	return func(e *Envelope) {
+		e.Payload = payload
+	}
+}
+
+// WithStream sets the JetStream stream the message was consumed from and its sequence number in the stream.
+func WithStream(stream string, sequence uint64) Option {
+	return func(e *Envelope) {
+		e.Stream = stream
+		e.Sequence = sequence
+	}
+}
+
+// NewKafkaEnvelope creates a new [Envelope] for the Kafka message at the given topic, partition and offset which
+// could not be processed because of the given error.
+//
+// If the error is not an [xerrors.Error], it is wrapped in one with code 0 and the same message.
+func NewKafkaEnvelope(err error, topic string, partition int32, offset int64, opts ...Option) *Envelope {
+	e := newEnvelope(err, BrokerKafka, opts)
+	e.Topic = topic
+	e.Partition = partition
+	e.Offset = offset
+	return e
+}
+
+// NewNATSEnvelope creates a new [Envelope] for the NATS message published to the given subject which could not be
+// processed because of the given error.  Use [WithStream] for messages consumed from JetStream.
+//
+// If the error is not an [xerrors.Error], it is wrapped in one with code 0 and the same message.
+func NewNATSEnvelope(err error, subject string, opts ...Option) *Envelope {
+	e := newEnvelope(err, BrokerNATS, opts)
+	e.Subject = subject
+	return e
+}
+
+// newEnvelope creates a new envelope for the given broker and applies the options.
+func newEnvelope(err error, broker string, opts []Option) *Envelope {
+	xerr, ok := err.(xerrors.Error)
+	if !ok && err != nil {
+		xerr = _factory.Wrap(0, err, err.Error())
+	}
+	e := &Envelope{
+		Broker:   broker,
+		Error:    xerr,
+		FailedAt: time.Now().UTC(),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// envelopeDocument is the JSON representation of an [Envelope].
+type envelopeDocument struct {
+	// embedded to marshal the metadata fields
+	*jsonEnvelope
+
+	// Error is the JSON document of the error.
+	Error json.RawMessage `json:"error"`
+
+	// Version is the format version of the envelope.
+	Version int `json:"version"`
+}
+
+// jsonEnvelope is a version of [Envelope] without its JSON methods.
+type jsonEnvelope Envelope
+
+// Marshal encodes the envelope as JSON.
+func Marshal(e *Envelope) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// Unmarshal decodes an envelope produced by [Marshal].
+func Unmarshal(data []byte) (*Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// MarshalJSON marshals the envelope to JSON.
+//
+// The error is marshaled after applying the registered transformers (see [xerrors.Transform]) in the format accepted
+// by [xerrors.ParseJSON], regardless of the marshal profile of its factory, so that every consumer of the dead-letter
+// queue can reconstruct it.
+func (e *Envelope) MarshalJSON() ([]byte, error) {
+	doc := envelopeDocument{
+		jsonEnvelope: (*jsonEnvelope)(e),
+		Error:        json.RawMessage("null"),
+		Version:      EnvelopeVersion,
+	}
+	if e.Error != nil {
+		data, err := xerrors.MarshalWithProfile(xerrors.Transform(e.Error), _profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal dead-letter error: %w", err)
+		}
+		doc.Error = data
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON unmarshals the envelope from JSON, reconstructing its error using [xerrors.ParseJSON].
+//
+// An error is returned if the envelope has a newer version than [EnvelopeVersion].
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var doc envelopeDocument
+	var env jsonEnvelope
+	doc.jsonEnvelope = &env
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Version < 1 || doc.Version > EnvelopeVersion {
+		return fmt.Errorf("invalid dead-letter envelope: unsupported version %d", doc.Version)
+	}
+	if doc.Broker == "" {
+		return errors.New("invalid dead-letter envelope: missing broker")
+	}
+	if len(doc.Error) > 0 && string(doc.Error) != "null" {
+		xerr, err := xerrors.ParseJSON(doc.Error)
+		if err != nil {
+			return fmt.Errorf("invalid dead-letter envelope error: %w", err)
+		}
+		env.Error = xerr
+	}
+	*e = Envelope(env)
+	return nil
+}
@@ -0,0 +1,86 @@
+package dlq
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+func TestKafkaEnvelopeRoundTrip(t *testing.T) {
+	err := xerrors.New(1042, "invalid order").WithAttr("orderId", "A-17")
+	e := NewKafkaEnvelope(err, "orders", 3, 1234, WithKey([]byte("A-17")), WithPayload([]byte(`{"id":"A-17"}`)),
+		WithHeaders(map[string][]string{"traceparent": {"00-abc-def-01"}}), WithConsumer("billing"), WithAttempts(5))
+	data, mErr := Marshal(e)
+	if mErr != nil {
+		t.Fatalf("Marshal() failed: %v", mErr)
+	}
+	if !bytes.Contains(data, []byte(`"version":1`)) || !bytes.Contains(data, []byte(`"broker":"kafka"`)) {
+		t.Errorf("unexpected envelope: %s", data)
+	}
+
+	decoded, uErr := Unmarshal(data)
+	if uErr != nil {
+		t.Fatalf("Unmarshal(%s) failed: %v", data, uErr)
+	}
+	if decoded.Topic != "orders" || decoded.Partition != 3 || decoded.Offset != 1234 || decoded.Consumer != "billing" ||
+		decoded.Attempts != 5 || string(decoded.Key) != "A-17" || string(decoded.Payload) != `{"id":"A-17"}` ||
+		decoded.Headers["traceparent"][0] != "00-abc-def-01" {
+		t.Errorf("unexpected decoded metadata: %+v", decoded)
+	}
+	if !decoded.FailedAt.Equal(e.FailedAt) {
+		t.Errorf("FailedAt = %v, want %v", decoded.FailedAt, e.FailedAt)
+	}
+	if decoded.Error == nil || decoded.Error.Code() != 1042 || decoded.Error.Error() != "invalid order" ||
+		decoded.Error.Attrs()["orderId"] != "A-17" {
+		t.Errorf("unexpected decoded error: %v", decoded.Error)
+	}
+}
+
+func TestNATSEnvelopeRoundTrip(t *testing.T) {
+	e := NewNATSEnvelope(errors.New("connection reset"), "orders.created", WithStream("ORDERS", 42))
+	if e.Error == nil || e.Error.Code() != 0 || e.Error.Error() != "connection reset" {
+		t.Fatalf("the standard error was not wrapped: %v", e.Error)
+	}
+	data, _ := Marshal(e)
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+	}
+	if decoded.Broker != BrokerNATS || decoded.Subject != "orders.created" || decoded.Stream != "ORDERS" ||
+		decoded.Sequence != 42 || decoded.Topic != "" {
+		t.Errorf("unexpected decoded metadata: %+v", decoded)
+	}
+	if decoded.Error == nil || decoded.Error.Error() != "connection reset" {
+		t.Errorf("unexpected decoded error: %v", decoded.Error)
+	}
+}
+
+func TestEnvelopeWithoutError(t *testing.T) {
+	data, err := Marshal(NewNATSEnvelope(nil, "orders.created"))
+	if err != nil || !bytes.Contains(data, []byte(`"error":null`)) {
+		t.Fatalf("Marshal() = %s, %v", data, err)
+	}
+	if decoded, err := Unmarshal(data); err != nil || decoded.Error != nil {
+		t.Errorf("Unmarshal() = %+v, %v, want an envelope without error", decoded, err)
+	}
+}
+
+func TestUnmarshalRejectsInvalidEnvelopes(t *testing.T) {
+	tests := map[string]string{
+		"newer version":  `{"broker":"kafka","version":2,"error":null}`,
+		"no version":     `{"broker":"kafka","error":null}`,
+		"missing broker": `{"version":1,"error":null}`,
+		"invalid error":  `{"broker":"kafka","version":1,"error":{"code":"x"}}`,
+		"invalid json":   `{"broker":`,
+	}
+	for name, data := range tests {
+		if _, err := Unmarshal([]byte(data)); err == nil {
+			t.Errorf("%s: Unmarshal(%s) succeeded", name, data)
+		} else if name != "invalid json" && !strings.Contains(err.Error(), "invalid dead-letter envelope") {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package xerrors
+
+import (
+	"strings"
+)
+
+// CollapseDuplicateWraps controls whether messages which repeat the start of the message they wrap are dropped from
+// composed messages, eg: "failed to do X: failed to do X: open foo" becomes "failed to do X: open foo".
+//
+// A message is dropped by [Error.FullMessage] (and by Error() when messages are composed) if the next message in the
+// chain is equal to it or starts with it followed by a colon, which happens when the same failure is wrapped at
+// several layers with the same message.  The messages of the individual errors are not changed, so they are still
+// marshaled as given.  This function enables or disables collapsing globally for this package.  This call is
+// thread-safe.
+func CollapseDuplicateWraps(enable bool) {
+	UpdateConfig(func(c *Config) {
+		c.CollapseDuplicateWraps = enable
+	})
+}
+
+// DetectDuplicateWraps enables a debug mode which calls the given handler for each new error whose message is equal
+// to the message of the error it wraps or starts it (followed by a colon), helping to find code which wraps errors
+// with the message they already have.
+//
+// The handler is called with the new error after the hooks (see [RegisterHook]) are called.  Passing nil disables
+// detection.  This call is thread-safe.
+func DetectDuplicateWraps(handler func(err Error)) {
+	UpdateConfig(func(c *Config) {
+		c.DuplicateWrapHandler = handler
+	})
+}
+
+// detectDuplicateWrap calls the given handler if the message of the given error duplicates the message of the error
+// it wraps.
+func detectDuplicateWrap(e *xerr, handler func(Error)) {
+	if e.wrappedErr == nil || e.message == "" {
+		return
+	}
+	wrapped := e.wrappedErr
+	var message string
+	if xerr, ok := wrapped.(*xerr); ok {
+		message = xerr.message
+	} else {
+		message = wrapped.Error()
+	}
+	if isDuplicateMessage(e.message, message) {
+		handler(e)
+	}
+}
+
+// collapseDuplicates removes the parts of a composed message which are repeated at the start of the next part.
+func collapseDuplicates(parts []string) []string {
+	collapsed := parts[:0]
+	for i, part := range parts {
+		if i+1 < len(parts) && isDuplicateMessage(part, parts[i+1]) {
+			continue
+		}
+		collapsed = append(collapsed, part)
+	}
+	return collapsed
+}
+
+// isDuplicateMessage returns true if the given message is equal to the wrapped message or starts it, followed by a
+// colon.
+func isDuplicateMessage(message, wrapped string) bool {
+	return message != "" && (wrapped == message || strings.HasPrefix(wrapped, message+":"))
+}
@@ -0,0 +1,113 @@
+package xerrors
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sync"
+)
+
+const (
+	// EncoderBinary is the name of the built-in encoder which encodes errors in the compact binary format (see
+	// [AppendBinary]).
+	EncoderBinary = "binary"
+
+	// EncoderJSON is the name of the built-in encoder which encodes errors as JSON (see [Error.MarshalJSON]).
+	EncoderJSON = "json"
+
+	// EncoderLogfmt is the name of the built-in encoder which encodes errors as logfmt (see [MarshalLogfmt]).
+	EncoderLogfmt = "logfmt"
+
+	// EncoderXML is the name of the built-in encoder which encodes errors as XML (see [Error.MarshalXML]).
+	EncoderXML = "xml"
+)
+
+var (
+	_encoders = map[string]Encoder{
+		EncoderBinary: NewEncoder("application/octet-stream", func(err Error) ([]byte, error) {
+			return AppendBinary(nil, err), nil
+		}),
+		EncoderJSON: NewEncoder("application/json", func(err Error) ([]byte, error) {
+			return err.MarshalJSON()
+		}),
+		EncoderLogfmt: NewEncoder("text/plain; charset=utf-8", func(err Error) ([]byte, error) {
+			return MarshalLogfmt(err), nil
+		}),
+		EncoderXML: NewEncoder("application/xml", func(err Error) ([]byte, error) {
+			return xml.Marshal(err)
+		}),
+	}
+	_encodersMutex sync.RWMutex
+)
+
+// Encoder encodes errors into a wire format, eg: Avro, Thrift or an internal format.
+//
+// Encoders are registered by name using [RegisterEncoder] and used with [Encode], a [Sink] created with
+// [NewEncoderSink] or the HTTP writers of the httpx package.
+type Encoder interface {
+	// ContentType should return the media type of the encoded errors, eg: "application/json".
+	ContentType() string
+
+	// Encode should return the encoding of the given error.
+	Encode(err Error) ([]byte, error)
+}
+
+// funcEncoder is an [Encoder] which calls a function to encode errors.
+type funcEncoder struct {
+	// unexported variables
+	contentType string                      // media type of the encoded errors
+	fn          func(Error) ([]byte, error) // function which encodes errors
+}
+
+// NewEncoder creates a new [Encoder] which encodes errors using the given function.
+func NewEncoder(contentType string, fn func(err Error) ([]byte, error)) Encoder {
+	return &funcEncoder{
+		contentType: contentType,
+		fn:          fn,
+	}
+}
+
+// ContentType returns the media type of the encoded errors.
+func (e *funcEncoder) ContentType() string {
+	return e.contentType
+}
+
+// Encode returns the encoding of the given error.
+func (e *funcEncoder) Encode(err Error) ([]byte, error) {
+	return e.fn(err)
+}
+
+// RegisterEncoder registers the encoder with the given name, replacing any encoder (including the built-in ones)
+// already registered with that name.  Passing a nil encoder removes the encoder.
+//
+// This call is thread-safe.
+func RegisterEncoder(name string, encoder Encoder) {
+	_encodersMutex.Lock()
+	if encoder == nil {
+		delete(_encoders, name)
+	} else {
+		_encoders[name] = encoder
+	}
+	_encodersMutex.Unlock()
+}
+
+// LookupEncoder returns the encoder registered with the given name.
+//
+// The second return value is false if no encoder has been registered with the name.  This call is thread-safe.
+func LookupEncoder(name string) (Encoder, bool) {
+	_encodersMutex.RLock()
+	defer _encodersMutex.RUnlock()
+	encoder, ok := _encoders[name]
+	return encoder, ok
+}
+
+// Encode encodes the error using the encoder registered with the given name, after applying the registered
+// transformers (see [Transform]).
+//
+// An error is returned if no encoder has been registered with the name.  This call is thread-safe.
+func Encode(err Error, name string) ([]byte, error) {
+	encoder, ok := LookupEncoder(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown encoder: %q", name)
+	}
+	return encoder.Encode(Transform(err))
+}
@@ -0,0 +1,118 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvCaptureCaller is the environment variable which sets [Config.CaptureCaller], eg: "true".
+	EnvCaptureCaller = "XERRORS_CAPTURE_CALLER"
+
+	// EnvCollapseDuplicateWraps is the environment variable which sets [Config.CollapseDuplicateWraps], eg: "true".
+	EnvCollapseDuplicateWraps = "XERRORS_COLLAPSE_DUPLICATE_WRAPS"
+
+	// EnvComposeMessages is the environment variable which sets [Config.ComposeMessages], eg: "true".
+	EnvComposeMessages = "XERRORS_COMPOSE_MESSAGES"
+
+	// EnvIDGenerator is the environment variable which sets [Config.IDGenerator] to either "uuid" ([UUID]), "ulid"
+	// ([ULID]) or "none".
+	EnvIDGenerator = "XERRORS_ID_GENERATOR"
+
+	// EnvMaxChainDepth is the environment variable which sets [Config.MaxChainDepth], eg: "50".
+	EnvMaxChainDepth = "XERRORS_MAX_CHAIN_DEPTH"
+
+	// EnvPanicOnViolation is the environment variable which sets [Config.PanicOnViolation], eg: "true".
+	EnvPanicOnViolation = "XERRORS_PANIC_ON_VIOLATION"
+
+	// EnvStackDepth is the environment variable which sets [Config.StackDepth], eg: "32".
+	EnvStackDepth = "XERRORS_STACK_DEPTH"
+
+	// EnvStripPrefixes is the environment variable which sets [Config.CallerFilePrefixes] to a comma-separated list
+	// of prefixes, eg: "/build/src/,/go/pkg/mod/".
+	EnvStripPrefixes = "XERRORS_STRIP_PREFIXES"
+
+	// EnvSymbolicCodes is the environment variable which sets [Config.SymbolicCodes], eg: "true".
+	EnvSymbolicCodes = "XERRORS_SYMBOLIC_CODES"
+
+	// EnvTraceWrapSites is the environment variable which sets [Config.TraceWrapSites], eg: "true".
+	EnvTraceWrapSites = "XERRORS_TRACE_WRAP_SITES"
+)
+
+// ConfigureFromEnv updates the global settings from the environment variables which are set (see [EnvCaptureCaller]
+// and the other Env constants), so that operators can change how much detail errors capture in production without
+// changing the code which configures this package.  Settings whose variables are not set are left unchanged.
+//
+// Boolean variables accept the values accepted by [strconv.ParseBool].  If any of the variables has an invalid value,
+// an error describing every invalid variable is returned and no setting is changed; otherwise all of the settings are
+// changed at once (see [UpdateConfig]).  This function is typically called at the start of main.
+func ConfigureFromEnv() error {
+	var updates []func(*Config)
+	var errs []error
+	parseBool := func(name string, set func(*Config, bool)) {
+		if value, ok := os.LookupEnv(name); ok {
+			b, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q is not a boolean", name, value))
+				return
+			}
+			updates = append(updates, func(c *Config) { set(c, b) })
+		}
+	}
+	parseInt := func(name string, set func(*Config, int)) {
+		if value, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				errs = append(errs, fmt.Errorf("invalid %s: %q is not a non-negative integer", name, value))
+				return
+			}
+			updates = append(updates, func(c *Config) { set(c, n) })
+		}
+	}
+
+	parseBool(EnvCaptureCaller, func(c *Config, b bool) { c.CaptureCaller = b })
+	parseBool(EnvCollapseDuplicateWraps, func(c *Config, b bool) { c.CollapseDuplicateWraps = b })
+	parseBool(EnvComposeMessages, func(c *Config, b bool) { c.ComposeMessages = b })
+	parseBool(EnvPanicOnViolation, func(c *Config, b bool) { c.PanicOnViolation = b })
+	parseBool(EnvSymbolicCodes, func(c *Config, b bool) { c.SymbolicCodes = b })
+	parseBool(EnvTraceWrapSites, func(c *Config, b bool) { c.TraceWrapSites = b })
+	parseInt(EnvMaxChainDepth, func(c *Config, n int) { c.MaxChainDepth = n })
+	parseInt(EnvStackDepth, func(c *Config, n int) { c.StackDepth = n })
+	if value, ok := os.LookupEnv(EnvIDGenerator); ok {
+		var gen IDGenerator
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "uuid":
+			gen = UUID
+		case "ulid":
+			gen = ULID
+		case "", "none":
+		default:
+			errs = append(errs, fmt.Errorf("invalid %s: %q is not one of uuid, ulid or none", EnvIDGenerator, value))
+		}
+		updates = append(updates, func(c *Config) { c.IDGenerator = gen })
+	}
+	if value, ok := os.LookupEnv(EnvStripPrefixes); ok {
+		var prefixes []string
+		for _, prefix := range strings.Split(value, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+		updates = append(updates, func(c *Config) { c.CallerFilePrefixes = prefixes })
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(updates) > 0 {
+		UpdateConfig(func(c *Config) {
+			for _, update := range updates {
+				update(c)
+			}
+		})
+	}
+	return nil
+}
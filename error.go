@@ -2,15 +2,24 @@ package xerrors
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Error is the interface implemented by extended errors.
 type Error interface {
 	error
 	json.Marshaler
+	xml.Marshaler
+
+	// AppendJSON should append the JSON encoding of the error to dst and return the extended buffer.
+	AppendJSON(dst []byte) ([]byte, error)
 
 	// Attrs should return a map of attributes associated with the error.
 	Attrs() map[string]any
@@ -18,51 +27,210 @@ type Error interface {
 	// Caller should return the information on where the error was generated.
 	Caller() CallerInfo
 
+	// Classifications should return the classification of each attribute which was added using WithClassifiedAttr.
+	Classifications() map[string]Classification
+
 	// Code should return the error code.
 	Code() int
 
+	// Domain should return the domain (eg: the service or component) the error belongs to, if any.
+	Domain() string
+
+	// ExpiresAt should return the time after which the error should no longer be used or the zero time if no TTL
+	// was set.
+	ExpiresAt() time.Time
+
+	// FullMessage should return the error message followed by the messages of all of the wrapped errors, separated
+	// by ": ".
+	FullMessage() string
+
+	// Hints should return the suggested next steps for resolving the failure, if any, in the order they were added.
+	Hints() []string
+
+	// ID should return the unique ID of the error or an empty string if no ID was generated.
+	ID() string
+
 	// Is should return true if the wrapped error inside the object matches the given error, false otherwise.
 	Is(error) bool
 
+	// Kind should return the broad category of the failure or an empty string if it has not been set.
+	Kind() Kind
+
+	// Message should return the message of the error itself, without the messages of the errors it wraps, even if
+	// they are included in the message returned by Error.
+	Message() string
+
+	// Op should return the name of the operation which failed (eg: "svc.user.Create") or an empty string if it has
+	// not been set.
+	Op() string
+
+	// Position should return the location in the input which the error refers to, eg: the line and column of a
+	// parse error, or the zero position if it has not been set.
+	Position() Position
+
+	// RetryAfter should return how long the caller should wait before retrying the operation which failed or 0 if
+	// no delay was given.
+	RetryAfter() time.Duration
+
+	// Retryable should return true if the operation which failed can be retried.
+	Retryable() bool
+
+	// SafeToRetry should return true if the operation which failed has been marked as safe to retry, eg: because it
+	// is idempotent.
+	SafeToRetry() bool
+
+	// Severity should return how serious the failure is or [SeverityUnknown] if it has not been set.
+	Severity() Severity
+
+	// StackTrace should return the stack frames captured when the error was generated, if any.
+	StackTrace() []CallerInfo
+
 	// String should return a string representation of the error.
 	//
 	// Unlike the Error() method, this function may include additional information such as the caller details or
 	// attributes in any format (eg: plaintext or JSON).
 	String() string
 
+	// Unwrap should return the wrapped error, if any.
+	Unwrap() error
+
 	// WithAttr should add an attribute to the error and return itself.
 	WithAttr(key string, value any) Error
 
 	// WithAttrs should add attributes to the error and return itself.
 	WithAttrs(attrs map[string]any) Error
+
+	// WithCause should add a secondary cause of the failure, which is not part of the error chain, as an attribute
+	// with the given name and return itself.
+	WithCause(name string, err error) Error
+
+	// WithClassifiedAttr should add an attribute labeled with the given classification to the error and return
+	// itself.
+	WithClassifiedAttr(key string, value any, class Classification) Error
+
+	// WithFile should set the name of the input which the position of the error refers to and return itself.
+	WithFile(name string) Error
+
+	// WithGroup should add the attributes which are subsequently added to the error to the group with the given name,
+	// nested inside the current group, and return itself.  An empty name should return to the top level.
+	WithGroup(name string) Error
+
+	// WithHint should add a suggested next step for resolving the failure (eg: "run 'app init' first") and return
+	// itself.
+	WithHint(hint string) Error
+
+	// WithID should set the unique ID of the error and return itself.
+	//
+	// This is intended for reconstructing errors received from another process.
+	WithID(id string) Error
+
+	// WithKind should set the broad category of the failure and return itself.
+	WithKind(kind Kind) Error
+
+	// WithOffset should set the byte offset in the input which the error refers to and return itself.
+	WithOffset(offset int64) Error
+
+	// WithOp should set the name of the operation which failed and return itself.
+	WithOp(op string) Error
+
+	// WithPosition should set the line and column in the input which the error refers to and return itself.
+	WithPosition(line, col int) Error
+
+	// WithRetryable should set whether or not the operation which failed can be retried and return itself.
+	WithRetryable(retryable bool) Error
+
+	// WithRetryAfter should set how long the caller should wait before retrying and return itself.
+	WithRetryAfter(d time.Duration) Error
+
+	// WithSafeToRetry should set whether or not the operation which failed is safe to retry (ie: idempotent) and
+	// return itself.
+	WithSafeToRetry(idempotent bool) Error
+
+	// WithSeverity should set how serious the failure is and return itself.
+	WithSeverity(severity Severity) Error
+
+	// WithTTL should set how long the error remains valid, eg: when it is stored in a negative cache, and return
+	// itself.
+	WithTTL(d time.Duration) Error
 }
 
 // xerr is a struct that implements the [Error] interface.
+//
+// Only the fields which are set for most errors are stored directly, so that creating an error with just a code and
+// a message allocates as little as possible.  The other fields are stored in an extension which is shared until one
+// of them is modified (see mutable).
 type xerr struct {
-	// unexported variables
-	attrs      map[string]any // error attributes
-	caller     *CallerInfo    // information on where the error was generated
-	code       int            // the error code
-	message    string         // the error message
-	wrappedErr error          // the wrapped error, if any
-}
+	*xerrExt // fields which are only set for some errors, shared until modified
 
-// jsonXErr is a version of [xerr] that is used to marshal the object to JSON.
-type jsonXErr struct {
-	// Attrs is a map of attributes associated with the error.
-	Attrs map[string]any `json:"attrs,omitempty"`
+	// unexported variables (the flags are kept together so the struct fits in a 64-byte allocation)
+	attrs       map[string]any // error attributes
+	code        int            // the error code
+	message     string         // the error message
+	wrappedErr  error          // the wrapped error, if any
+	compose     bool           // whether or not Error() includes the wrapped error's message
+	transformed bool           // whether or not the error is the result of Transform
+	inspected   atomic.Bool    // whether or not the error has been inspected (see DetectSwallowedErrors)
+}
 
-	// Caller contains the information on where the error was generated.
-	Caller *CallerInfo `json:"caller,omitempty"`
+// xerrExt holds the fields of an [xerr] which are only set for some errors.
+//
+// An extension may be shared between errors, eg: the one holding the settings of a factory, in which case it must be
+// copied before it is modified (see mutable).
+type xerrExt struct {
+	// unexported variables
+	caller     *CallerInfo               // information on where the error was generated
+	classes    map[string]Classification // classification of each classified attribute
+	domain     string                    // the domain the error belongs to
+	elapsed    time.Duration             // time elapsed since the start of a done context or 0 if not known
+	expires    time.Time                 // time after which the error should no longer be used or zero for no TTL
+	formatter  StringFormatter           // formatter used by String() or nil to use the global setting
+	group      []string                  // path of the group which attributes are added to
+	hints      []string                  // suggested next steps for resolving the failure
+	id         string                    // the unique ID of the error
+	kind       Kind                      // the broad category of the failure
+	op         string                    // the name of the operation which failed
+	payloads   []any                     // typed payloads attached using WithPayload
+	position   *Position                 // location in the input the error refers to or nil if not set
+	profile    *MarshalProfile           // profile used when marshaling the error
+	retryAfter time.Duration             // how long to wait before retrying
+	retryable  *bool                     // whether or not the operation can be retried or nil if unknown
+	safe       *bool                     // whether or not the operation is safe to retry or nil if unknown
+	sentinel   *Registry                 // registry which a sentinel error belongs to or nil for other errors
+	severity   Severity                  // how serious the failure is
+	shared     bool                      // whether or not the extension is shared and must be copied to be modified
+	site       *CallerInfo               // where the error was created when only wrap sites are traced
+	stack      []CallerInfo              // stack frames captured when the error was generated
+}
 
-	// Code is the error code.
-	Code int `json:"code"`
+var (
+	// _noExt is the shared extension of errors which do not have any of its fields set.
+	_noExt = &xerrExt{shared: true}
+)
 
-	// Message is the error message.
-	Message string `json:"message"`
+// mutable returns the extension of the error, replacing it with a copy first if it is shared, so that its fields can
+// be modified.
+func (e *xerr) mutable() *xerrExt {
+	if e.xerrExt.shared {
+		e.xerrExt = e.xerrExt.copy()
+	}
+	return e.xerrExt
+}
 
-	// WrappedError is the wrapped error, if any.
-	WrappedError error `json:"wrappedError,omitempty"`
+// copy returns an unshared copy of the extension whose classifications, position and slices can be modified
+// independently of the original.
+func (x *xerrExt) copy() *xerrExt {
+	c := *x
+	c.classes = maps.Clone(x.classes)
+	c.group = slices.Clip(x.group)
+	c.hints = slices.Clip(x.hints)
+	c.payloads = slices.Clip(x.payloads)
+	c.shared = false
+	c.stack = slices.Clip(x.stack)
+	if x.position != nil {
+		position := *x.position
+		c.position = &position
+	}
+	return &c
 }
 
 // jsonStdErr is a version of a standard Go error that is used to marshal the object to JSON.
@@ -77,52 +245,22 @@ func (e *jsonStdError) Error() string {
 
 // New creates a new [Error] with the given code and message.
 func New(code int, message string) Error {
-	xerr := &xerr{
-		code:    code,
-		message: message,
-	}
-	if _captureCaller {
-		xerr.caller = GetCallerInfo(0)
-	}
-	return xerr
+	return newError(nil, nil, 0, code, message, nil)
 }
 
 // Newf creates a new [Error] with the given code and formatted message.
 func Newf(code int, format string, args ...any) Error {
-	xerr := &xerr{
-		code:    code,
-		message: fmt.Sprintf(format, args...),
-	}
-	if _captureCaller {
-		xerr.caller = GetCallerInfo(0)
-	}
-	return xerr
+	return newError(nil, nil, 0, code, fmt.Sprintf(format, args...), nil)
 }
 
 // Wrap wraps the given error in a new [Error] with the given code and message.
 func Wrap(code int, err error, message string) Error {
-	xerr := &xerr{
-		code:       code,
-		message:    message,
-		wrappedErr: err,
-	}
-	if _captureCaller {
-		xerr.caller = GetCallerInfo(0)
-	}
-	return xerr
+	return newError(nil, nil, 0, code, message, err)
 }
 
 // Wrapf wraps the given error in a new [Error] with the given code and formatted message.
 func Wrapf(code int, err error, format string, args ...any) Error {
-	xerr := &xerr{
-		code:       code,
-		message:    fmt.Sprintf(format, args...),
-		wrappedErr: err,
-	}
-	if _captureCaller {
-		xerr.caller = GetCallerInfo(0)
-	}
-	return xerr
+	return newError(nil, nil, 0, code, fmt.Sprintf(format, args...), err)
 }
 
 // Attrs returns a map of attributes associated with the error.
@@ -139,47 +277,185 @@ func (e *xerr) Caller() CallerInfo {
 	return *e.caller
 }
 
+// Classifications returns the classification of each attribute which was added using WithClassifiedAttr.
+func (e *xerr) Classifications() map[string]Classification {
+	return e.classes
+}
+
 // Code returns the error code.
 func (e *xerr) Code() int {
+	e.markInspected()
 	return e.code
 }
 
+// Domain returns the domain the error belongs to, if any.
+func (e *xerr) Domain() string {
+	return e.domain
+}
+
 // Error returns the error message.
+//
+// If message composition was enabled when the error was created (see [ComposeMessages]), the message is followed by
+// the messages of all of the wrapped errors in the same way as FullMessage().
 func (e *xerr) Error() string {
+	e.markInspected()
+	if e.compose {
+		return e.FullMessage()
+	}
 	return e.message
 }
 
+// FullMessage returns the error message followed by the messages of all of the wrapped errors, separated by ": ".
+//
+// Wrapped errors which were not created by this package are expected to include the messages of the errors they
+// wrap in their own message (as [fmt.Errorf] does); if such an error has the same message as the error wrapping it,
+// the message is only included once.  Messages which repeat the start of the next message are dropped while
+// [CollapseDuplicateWraps] is enabled.  Chains which are too deep or contain a cycle end with the [TruncationMarker].
+func (e *xerr) FullMessage() string {
+	e.markInspected()
+	var parts []string
+	truncated := walkChain(e, func(err error) bool {
+		switch err := err.(type) {
+		case *xerr:
+			if err.message != "" {
+				parts = append(parts, err.message)
+			}
+			return true
+		case Error:
+			parts = append(parts, err.FullMessage())
+		default:
+			// wrappers which repeat the message of the error they wrap, eg: Wrap(code, err, err.Error()), only
+			// render it once
+			if message := err.Error(); len(parts) == 0 || parts[len(parts)-1] != message {
+				parts = append(parts, message)
+			}
+		}
+		return false
+	})
+	parts = slices.DeleteFunc(parts, func(p string) bool { return p == "" })
+	if loadConfig().CollapseDuplicateWraps {
+		parts = collapseDuplicates(parts)
+	}
+	if truncated {
+		parts = append(parts, TruncationMarker)
+	}
+	return strings.Join(parts, ": ")
+}
+
+// Hints returns the suggested next steps for resolving the failure, if any, in the order they were added.
+func (e *xerr) Hints() []string {
+	return e.hints
+}
+
+// ID returns the unique ID of the error or an empty string if no ID was generated.
+func (e *xerr) ID() string {
+	return e.id
+}
+
 // Is returns true if the error matches the wrapped error in this object (if there is one) or false otherwise.
+//
+// The error also matches the sentinel of its code returned by [Registry.Sentinel], so sentinel checks keep working
+// after the error has been reconstructed in another process, eg: by [ParseJSON], and the [Matcher] of its code or
+// kind.
 func (e *xerr) Is(err error) bool {
+	e.markInspected()
+	if m, ok := err.(*Matcher); ok && m.matches(e) {
+		return true
+	}
+	if target, ok := err.(*xerr); ok && target.sentinel != nil && target.matchesSentinel(e) {
+		return true
+	}
 	if e.wrappedErr == nil {
 		return false
 	}
 	return errors.Is(err, e.wrappedErr)
 }
 
-// MarshalJSON marshals the error to JSON.
+// Kind returns the broad category of the failure or an empty string if it has not been set.
+func (e *xerr) Kind() Kind {
+	return e.kind
+}
+
+// MarshalJSON marshals the error to JSON using the marshal profile of the factory which created it.
+//
+// Attribute values which cannot be marshaled (eg: channels or functions) are replaced with a placeholder string
+// containing their type and value, so the error document is always produced.
 func (e *xerr) MarshalJSON() ([]byte, error) {
-	jsonError := jsonXErr{
-		Caller:  e.caller,
-		Code:    e.code,
-		Message: e.message,
-	}
-	if e.wrappedErr != nil {
-		if _, ok := e.wrappedErr.(Error); !ok {
-			jsonError.WrappedError = &jsonStdError{
-				Message: e.wrappedErr.Error(),
-			}
-		}
+	return e.marshalJSON(e.profile.resolve())
+}
+
+// marshalJSON marshals the error to JSON using the given resolved profile.
+func (e *xerr) marshalJSON(profile *MarshalProfile) ([]byte, error) {
+	data, err := e.appendJSON(nil, profile)
+	if err != nil || profile.CompressAbove <= 0 {
+		return data, err
 	}
-	if e.attrs != nil {
-		jsonError.Attrs = make(map[string]any)
-		maps.Copy(jsonError.Attrs, e.attrs)
+	return CompressJSON(data, profile.CompressAbove, profile.Compression)
+}
+
+// ExpiresAt returns the time after which the error should no longer be used or the zero time if no TTL was set.
+func (e *xerr) ExpiresAt() time.Time {
+	return e.expires
+}
+
+// Message returns the message of the error itself, without the messages of the errors it wraps, even if messages are
+// composed (see [ComposeMessages]).
+func (e *xerr) Message() string {
+	e.markInspected()
+	return e.message
+}
+
+// Op returns the name of the operation which failed or an empty string if it has not been set.
+func (e *xerr) Op() string {
+	return e.op
+}
+
+// Position returns the location in the input which the error refers to or the zero position if it has not been set.
+func (e *xerr) Position() Position {
+	if e.position == nil {
+		return Position{}
 	}
-	return json.Marshal(jsonError)
+	return *e.position
+}
+
+// RetryAfter returns how long the caller should wait before retrying the operation which failed or 0 if no delay
+// was given.
+func (e *xerr) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// Retryable returns true if the operation which failed can be retried.
+func (e *xerr) Retryable() bool {
+	return e.retryable != nil && *e.retryable
+}
+
+// SafeToRetry returns true if the operation which failed has been marked as safe to retry.
+func (e *xerr) SafeToRetry() bool {
+	return e.safe != nil && *e.safe
+}
+
+// Severity returns how serious the failure is or [SeverityUnknown] if it has not been set.
+func (e *xerr) Severity() Severity {
+	return e.severity
+}
+
+// StackTrace returns the stack frames captured when the error was generated, if any.
+func (e *xerr) StackTrace() []CallerInfo {
+	return e.stack
 }
 
 // String returns the error (including the code, attributes, caller and wrapped error) represented as a JSON string.
+//
+// If a formatter has been set using [WithStringFormatter] or [SetStringFormatter], the string is rendered by the
+// formatter instead.
 func (e *xerr) String() string {
+	formatter := e.formatter
+	if formatter == nil {
+		formatter = loadConfig().StringFormatter
+	}
+	if formatter != nil {
+		return formatter(e)
+	}
 	str, err := e.MarshalJSON()
 	if err != nil {
 		return fmt.Sprintf("failed to marshal error to JSON: %s", err.Error())
@@ -187,20 +463,155 @@ func (e *xerr) String() string {
 	return string(str)
 }
 
-// WithAttr adds an attribute to the error and returns itself.
+// Unwrap returns the wrapped error, if any.
+func (e *xerr) Unwrap() error {
+	e.markInspected()
+	return e.wrappedErr
+}
+
+// WithAttr adds an attribute to the error (in the current group, if any) and returns itself.
 func (e *xerr) WithAttr(key string, value any) Error {
-	if e.attrs == nil {
-		e.attrs = make(map[string]any)
-	}
-	e.attrs[key] = value
+	e.attrTarget()[normalizeKey(key)] = value
 	return e
 }
 
-// WithAttrs adds attributes to the error and returns itself.
+// WithAttrs adds attributes to the error (in the current group, if any) and returns itself.
 func (e *xerr) WithAttrs(attrs map[string]any) Error {
-	if e.attrs == nil {
-		e.attrs = make(map[string]any)
+	target := e.attrTarget()
+	for k, v := range attrs {
+		target[normalizeKey(k)] = v
+	}
+	return e
+}
+
+// WithCause adds a secondary cause of the failure as an attribute with the given name (in the current group, if any)
+// and returns itself, eg: when several independent failures occurred but only one of them is the wrapped error.
+//
+// The cause is stored as the attribute value and is marshaled as a nested error object.  [HasCode] also searches the
+// causes.  Nil errors and the error itself are ignored.
+func (e *xerr) WithCause(name string, err error) Error {
+	if err == nil || err == error(e) {
+		return e
+	}
+	markWrappedInspected(err)
+	return e.WithAttr(name, err)
+}
+
+// WithClassifiedAttr adds an attribute labeled with the given classification to the error (in the current group, if
+// any) and returns itself.
+//
+// The classifications of attributes in groups are keyed by their dotted path, eg: "db.query".
+func (e *xerr) WithClassifiedAttr(key string, value any, class Classification) Error {
+	key = normalizeKey(key)
+	e.WithAttr(key, value)
+	ext := e.mutable()
+	if ext.classes == nil {
+		ext.classes = make(map[string]Classification)
+	}
+	ext.classes[e.attrPath(key)] = class
+	return e
+}
+
+// WithFile sets the name of the input which the position of the error refers to and returns itself.
+func (e *xerr) WithFile(name string) Error {
+	e.positionTarget().File = name
+	return e
+}
+
+// WithGroup adds the attributes which are subsequently added to the error to the group with the given name, nested
+// inside the current group, and returns itself.
+//
+// For example, err.WithGroup("db").WithAttr("query", q) renders as {"db":{"query":"..."}} in the error attributes.
+// An empty name returns to the top level.
+func (e *xerr) WithGroup(name string) Error {
+	if name == "" {
+		e.mutable().group = nil
+	} else {
+		ext := e.mutable()
+		ext.group = append(ext.group, normalizeKey(name))
+	}
+	return e
+}
+
+// WithHint adds a suggested next step for resolving the failure and returns itself, eg: "run 'app init' first".
+//
+// Hints are kept separate from the message, so that CLIs and APIs can present them as actionable advice.  Empty
+// hints are ignored.
+func (e *xerr) WithHint(hint string) Error {
+	if hint != "" {
+		ext := e.mutable()
+		ext.hints = append(ext.hints, hint)
 	}
-	maps.Copy(e.attrs, attrs)
+	return e
+}
+
+// WithID sets the unique ID of the error and returns itself.
+func (e *xerr) WithID(id string) Error {
+	e.mutable().id = id
+	return e
+}
+
+// WithKind sets the broad category of the failure and returns itself.
+func (e *xerr) WithKind(kind Kind) Error {
+	e.mutable().kind = kind
+	return e
+}
+
+// WithOffset sets the byte offset in the input which the error refers to and returns itself, eg: when a stream
+// processor fails on a corrupt record.
+func (e *xerr) WithOffset(offset int64) Error {
+	e.positionTarget().Offset = max(offset, 0)
+	return e
+}
+
+// WithOp sets the name of the operation which failed and returns itself.
+func (e *xerr) WithOp(op string) Error {
+	e.mutable().op = op
+	return e
+}
+
+// WithPosition sets the line and column in the input which the error refers to and returns itself, eg: when a parser
+// fails on invalid syntax.  A column of 0 means the column is not known.
+//
+// Together with the name set by WithFile, the position is rendered as "config.yaml:12:3" (see [Position.String]).
+func (e *xerr) WithPosition(line, col int) Error {
+	position := e.positionTarget()
+	position.Line = max(line, 0)
+	position.Column = max(col, 0)
+	return e
+}
+
+// WithRetryable sets whether or not the operation which failed can be retried and returns itself.
+func (e *xerr) WithRetryable(retryable bool) Error {
+	e.mutable().retryable = &retryable
+	return e
+}
+
+// WithRetryAfter sets how long the caller should wait before retrying and returns itself.
+func (e *xerr) WithRetryAfter(d time.Duration) Error {
+	e.mutable().retryAfter = max(d, 0)
+	return e
+}
+
+// WithSafeToRetry sets whether or not the operation which failed is safe to retry (ie: idempotent) and returns
+// itself.
+//
+// This is distinct from whether the failure is retryable: eg: a timeout is retryable, but retrying a POST request
+// which timed out may apply it twice unless the operation is idempotent.  See [ShouldRetry] for details.
+func (e *xerr) WithSafeToRetry(idempotent bool) Error {
+	e.mutable().safe = &idempotent
+	return e
+}
+
+// WithSeverity sets how serious the failure is and returns itself.
+func (e *xerr) WithSeverity(severity Severity) Error {
+	e.mutable().severity = severity
+	return e
+}
+
+// WithTTL sets how long (from now) the error remains valid, eg: when it is stored in a negative cache, and returns
+// itself.  See [Expired] for details.
+func (e *xerr) WithTTL(d time.Duration) Error {
+	e.mutable().expires = time.Now().Add(max(d, 0))
 	return e
 }
@@ -0,0 +1,169 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_benchErr   error
+	_benchCause = errors.New("connection refused")
+)
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = New(1042, "quota exceeded")
+	}
+}
+
+func BenchmarkNewf(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = Newf(1042, "quota exceeded for %s", "user")
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = Wrap(1042, _benchCause, "failed to connect")
+	}
+}
+
+func BenchmarkWrapError(b *testing.B) {
+	cause := New(1041, "connection refused")
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = Wrap(1042, cause, "failed to connect")
+	}
+}
+
+func BenchmarkStdlibNew(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = errors.New("quota exceeded")
+	}
+}
+
+func BenchmarkStdlibErrorf(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = fmt.Errorf("failed to connect: %w", _benchCause)
+	}
+}
+
+// stackTracer mimics the errors created by github.com/pkg/errors, whose stack traces are detected using reflection.
+type stackTracer struct {
+	pcs []uintptr
+}
+
+func (e stackTracer) Error() string {
+	return "stack tracer"
+}
+
+func (e stackTracer) StackTrace() []uintptr {
+	return e.pcs
+}
+
+func TestSharedExtensionIsNotModified(t *testing.T) {
+	f := NewFactory(WithDomain("billing"))
+	mutate := func(e Error) {
+		e.WithClassifiedAttr("card", "4111", ClassificationPII).WithGroup("db").WithHint("retry later").
+			WithID("id").WithKind(KindCanceled).WithOp("op").WithPosition(1, 2).WithRetryable(true).
+			WithRetryAfter(time.Second).WithSafeToRetry(true).WithSeverity(SeverityCritical).WithTTL(time.Minute)
+	}
+	pcs := make([]uintptr, 8)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	mutate(New(1, "plain"))
+	mutate(f.New(1, "factory"))
+	mutate(Wrap(1, stackTracer{pcs: pcs}, "foreign"))
+	_ = WithPayload(New(1, "payload"), "payload")
+
+	if !reflect.DeepEqual(*_noExt, xerrExt{shared: true}) {
+		t.Errorf("shared extension of plain errors was modified: %+v", *_noExt)
+	}
+	if e := f.New(2, "untouched").(*xerr); e.domain != "billing" || e.id != "" || e.kind != "" || len(e.hints) != 0 ||
+		e.position != nil || len(e.classes) != 0 {
+		t.Errorf("shared extension of the factory was modified: %+v", *e.xerrExt)
+	}
+	if e := Wrap(2, stackTracer{pcs: pcs}, "foreign").(*xerr); len(e.stack) == 0 {
+		t.Error("stack trace of the wrapped error was not captured")
+	}
+}
+
+func TestCloneDoesNotShareModifications(t *testing.T) {
+	orig := New(1, "original").WithHint("first").(*xerr)
+	clone := orig.clone()
+	clone.WithHint("second").WithPosition(3, 4)
+	if len(orig.hints) != 1 || orig.position != nil {
+		t.Errorf("modifying the clone modified the original: %+v", *orig.xerrExt)
+	}
+}
+
+// failingMarshaler is an attribute value whose MarshalJSON method always fails.
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("cannot marshal")
+}
+
+func TestStringMatchesMarshalJSON(t *testing.T) {
+	cycle := map[string]any{}
+	cycle["self"] = cycle
+	tests := map[string]Error{
+		"plain":      New(1, "plain"),
+		"attributes": New(1, "attributes").WithAttr("user", "alice").WithGroup("db").WithAttr("rows", 3),
+		"wrapped":    Wrap(1, errors.New("cause"), "wrapped").WithHint("retry later"),
+		"channel":    New(1, "channel").WithAttr("ch", make(chan int)).WithAttr("user", "alice"),
+		"function":   New(1, "function").WithAttr("fn", func() {}).WithAttr("user", "alice"),
+		"nan":        New(1, "nan").WithAttr("ratio", math.NaN()).WithAttr("user", "alice"),
+		"cycle":      New(1, "cycle").WithAttr("cycle", cycle).WithAttr("user", "alice"),
+		"marshaler":  New(1, "marshaler").WithAttr("value", failingMarshaler{}).WithAttr("user", "alice"),
+		"group":      New(1, "group").WithGroup("db").WithAttr("ch", make(chan int)).WithAttr("user", "alice"),
+	}
+	for name, err := range tests {
+		data, mErr := err.MarshalJSON()
+		if mErr != nil {
+			t.Errorf("%s: failed to marshal: %v", name, mErr)
+			continue
+		}
+		str := err.String()
+		if str != string(data) {
+			t.Errorf("%s: String() = %s, MarshalJSON = %s", name, str, data)
+		}
+		if !json.Valid([]byte(str)) {
+			t.Errorf("%s: String() is not valid JSON: %s", name, str)
+		}
+		if name != "plain" && name != "wrapped" && !strings.Contains(str, `"user":"alice"`) {
+			t.Errorf("%s: String() dropped the marshalable attributes: %s", name, str)
+		}
+	}
+}
+
+func TestStringReplacesUnmarshalableValues(t *testing.T) {
+	cycle := map[string]any{}
+	cycle["self"] = cycle
+	tests := map[string]struct {
+		value any
+		want  string
+	}{
+		"channel":   {value: make(chan int), want: `"!UNMARSHALABLE(chan int): 0x`},
+		"nan":       {value: math.NaN(), want: `"!UNMARSHALABLE(float64): NaN"`},
+		"cycle":     {value: cycle, want: `{"self":"!UNMARSHALABLE(map[string]interface {})"}`},
+		"marshaler": {value: failingMarshaler{}, want: `"!UNMARSHALABLE(xerrors.failingMarshaler): {}"`},
+	}
+	for name, test := range tests {
+		str := New(1, name).WithAttr("value", test.value).String()
+		if !strings.Contains(str, `"value":`+test.want) {
+			t.Errorf("%s: expected placeholder %s in %s", name, test.want, str)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package xerrors
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	// _eventSchema is the namespace of Windows Event Log event documents.
+	_eventSchema = "http://schemas.microsoft.com/win/2004/08/events/event"
+)
+
+// EventLevel is the level of an event in the Windows Event Log.
+type EventLevel int
+
+const (
+	// EventLevelCritical is the level of critical events.
+	EventLevelCritical EventLevel = 1
+
+	// EventLevelError is the level of error events.
+	EventLevelError EventLevel = 2
+
+	// EventLevelWarning is the level of warning events.
+	EventLevelWarning EventLevel = 3
+
+	// EventLevelInformation is the level of informational events.
+	EventLevelInformation EventLevel = 4
+
+	// EventLevelVerbose is the level of verbose events.
+	EventLevelVerbose EventLevel = 5
+)
+
+// EventLevel returns the Windows Event Log level of the severity.
+//
+// Unknown severities are mapped to [EventLevelError].
+func (s Severity) EventLevel() EventLevel {
+	switch s {
+	case SeverityDebug:
+		return EventLevelVerbose
+	case SeverityInfo:
+		return EventLevelInformation
+	case SeverityWarning:
+		return EventLevelWarning
+	case SeverityCritical:
+		return EventLevelCritical
+	}
+	return EventLevelError
+}
+
+// EventType returns the event type passed to the ReportEvent API for the level, ie: EVENTLOG_ERROR_TYPE (1),
+// EVENTLOG_WARNING_TYPE (2) or EVENTLOG_INFORMATION_TYPE (4).
+func (l EventLevel) EventType() uint16 {
+	switch {
+	case l <= EventLevelError:
+		return 1
+	case l == EventLevelWarning:
+		return 2
+	}
+	return 4
+}
+
+// EventID returns the Windows Event Log event ID for the code of the given error.
+//
+// Event IDs are 16-bit values, so codes outside the range from 0 to 65535 are mapped to 0.
+func EventID(err Error) uint16 {
+	if code := err.Code(); code >= 0 && code <= 0xFFFF {
+		return uint16(code)
+	}
+	return 0
+}
+
+// EventLogString returns the compact single-line form of the given error which is passed as the message string to
+// the ReportEvent API, ie: its logfmt form (see [MarshalLogfmt]).
+func EventLogString(err Error) string {
+	return string(MarshalLogfmt(err))
+}
+
+// xmlEvent is a version of a Windows Event Log event that is used to marshal an error to XML.
+type xmlEvent struct {
+	// XMLName is the name of the root element.
+	XMLName xml.Name `xml:"Event"`
+
+	// Namespace is the namespace of the event schema.
+	Namespace string `xml:"xmlns,attr"`
+
+	// System holds the system properties of the event.
+	System xmlEventSystem `xml:"System"`
+
+	// Data holds the event data.
+	Data []xmlEventData `xml:"EventData>Data"`
+}
+
+// xmlEventSystem holds the system properties of a Windows Event Log event.
+type xmlEventSystem struct {
+	// Provider is the name of the event provider.
+	Provider struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"Provider"`
+
+	// EventID is the ID of the event.
+	EventID uint16 `xml:"EventID"`
+
+	// Level is the level of the event.
+	Level EventLevel `xml:"Level"`
+
+	// TimeCreated is the time at which the event was created.
+	TimeCreated struct {
+		SystemTime string `xml:"SystemTime,attr"`
+	} `xml:"TimeCreated"`
+}
+
+// xmlEventData is a single named value of the data of a Windows Event Log event.
+type xmlEventData struct {
+	// Name is the name of the value.
+	Name string `xml:"Name,attr"`
+
+	// Value is the value formatted as a string.
+	Value string `xml:",chardata"`
+}
+
+// MarshalEventXML marshals the given error to a Windows Event Log event document for the given provider, eg:
+//
+//	<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event">
+//	  <System>
+//	    <Provider Name="agent"/>
+//	    <EventID>12</EventID>
+//	    <Level>2</Level>
+//	    <TimeCreated SystemTime="2024-05-01T10:00:00.0000000Z"/>
+//	  </System>
+//	  <EventData>
+//	    <Data Name="message">not found</Data>
+//	    <Data Name="code">12</Data>
+//	  </EventData>
+//	</Event>
+//
+// The event data holds the message, code, domain, id, kind, op and severity of the error, followed by its attributes
+// (using dotted keys for groups).
+func MarshalEventXML(err Error, provider string) ([]byte, error) {
+	event := xmlEvent{
+		Namespace: _eventSchema,
+		Data:      []xmlEventData{{Name: "message", Value: err.Error()}},
+	}
+	event.System.Provider.Name = provider
+	event.System.EventID = EventID(err)
+	event.System.Level = err.Severity().EventLevel()
+	event.System.TimeCreated.SystemTime = time.Now().UTC().Format("2006-01-02T15:04:05.0000000Z")
+	forEachField(err, func(key, value string) {
+		event.Data = append(event.Data, xmlEventData{Name: key, Value: value})
+	})
+	return xml.Marshal(event)
+}
@@ -0,0 +1,81 @@
+package xerrors
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// CommandAttr is the name of the attribute holding the command line of a failed command.
+	CommandAttr = "command"
+
+	// ExitCodeAttr is the name of the attribute holding the exit code of a failed command.
+	ExitCodeAttr = "exitCode"
+
+	// MaxStderrBytes is the maximum number of bytes of the standard error output of a failed command which are kept.
+	MaxStderrBytes = 4096
+
+	// StderrAttr is the name of the attribute holding the standard error output of a failed command.
+	StderrAttr = "stderr"
+)
+
+// WrapExec wraps the error returned when running the given command in a new [Error] with the given code, so that the
+// failures of subprocesses are reported consistently.
+//
+// The command line, the exit code (if the command ran) and the end of the standard error output are added in the
+// [CommandAttr], [ExitCodeAttr] and [StderrAttr] attributes.  The standard error output is taken from the
+// [exec.ExitError] (as captured by [exec.Cmd.Output]) or from the command's Stderr if it is a [bytes.Buffer] or a
+// [strings.Builder].  Only the last [MaxStderrBytes] bytes are kept, preceded by the [TruncationMarker] if any were
+// dropped.
+func WrapExec(code int, err error, cmd *exec.Cmd) Error {
+	xerr := newError(nil, nil, 0, code, "command failed", err)
+	xerr.WithAttr(CommandAttr, commandLine(cmd.Args))
+
+	var stderr []byte
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		xerr.WithAttr(ExitCodeAttr, exitErr.ExitCode())
+		stderr = exitErr.Stderr
+	}
+	if len(stderr) == 0 {
+		switch w := cmd.Stderr.(type) {
+		case *bytes.Buffer:
+			stderr = w.Bytes()
+		case *strings.Builder:
+			stderr = []byte(w.String())
+		}
+	}
+	if len(stderr) > 0 {
+		xerr.WithAttr(StderrAttr, stderrTail(stderr))
+	}
+	return xerr
+}
+
+// commandLine returns the arguments as a single command line, quoting any arguments which contain spaces or quotes.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// stderrTail returns the last MaxStderrBytes bytes of the output, without any trailing newline.
+func stderrTail(stderr []byte) string {
+	stderr = bytes.TrimRight(stderr, "\n")
+	if len(stderr) <= MaxStderrBytes {
+		return string(stderr)
+	}
+	stderr = stderr[len(stderr)-MaxStderrBytes:]
+	for i := 0; i < utf8.UTFMax && len(stderr) > 0 && !utf8.RuneStart(stderr[0]); i++ {
+		stderr = stderr[1:]
+	}
+	return TruncationMarker + string(stderr)
+}
@@ -0,0 +1,114 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net"
+	"sync"
+)
+
+var (
+	_extractors      = defaultAttrExtractors()
+	_extractorsMutex sync.Mutex
+)
+
+// AttrExtractor is a function which returns the attributes to add to an [Error] wrapping the given error, or nil if it
+// does not handle the error.
+type AttrExtractor func(err error) map[string]any
+
+// RegisterAttrExtractor registers a function which extracts attributes from errors of type T when they are wrapped.
+//
+// When an error is wrapped using [Wrap] or any of the other constructors, each error in the wrapped chain up to the
+// first [Error] is passed to the extractors registered for its type and the attributes they return are added to the
+// new error.  Attributes added to the error afterwards take precedence over extracted ones.
+//
+// The following extractors are registered by default:
+//
+//   - *fs.PathError (and so *os.PathError): path and op
+//   - *net.OpError: addr, net and op
+//   - *json.SyntaxError: offset
+//
+// This function affects all errors created globally by this package.  This call is thread-safe.
+func RegisterAttrExtractor[T error](fn func(T) map[string]any) {
+	extractor := func(err error) map[string]any {
+		if e, ok := err.(T); ok {
+			return fn(e)
+		}
+		return nil
+	}
+	_extractorsMutex.Lock()
+	_extractors = append(_extractors, extractor)
+	_extractorsMutex.Unlock()
+}
+
+// ResetAttrExtractors removes all extractors, including the default ones.
+//
+// This call is thread-safe.
+func ResetAttrExtractors() {
+	_extractorsMutex.Lock()
+	_extractors = []AttrExtractor{}
+	_extractorsMutex.Unlock()
+}
+
+// defaultAttrExtractors returns the extractors for the well-known error types in the standard library.
+func defaultAttrExtractors() []AttrExtractor {
+	return []AttrExtractor{
+		func(err error) map[string]any {
+			if e, ok := err.(*fs.PathError); ok {
+				return map[string]any{"op": e.Op, "path": e.Path}
+			}
+			return nil
+		},
+		func(err error) map[string]any {
+			e, ok := err.(*net.OpError)
+			if !ok {
+				return nil
+			}
+			attrs := map[string]any{"op": e.Op}
+			if e.Net != "" {
+				attrs["net"] = e.Net
+			}
+			if e.Addr != nil {
+				attrs["addr"] = e.Addr.String()
+			}
+			return attrs
+		},
+		func(err error) map[string]any {
+			if e, ok := err.(*json.SyntaxError); ok {
+				return map[string]any{"offset": e.Offset}
+			}
+			return nil
+		},
+	}
+}
+
+// extractAttrs adds the attributes extracted from the wrapped chain of errors to the new error.
+func extractAttrs(xerr *xerr, wrapped error) {
+	if _, ok := wrapped.(Error); ok {
+		return
+	}
+	_extractorsMutex.Lock()
+	extractors := _extractors
+	_extractorsMutex.Unlock()
+	if len(extractors) == 0 {
+		return
+	}
+
+	walkChain(wrapped, func(err error) bool {
+		if _, ok := err.(Error); ok {
+			return false
+		}
+		for _, extractor := range extractors {
+			attrs := extractor(err)
+			if len(attrs) == 0 {
+				continue
+			}
+			for k, v := range attrs {
+				if _, ok := xerr.attrs[k]; !ok {
+					xerr.WithAttr(k, v)
+				}
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,261 @@
+package xerrors
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+// Factory creates [Error] objects which share a common configuration.
+//
+// The zero value is not usable; create a new factory using [NewFactory].  The package-level [New], [Newf], [Wrap]
+// and [Wrapf] functions behave like a factory created with no options.
+type Factory struct {
+	// unexported variables
+	attrs      map[string]any    // attributes added to errors created by this factory
+	callerSkip int               // number of additional stack frames skipped when capturing caller information
+	compose    *bool             // whether or not Error() includes wrapped messages or nil to use the global setting
+	domain     string            // domain assigned to errors created by this factory
+	enrichers  []ContextEnricher // enrichers applied to errors created with a context
+	ext        *xerrExt          // extension shared by errors created by this factory
+	formatter  StringFormatter   // formatter used by String() or nil to use the global setting
+	idGen      *IDGenerator      // generator for error IDs or nil to use the global setting
+	profile    *MarshalProfile   // profile used when marshaling errors created by this factory
+	stackDepth int               // maximum stack depth captured or -1 to use the global setting
+	templates  *templateCache    // interned templates
+	version    *int              // marshal format version or nil to use the profile setting
+}
+
+// FactoryOption is a function which configures a [Factory].
+type FactoryOption func(*Factory)
+
+// WithBaseAttrs adds the given attributes (eg: the service, component and version) to every error created by the
+// factory, so that call sites do not have to repeat them.  Calling it more than once merges the attributes.
+//
+// Attributes added by the call site, context enrichers (see [WithContextEnricher]) or attribute extractors (see
+// [RegisterAttrExtractor]) take precedence over base attributes with the same key.  Each error receives its own copy
+// of the map, but the values are shared between the errors.
+func WithBaseAttrs(attrs map[string]any) FactoryOption {
+	return func(f *Factory) {
+		if f.attrs == nil {
+			f.attrs = make(map[string]any, len(attrs))
+		}
+		maps.Copy(f.attrs, attrs)
+	}
+}
+
+// WithCallerSkip sets the number of additional stack frames to skip when capturing the caller information and stack
+// trace of errors created by the factory.
+//
+// This is useful for helper functions which create errors on behalf of their caller: a skip of 1 attributes the
+// error to the code which called the helper rather than the helper itself.
+func WithCallerSkip(skip int) FactoryOption {
+	return func(f *Factory) {
+		f.callerSkip = max(skip, 0)
+	}
+}
+
+// WithComposedMessages controls whether the Error() method of errors created by the factory includes the messages
+// of the wrapped errors, overriding the global setting from [ComposeMessages].
+func WithComposedMessages(enable bool) FactoryOption {
+	return func(f *Factory) {
+		f.compose = &enable
+	}
+}
+
+// WithDomain sets the domain (eg: the service or component) assigned to errors created by the factory.
+func WithDomain(domain string) FactoryOption {
+	return func(f *Factory) {
+		f.domain = domain
+	}
+}
+
+// WithContextEnricher adds an enricher which is applied to errors created by the factory with a context, in addition
+// to any enrichers registered globally using [RegisterContextEnricher].
+func WithContextEnricher(enricher ContextEnricher) FactoryOption {
+	return func(f *Factory) {
+		f.enrichers = append(f.enrichers, enricher)
+	}
+}
+
+// WithIDGenerator sets the function used to generate a unique ID for each error created by the factory, overriding
+// the global setting from [SetIDGenerator].  Passing nil disables ID generation for the factory.
+func WithIDGenerator(gen IDGenerator) FactoryOption {
+	return func(f *Factory) {
+		f.idGen = &gen
+	}
+}
+
+// WithMarshalProfile sets the profile used to marshal errors created by the factory.
+//
+// If the profile is nil, [DefaultMarshalProfile] is used.
+func WithMarshalProfile(profile *MarshalProfile) FactoryOption {
+	return func(f *Factory) {
+		f.profile = profile
+	}
+}
+
+// WithMarshalVersion sets the format version of the documents produced when errors created by the factory are
+// marshaled to JSON (see [MarshalProfile]), regardless of the order in which the options are given.
+func WithMarshalVersion(version int) FactoryOption {
+	return func(f *Factory) {
+		f.version = &version
+	}
+}
+
+// WithStackDepth sets the maximum number of stack frames captured for errors created by the factory, overriding the
+// global setting from [CaptureStackTrace].  A depth of 0 disables capturing stack traces.
+func WithStackDepth(depth int) FactoryOption {
+	return func(f *Factory) {
+		f.stackDepth = max(depth, 0)
+	}
+}
+
+// NewFactory creates a new [Factory] with the given options.
+func NewFactory(opts ...FactoryOption) *Factory {
+	f := &Factory{
+		stackDepth: -1,
+		templates:  &templateCache{},
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.version != nil {
+		profile := DefaultMarshalProfile()
+		if f.profile != nil {
+			*profile = *f.profile
+		}
+		profile.Version = *f.version
+		f.profile = profile
+	}
+	f.ext = _noExt
+	if f.domain != "" || f.formatter != nil || f.profile != nil {
+		f.ext = &xerrExt{domain: f.domain, formatter: f.formatter, profile: f.profile, shared: true}
+	}
+	return f
+}
+
+// CallerSkip returns a copy of the factory which skips the given number of additional stack frames when capturing
+// the caller information and stack trace.
+//
+// This allows the skip count to be chosen per call, eg: f.CallerSkip(1).New(code, message).
+func (f *Factory) CallerSkip(skip int) *Factory {
+	clone := *f
+	clone.callerSkip += max(skip, 0)
+	clone.templates = &templateCache{}
+	return &clone
+}
+
+// New creates a new [Error] with the given code and message.
+func (f *Factory) New(code int, message string) Error {
+	return newError(nil, f, f.callerSkip, code, message, nil)
+}
+
+// Newf creates a new [Error] with the given code and formatted message.
+func (f *Factory) Newf(code int, format string, args ...any) Error {
+	return newError(nil, f, f.callerSkip, code, fmt.Sprintf(format, args...), nil)
+}
+
+// Wrap wraps the given error in a new [Error] with the given code and message.
+func (f *Factory) Wrap(code int, err error, message string) Error {
+	return newError(nil, f, f.callerSkip, code, message, err)
+}
+
+// Wrapf wraps the given error in a new [Error] with the given code and formatted message.
+func (f *Factory) Wrapf(code int, err error, format string, args ...any) Error {
+	return newError(nil, f, f.callerSkip, code, fmt.Sprintf(format, args...), err)
+}
+
+// NewContext creates a new [Error] with the given code and message, enriched with attributes from the context.
+func (f *Factory) NewContext(ctx context.Context, code int, message string) Error {
+	return newError(ctx, f, f.callerSkip, code, message, nil)
+}
+
+// NewContextf creates a new [Error] with the given code and formatted message, enriched with attributes from the
+// context.
+func (f *Factory) NewContextf(ctx context.Context, code int, format string, args ...any) Error {
+	return newError(ctx, f, f.callerSkip, code, fmt.Sprintf(format, args...), nil)
+}
+
+// WrapContext wraps the given error in a new [Error] with the given code and message, enriched with attributes from
+// the context.
+func (f *Factory) WrapContext(ctx context.Context, code int, err error, message string) Error {
+	return newError(ctx, f, f.callerSkip, code, message, err)
+}
+
+// WrapContextf wraps the given error in a new [Error] with the given code and formatted message, enriched with
+// attributes from the context.
+func (f *Factory) WrapContextf(ctx context.Context, code int, err error, format string, args ...any) Error {
+	return newError(ctx, f, f.callerSkip, code, fmt.Sprintf(format, args...), err)
+}
+
+// newError creates the underlying [xerr] object for all of the public constructors.
+//
+// This function must be called directly from the public constructor so that the caller information points at the
+// code which called the constructor.  The skip parameter indicates how many additional stack frames to skip.  If
+// the context is not nil, the context enrichers are applied to the new error.
+func newError(ctx context.Context, f *Factory, skip int, code int, message string, err error) *xerr {
+	cfg := loadConfig()
+	xerr := &xerr{
+		xerrExt:    _noExt,
+		code:       code,
+		compose:    cfg.ComposeMessages,
+		message:    message,
+		wrappedErr: err,
+	}
+	idGen := cfg.IDGenerator
+	stackDepth := cfg.StackDepth
+	if f != nil {
+		if f.idGen != nil {
+			idGen = *f.idGen
+		}
+		if f.compose != nil {
+			xerr.compose = *f.compose
+		}
+		xerr.xerrExt = f.ext
+		if f.stackDepth >= 0 {
+			stackDepth = f.stackDepth
+		}
+	}
+	if idGen != nil {
+		xerr.mutable().id = idGen()
+	}
+	if cfg.CaptureCaller {
+		xerr.mutable().caller = GetCallerInfo(1 + skip)
+	} else if cfg.TraceWrapSites {
+		xerr.mutable().site = GetCallerInfo(1 + skip)
+	}
+	if stackDepth > 0 {
+		xerr.mutable().stack = GetStackTrace(1+skip, stackDepth)
+	}
+	if err != nil {
+		markWrappedInspected(err)
+		extractAttrs(xerr, err)
+		if len(xerr.stack) == 0 {
+			if stack := foreignStackTrace(err); stack != nil {
+				xerr.mutable().stack = stack
+			}
+		}
+	}
+	if f != nil && len(f.attrs) > 0 {
+		if xerr.attrs == nil {
+			xerr.attrs = make(map[string]any, len(f.attrs))
+		}
+		for k, v := range f.attrs {
+			k = normalizeKey(k)
+			if _, ok := xerr.attrs[k]; !ok {
+				xerr.attrs[k] = v
+			}
+		}
+	}
+	if ctx != nil {
+		enrich(ctx, f, xerr)
+	}
+	runHooks(xerr)
+	if cfg.DuplicateWrapHandler != nil {
+		detectDuplicateWrap(xerr, cfg.DuplicateWrapHandler)
+	}
+	writeSink(xerr)
+	trackSwallowed(xerr)
+	return xerr
+}
@@ -0,0 +1,82 @@
+package xerrors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Fingerprint returns a short hexadecimal string identifying the kind of failure represented by the given error, so
+// that repeated occurrences of the same failure can be grouped together.
+//
+// The fingerprint is derived from the domain, code and caller location (if captured) of each [Error] in the chain
+// and from the types of any other errors in it.  Messages, attributes and IDs are not included, so errors created at
+// the same place for different values share a fingerprint.  See [ErrorKey] for the stability of fingerprints.  The
+// fingerprint of a nil error is an empty string.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	h := sha256.New()
+	walkChain(err, func(err error) bool {
+		if e, ok := err.(Error); ok {
+			h.Write([]byte(e.Domain()))
+			h.Write([]byte{0})
+			h.Write([]byte(strconv.Itoa(e.Code())))
+			h.Write([]byte{0})
+			if caller := e.Caller(); caller != *DefaultCallerInfo() {
+				h.Write([]byte(caller.File))
+				h.Write([]byte{0})
+				h.Write([]byte(strconv.Itoa(caller.Line)))
+			}
+		} else {
+			h.Write([]byte(fmt.Sprintf("%T", err)))
+		}
+		h.Write([]byte{0xff})
+		return true
+	})
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// ErrorKey is a comparable identifier of the kind of failure represented by an error, suitable for use as a map key
+// when counting errors, caching negative results or deduplicating alerts.
+//
+// Keys are stable across processes and restarts as long as the errors are created at the same place in the code:
+// the fingerprint includes the caller file and line (if captured), so moving the code which creates an error changes
+// its key.  The way keys are derived will not change within a major version of this package.
+type ErrorKey struct {
+	// Domain is the domain of the first [Error] in the chain.
+	Domain string `json:"domain,omitempty"`
+
+	// Code is the code of the first [Error] in the chain.
+	Code int `json:"code"`
+
+	// Fingerprint is the [Fingerprint] of the error.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Key returns the [ErrorKey] of the given error.
+//
+// Errors without an [Error] in their chain have an empty domain and a code of 0.  The key of a nil error is the
+// zero value.
+func Key(err error) ErrorKey {
+	if err == nil {
+		return ErrorKey{}
+	}
+	key := ErrorKey{
+		Fingerprint: Fingerprint(err),
+	}
+	var xerr Error
+	if errors.As(err, &xerr) {
+		key.Domain = xerr.Domain()
+		key.Code = xerr.Code()
+	}
+	return key
+}
+
+// String returns the key in the form "domain/code/fingerprint".
+func (k ErrorKey) String() string {
+	return k.Domain + "/" + strconv.Itoa(k.Code) + "/" + k.Fingerprint
+}
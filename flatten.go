@@ -0,0 +1,51 @@
+package xerrors
+
+// FlatError is a single layer of an error chain produced by [Flatten].
+type FlatError struct {
+	// Index is the position of the layer in the chain, starting at 0 for the outermost error.
+	Index int `json:"index"`
+
+	// Code is the error code or 0 if the layer was not created by this package.
+	Code int `json:"code"`
+
+	// Message is the message of the layer.
+	Message string `json:"message"`
+
+	// Caller contains the information on where the layer was generated, if it was captured.
+	Caller *CallerInfo `json:"caller,omitempty"`
+}
+
+// Flatten returns the layers of the chain of the given error as a flat, ordered slice, from the outermost to the
+// innermost.
+//
+// This is intended for log backends which cannot index nested documents.  Every error visited by [Chain] becomes a
+// layer; note that the messages of errors which were not created by this package usually include the messages of the
+// errors they wrap.  If the chain is too deep or contains a cycle, the last layer only contains the [TruncationMarker]
+// as its message.
+func Flatten(err error) []FlatError {
+	var layers []FlatError
+	truncated := walkChain(err, func(err error) bool {
+		layer := FlatError{
+			Index:   len(layers),
+			Message: err.Error(),
+		}
+		if e, ok := err.(Error); ok {
+			layer.Code = e.Code()
+			if caller := e.Caller(); caller != *DefaultCallerInfo() {
+				layer.Caller = &caller
+			}
+		}
+		if xerr, isXErr := err.(*xerr); isXErr {
+			layer.Message = xerr.message
+		}
+		layers = append(layers, layer)
+		return true
+	})
+	if truncated {
+		layers = append(layers, FlatError{
+			Index:   len(layers),
+			Message: TruncationMarker,
+		})
+	}
+	return layers
+}
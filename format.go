@@ -0,0 +1,105 @@
+package xerrors
+
+import (
+	"strings"
+	"text/template"
+)
+
+// StringFormatter renders an [Error] as the string returned by its String method, eg: to match an existing log line
+// convention.
+type StringFormatter func(err Error) string
+
+// TemplateData holds the details of an [Error] which are available to the template of a [TemplateFormatter].
+type TemplateData struct {
+	// Attrs holds the attributes of the error.
+	Attrs map[string]any
+
+	// Caller holds the information on where the error was generated or nil if it was not captured.
+	Caller *CallerInfo
+
+	// Chain holds the layers of the error chain, from the error itself to the innermost error (see [Flatten]).
+	Chain []FlatError
+
+	// Code is the error code.
+	Code int
+
+	// Domain is the domain the error belongs to, if any.
+	Domain string
+
+	// ID is the unique ID of the error, if any.
+	ID string
+
+	// Kind is the broad category of the failure, if set.
+	Kind Kind
+
+	// Message is the message of the error itself, without the messages of the wrapped errors.
+	Message string
+
+	// Op is the breadcrumb path of the operations in the error chain, if any (see [Ops]).
+	Op string
+
+	// Position is the location in the input the error refers to or nil if it has not been set.
+	Position *Position
+
+	// Severity is how serious the failure is.
+	Severity Severity
+}
+
+// SetStringFormatter sets the formatter used by the String method of errors created by a factory without a
+// formatter of its own (see [WithStringFormatter]) or nil (the default) to render errors as JSON.
+//
+// This function affects all errors generated globally by this package.  This call is thread-safe.
+func SetStringFormatter(formatter StringFormatter) {
+	UpdateConfig(func(c *Config) {
+		c.StringFormatter = formatter
+	})
+}
+
+// WithStringFormatter sets the formatter used by the String method of errors created by the factory, overriding the
+// global setting from [SetStringFormatter].
+func WithStringFormatter(formatter StringFormatter) FactoryOption {
+	return func(f *Factory) {
+		f.formatter = formatter
+	}
+}
+
+// TemplateFormatter returns a [StringFormatter] which renders errors by executing the given template with the
+// [TemplateData] of the error, eg:
+//
+//	text := `[{{.Code}}] {{.Message}}{{range $k, $v := .Attrs}} {{$k}}={{$v}}{{end}}`
+//	tmpl := template.Must(template.New("error").Parse(text))
+//	xerrors.SetStringFormatter(xerrors.TemplateFormatter(tmpl))
+//
+// If the template fails to execute, the string describes the failure instead.
+func TemplateFormatter(tmpl *template.Template) StringFormatter {
+	return func(err Error) string {
+		var sb strings.Builder
+		if tErr := tmpl.Execute(&sb, newTemplateData(err)); tErr != nil {
+			return "failed to render error: " + tErr.Error()
+		}
+		return sb.String()
+	}
+}
+
+// newTemplateData returns the details of the error which are available to templates.
+func newTemplateData(err Error) TemplateData {
+	data := TemplateData{
+		Attrs:    err.Attrs(),
+		Chain:    Flatten(err),
+		Code:     err.Code(),
+		Domain:   err.Domain(),
+		ID:       err.ID(),
+		Kind:     err.Kind(),
+		Message:  err.Error(),
+		Op:       opPath(err),
+		Severity: err.Severity(),
+	}
+	if caller := err.Caller(); caller != *DefaultCallerInfo() {
+		data.Caller = &caller
+	}
+	if xerr, ok := err.(*xerr); ok {
+		data.Message = xerr.message
+		data.Position = xerr.position
+	}
+	return data
+}
@@ -0,0 +1,59 @@
+package xerrors
+
+import (
+	"strings"
+)
+
+// AttrGroup holds the attributes added to a group using WithGroup.
+//
+// Groups are stored as values in the attributes of an [Error], keyed by the group name, and are rendered as nested
+// objects when the error is marshaled to JSON.
+type AttrGroup map[string]any
+
+// attrTarget returns the map which attributes are added to, creating the groups of the current group path as
+// needed.
+func (e *xerr) attrTarget() map[string]any {
+	if e.attrs == nil {
+		e.attrs = make(map[string]any)
+	}
+	target := e.attrs
+	for _, name := range e.group {
+		group, ok := target[name].(AttrGroup)
+		if !ok {
+			group = make(AttrGroup)
+			target[name] = group
+		}
+		target = group
+	}
+	return target
+}
+
+// attrPath returns the dotted path of the given key in the current group, eg: "db.query".
+func (e *xerr) attrPath(key string) string {
+	if len(e.group) == 0 {
+		return key
+	}
+	return strings.Join(e.group, ".") + "." + key
+}
+
+// prepareAttr returns the attribute value at the given dotted path in the form which is marshaled using the given
+// profile, applying the registered serializers and the truncation limits.
+//
+// Attributes in groups are prepared recursively; the second return value is false if the attribute is omitted due to
+// its classification.
+func (p *MarshalProfile) prepareAttr(classes map[string]Classification, path string, value any) (any, bool) {
+	if p.omitsClassification(classes[path]) {
+		return nil, false
+	}
+	group, ok := value.(AttrGroup)
+	if !ok {
+		return p.truncateAttr(serializeAttr(value)), true
+	}
+	prepared := make(AttrGroup, len(group))
+	for k, v := range group {
+		if v, ok := p.prepareAttr(classes, path+"."+k, v); ok {
+			prepared[p.KeyNormalizer.Normalize(k)] = v
+		}
+	}
+	return prepared, true
+}
@@ -0,0 +1,69 @@
+// Package grpcx contains helpers for transporting [xerrors.Error] objects over gRPC.
+//
+// The helpers operate on plain map[string][]string values, which is the underlying type of the gRPC metadata.MD
+// type, so this package does not depend on the gRPC module: metadata.MD values can be passed in directly.
+package grpcx
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// CodeKey is the metadata key containing the error code.
+	CodeKey = "x-error-code"
+
+	// DomainKey is the metadata key containing the error domain.
+	DomainKey = "x-error-domain"
+
+	// IDKey is the metadata key containing the unique error ID.
+	IDKey = "x-error-id"
+
+	// MessageKey is the metadata key containing the percent-encoded error message.
+	MessageKey = "x-error-message"
+)
+
+// DecodeMetadata reconstructs an [xerrors.Error] from metadata set by [SetMetadata].
+//
+// The second return value is false if the metadata does not contain an error code.
+func DecodeMetadata(md map[string][]string) (xerrors.Error, bool) {
+	code, err := strconv.Atoi(get(md, CodeKey))
+	if err != nil {
+		return nil, false
+	}
+	message, err := url.PathUnescape(get(md, MessageKey))
+	if err != nil {
+		message = get(md, MessageKey)
+	}
+	xerr := xerrors.NewFactory(xerrors.WithDomain(get(md, DomainKey))).New(code, message)
+	if id := get(md, IDKey); id != "" {
+		xerr.WithID(id)
+	}
+	return xerr, true
+}
+
+// SetMetadata adds keys describing the given error to the metadata (eg: a header or trailer metadata.MD sent with
+// grpc.SetTrailer).
+func SetMetadata(md map[string][]string, err xerrors.Error) {
+	md[CodeKey] = []string{strconv.Itoa(err.Code())}
+	md[MessageKey] = []string{url.PathEscape(err.Error())}
+	if domain := err.Domain(); domain != "" {
+		md[DomainKey] = []string{domain}
+	}
+	if id := err.ID(); id != "" {
+		md[IDKey] = []string{id}
+	}
+}
+
+// get returns the first value for the given key, which is matched case-insensitively like gRPC metadata keys.
+func get(md map[string][]string, key string) string {
+	for k, v := range md {
+		if strings.ToLower(k) == key && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package grpcx
+
+import (
+	"testing"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	err := xerrors.NewFactory(xerrors.WithDomain("billing")).New(1042, "quota exceeded: 100% used / ünïcode\n").
+		WithID("01J9ZQ3V5X8Y2K6M4N7P0R1S3T")
+	md := map[string][]string{"x-request-id": {"abc"}}
+	SetMetadata(md, err)
+
+	decoded, ok := DecodeMetadata(md)
+	if !ok {
+		t.Fatal("the error was not decoded")
+	}
+	if decoded.Code() != 1042 || decoded.Error() != err.Error() || decoded.Domain() != "billing" ||
+		decoded.ID() != err.ID() {
+		t.Errorf("decoded %d %q in domain %q with ID %q", decoded.Code(), decoded.Error(), decoded.Domain(),
+			decoded.ID())
+	}
+	if md["x-request-id"][0] != "abc" {
+		t.Error("the other metadata was modified")
+	}
+}
+
+func TestDecodeMetadataMatchesKeysCaseInsensitively(t *testing.T) {
+	decoded, ok := DecodeMetadata(map[string][]string{"X-Error-Code": {"7"}, "X-Error-Message": {"failed%20here"}})
+	if !ok || decoded.Code() != 7 || decoded.Error() != "failed here" {
+		t.Errorf("unexpected decoded error: %v", decoded)
+	}
+	if _, ok := DecodeMetadata(map[string][]string{MessageKey: {"failed"}}); ok {
+		t.Error("expected metadata without a code not to be decoded")
+	}
+	if _, ok := DecodeMetadata(map[string][]string{CodeKey: {}}); ok {
+		t.Error("expected metadata with an empty code not to be decoded")
+	}
+}
@@ -0,0 +1,91 @@
+package xerrors
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	_maxHelperFrames = 64
+)
+
+var (
+	_helperFuncs    = map[string]struct{}{}
+	_helperPackages = []string{}
+	_helpersMutex   sync.RWMutex
+)
+
+// MarkHelper marks the calling function as an error helper function.
+//
+// Like [testing.T.Helper], frames belonging to helper functions are skipped when capturing caller information and
+// stack traces, so errors created inside a generic helper such as "fail(err)" are attributed to the code which
+// called the helper.  Unlike [testing.T.Helper], the mark applies globally and only needs to happen once, although
+// calling it every time the helper runs is cheap.  This call is thread-safe.
+func MarkHelper() {
+	// the frame is resolved logically, so a helper which has been inlined into its caller is still marked
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+	if frame, _ := runtime.CallersFrames(pcs[:]).Next(); frame.Function != "" {
+		RegisterHelperFuncs(frame.Function)
+	}
+}
+
+// RegisterHelperFuncs marks the functions with the given fully-qualified names (eg: "example.com/app/errutil.Fail")
+// as error helper functions.  See [MarkHelper] for details.
+//
+// This call is thread-safe.
+func RegisterHelperFuncs(names ...string) {
+	_helpersMutex.Lock()
+	for _, name := range names {
+		_helperFuncs[name] = struct{}{}
+	}
+	_helpersMutex.Unlock()
+}
+
+// RegisterHelperPackages marks every function in the packages with the given import paths (eg:
+// "example.com/app/errutil") as error helper functions.  See [MarkHelper] for details.
+//
+// This call is thread-safe.
+func RegisterHelperPackages(paths ...string) {
+	_helpersMutex.Lock()
+	_helperPackages = append(_helperPackages, paths...)
+	_helpersMutex.Unlock()
+}
+
+// hasHelpers returns true if any helper functions or packages have been registered.
+func hasHelpers() bool {
+	_helpersMutex.RLock()
+	defer _helpersMutex.RUnlock()
+	return len(_helperFuncs) > 0 || len(_helperPackages) > 0
+}
+
+// isHelper returns true if the function with the given fully-qualified name is a helper function.
+func isHelper(fn string) bool {
+	_helpersMutex.RLock()
+	defer _helpersMutex.RUnlock()
+	if _, ok := _helperFuncs[fn]; ok {
+		return true
+	}
+	if len(_helperPackages) == 0 {
+		return false
+	}
+	pkg := funcPackage(fn)
+	for _, path := range _helperPackages {
+		if pkg == path {
+			return true
+		}
+	}
+	return false
+}
+
+// funcPackage returns the import path of the package from a fully-qualified function name.
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+	if dot := strings.Index(fn[slash+1:], "."); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+	return fn
+}
@@ -0,0 +1,85 @@
+package xerrors
+
+import (
+	"strings"
+	"testing"
+)
+
+// inlinedCaller returns its caller; it is small enough to be inlined into the test.
+func inlinedCaller() *CallerInfo {
+	return GetCallerInfo(0)
+}
+
+// inlinedHelper creates an error on behalf of its caller; it is small enough to be inlined into the test, so it is
+// registered by name rather than by calling MarkHelper.
+func inlinedHelper() Error {
+	return New(1, "failed")
+}
+
+// outlinedHelper creates an error on behalf of its caller and is never inlined.
+//
+//go:noinline
+func outlinedHelper() Error {
+	MarkHelper()
+	return New(1, "failed")
+}
+
+// resetHelpers removes the helpers marked by the test at the end of the test.
+func resetHelpers(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		_helpersMutex.Lock()
+		clear(_helperFuncs)
+		_helpersMutex.Unlock()
+	})
+}
+
+// wantTestCaller checks that the caller is the given line of the test.
+func wantTestCaller(t *testing.T, caller CallerInfo, line int, fn string) {
+	t.Helper()
+	if !strings.HasSuffix(caller.File, "helper_test.go") || caller.Line != line ||
+		caller.Func != "go.innotegrity.dev/xerrors."+fn {
+		t.Errorf("expected caller at helper_test.go:%d in %s, got %s", line, fn, caller)
+	}
+}
+
+func TestGetCallerInfoInInlinedFunction(t *testing.T) {
+	caller, line := inlinedCaller(), currentLine()
+	wantTestCaller(t, *caller, line, "TestGetCallerInfoInInlinedFunction")
+}
+
+func TestCallerOfInlinedNew(t *testing.T) {
+	captureCallers(t)
+	err, line := New(1, "failed"), currentLine()
+	wantTestCaller(t, err.Caller(), line, "TestCallerOfInlinedNew")
+}
+
+func TestInlinedHelpersAreSkipped(t *testing.T) {
+	captureCallers(t)
+	resetHelpers(t)
+	RegisterHelperFuncs("go.innotegrity.dev/xerrors.inlinedHelper")
+	err, line := inlinedHelper(), currentLine()
+	wantTestCaller(t, err.Caller(), line, "TestInlinedHelpersAreSkipped")
+}
+
+func TestMarkedHelpersAreSkipped(t *testing.T) {
+	captureCallers(t)
+	resetHelpers(t)
+	// the first call marks the helper, so the caller of the second one is checked
+	outlinedHelper()
+	err, line := outlinedHelper(), currentLine()
+	wantTestCaller(t, err.Caller(), line, "TestMarkedHelpersAreSkipped")
+}
+
+func TestInlinedHelpersAreSkippedInStackTraces(t *testing.T) {
+	CaptureStackTrace(4)
+	t.Cleanup(func() { CaptureStackTrace(0) })
+	resetHelpers(t)
+	RegisterHelperFuncs("go.innotegrity.dev/xerrors.inlinedHelper")
+	err, line := inlinedHelper(), currentLine()
+	stack := err.StackTrace()
+	if len(stack) == 0 {
+		t.Fatal("no stack trace was captured")
+	}
+	wantTestCaller(t, stack[0], line, "TestInlinedHelpersAreSkippedInStackTraces")
+}
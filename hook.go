@@ -0,0 +1,52 @@
+package xerrors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	_hooks        = []Hook{}
+	_hooksEnabled atomic.Bool // set while any hooks are registered, so creating errors can skip the mutex
+	_hooksMutex   sync.Mutex
+)
+
+// Hook is a function which is called whenever a new [Error] is created by this package.
+//
+// Hooks are called synchronously before the error is returned to the caller, so they should return quickly.  Any
+// attributes added to the error after it has been created will not be present when the hook is called.
+type Hook func(Error)
+
+// RegisterHook adds a hook to be called whenever a new [Error] is created.
+//
+// This function affects all errors created globally by this package.  This call is thread-safe.
+func RegisterHook(hook Hook) {
+	_hooksMutex.Lock()
+	_hooks = append(_hooks, hook)
+	_hooksEnabled.Store(true)
+	_hooksMutex.Unlock()
+}
+
+// ResetHooks removes all hooks which were added using [RegisterHook].
+//
+// This call is thread-safe.
+func ResetHooks() {
+	_hooksMutex.Lock()
+	_hooks = []Hook{}
+	_hooksEnabled.Store(false)
+	_hooksMutex.Unlock()
+}
+
+// runHooks calls each registered hook with the given error.
+func runHooks(err Error) {
+	if !_hooksEnabled.Load() {
+		return
+	}
+	_hooksMutex.Lock()
+	hooks := _hooks
+	_hooksMutex.Unlock()
+
+	for _, hook := range hooks {
+		hook(err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package httpx contains helpers for transporting [xerrors.Error] objects over HTTP.
+package httpx
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// MaxBodySize is the maximum number of bytes read from a response body when decoding an error.
+	MaxBodySize = 1 << 20
+
+	// ProblemContentType is the content type of an RFC 9457 problem details document.
+	ProblemContentType = "application/problem+json"
+)
+
+// DecodeResponse reconstructs an [xerrors.Error] from a failed HTTP response.
+//
+// The response body may contain either an RFC 9457 problem details document or a JSON document produced by
+// [xerrors.Error.MarshalJSON].  For problem details documents, the "code" extension member is used as the error
+// code (falling back to the response status code), "detail" (or "title") is used as the message and all other
+// members are added as attributes.  If the body cannot be decoded, the error has the response status code and a
+// message built from the status and body text.
+//
+// The backoff hint written by [WriteError] (or a [RetryHint] in the "retry" member of a problem details document) is
+// restored onto the returned error.  If there is no delay in the hint and the response has a Retry-After header, the
+// delay from the header (which only has a precision of seconds) is set instead.  The response body is read but not
+// closed.
+func DecodeResponse(resp *http.Response) xerrors.Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxBodySize))
+	xerr := decodeBody(resp, body)
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && xerr.RetryAfter() == 0 {
+		xerr.WithRetryAfter(d)
+	}
+	return xerr
+}
+
+// decodeBody reconstructs the error from the response body.
+func decodeBody(resp *http.Response, body []byte) xerrors.Error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != ProblemContentType {
+		if xerr, err := xerrors.ParseJSON(body); err == nil {
+			var doc map[string]json.RawMessage
+			if json.Unmarshal(body, &doc) == nil {
+				applyRetryHint(xerr, doc[RetryMember])
+			}
+			return xerr
+		}
+	}
+	if xerr, ok := decodeProblem(resp, body); ok {
+		return xerr
+	}
+
+	message := resp.Status
+	if text := strings.TrimSpace(string(body)); text != "" {
+		message += ": " + text
+	}
+	return xerrors.New(resp.StatusCode, message)
+}
+
+// decodeProblem reconstructs the error from a problem details document.
+func decodeProblem(resp *http.Response, body []byte) (xerrors.Error, bool) {
+	var members map[string]any
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, false
+	}
+
+	code := resp.StatusCode
+	if c, ok := members["code"].(float64); ok {
+		code = int(c)
+		delete(members, "code")
+	}
+	message, _ := members["detail"].(string)
+	if message == "" {
+		message, _ = members["title"].(string)
+	} else {
+		delete(members, "detail")
+	}
+	if message == "" {
+		message = resp.Status
+	}
+	var retry json.RawMessage
+	if hint, ok := members[RetryMember]; ok {
+		retry, _ = json.Marshal(hint)
+		delete(members, RetryMember)
+	}
+	xerr := xerrors.New(code, message)
+	applyRetryHint(xerr, retry)
+	if len(members) > 0 {
+		xerr.WithAttrs(members)
+	}
+	return xerr, true
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which may be either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,142 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// newResponse returns a response with the given status code, content type and body.
+func newResponse(status int, contentType, body string) *http.Response {
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	if contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	return resp
+}
+
+func TestWriteErrorRoundTrip(t *testing.T) {
+	err := xerrors.New(1042, "quota exceeded").WithAttr("user", "alice").WithRetryAfter(1500 * time.Millisecond)
+	rec := httptest.NewRecorder()
+	WriteError(rec, http.StatusTooManyRequests, err)
+
+	decoded := DecodeResponse(rec.Result())
+	if decoded.Code() != 1042 || decoded.Error() != "quota exceeded" || decoded.Attrs()["user"] != "alice" {
+		t.Errorf("unexpected decoded error: %v", decoded)
+	}
+	// the hint in the body is more precise than the Retry-After header
+	if !decoded.Retryable() || decoded.RetryAfter() != 1500*time.Millisecond {
+		t.Errorf("decoded Retryable() = %t and RetryAfter() = %v", decoded.Retryable(), decoded.RetryAfter())
+	}
+}
+
+func TestWriteProblemRoundTrip(t *testing.T) {
+	registry := xerrors.NewRegistry()
+	registry.Register(xerrors.Definition{Code: 1042, Message: "Quota exceeded", DocsURL: "https://example.com/1042"})
+	err := xerrors.New(1042, "user alice exceeded the quota").WithAttr("limit", 10).WithRetryable(true).
+		WithAttr(RetryMember, "soon")
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, http.StatusTooManyRequests, err, registry)
+
+	resp := rec.Result()
+	if ct := resp.Header.Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %s, want %s", ct, ProblemContentType)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if n := bytes.Count(body, []byte(`"retry":`)); n != 1 {
+		t.Errorf("the document contains %d retry members: %s", n, body)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	decoded := DecodeResponse(resp)
+	if decoded.Code() != 1042 || decoded.Error() != "user alice exceeded the quota" || !decoded.Retryable() {
+		t.Errorf("unexpected decoded error: %v", decoded)
+	}
+	attrs := decoded.Attrs()
+	if attrs["limit"] != 10.0 || attrs["title"] != "Quota exceeded" || attrs["type"] != "https://example.com/1042" {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		resp        *http.Response
+		wantCode    int
+		wantMessage string
+		wantRetry   time.Duration
+	}{
+		{
+			name:        "problem without code",
+			resp:        newResponse(http.StatusNotFound, ProblemContentType, `{"title":"Not Found","status":404}`),
+			wantCode:    http.StatusNotFound,
+			wantMessage: "Not Found",
+		},
+		{
+			name: "problem with retry hint",
+			resp: newResponse(http.StatusServiceUnavailable, ProblemContentType+"; charset=utf-8",
+				`{"code":7,"detail":"maintenance","retry":{"retryable":true,"afterMs":250}}`),
+			wantCode:    7,
+			wantMessage: "maintenance",
+			wantRetry:   250 * time.Millisecond,
+		},
+		{
+			name:        "plain text",
+			resp:        newResponse(http.StatusBadGateway, "text/plain", "upstream is down\n"),
+			wantCode:    http.StatusBadGateway,
+			wantMessage: "502 Bad Gateway: upstream is down",
+		},
+		{
+			name:        "empty body",
+			resp:        newResponse(http.StatusInternalServerError, "", ""),
+			wantCode:    http.StatusInternalServerError,
+			wantMessage: "500 Internal Server Error",
+		},
+	}
+	for _, test := range tests {
+		decoded := DecodeResponse(test.resp)
+		if decoded.Code() != test.wantCode || decoded.Error() != test.wantMessage {
+			t.Errorf("%s: decoded %d %q, want %d %q", test.name, decoded.Code(), decoded.Error(), test.wantCode,
+				test.wantMessage)
+		}
+		if decoded.RetryAfter() != test.wantRetry {
+			t.Errorf("%s: RetryAfter() = %v, want %v", test.name, decoded.RetryAfter(), test.wantRetry)
+		}
+	}
+}
+
+func TestDecodeResponseUsesRetryAfterHeader(t *testing.T) {
+	resp := newResponse(http.StatusTooManyRequests, "text/plain", "slow down")
+	resp.Header.Set("Retry-After", "3")
+	if got := DecodeResponse(resp).RetryAfter(); got != 3*time.Second {
+		t.Errorf("RetryAfter() = %v, want 3s", got)
+	}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"120": 2 * time.Minute,
+		"-5":  0,
+		now.Add(time.Minute).Format(http.TimeFormat):  time.Minute,
+		now.Add(-time.Minute).Format(http.TimeFormat): 0,
+	}
+	for value, want := range tests {
+		if got, ok := parseRetryAfter(value, now); !ok || got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, %t, want %v", value, got, ok, want)
+		}
+	}
+	if _, ok := parseRetryAfter("soon", now); ok {
+		t.Error("parseRetryAfter accepted an invalid value")
+	}
+}
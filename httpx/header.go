@@ -0,0 +1,80 @@
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// CodeHeader is the name of the header containing the error code.
+	CodeHeader = "X-Error-Code"
+
+	// DomainHeader is the name of the header containing the error domain.
+	DomainHeader = "X-Error-Domain"
+
+	// IDHeader is the name of the header containing the unique error ID.
+	IDHeader = "X-Error-Id"
+
+	// MessageHeader is the name of the header containing the percent-encoded error message.
+	MessageHeader = "X-Error-Message"
+)
+
+// DecodeHeaders reconstructs an [xerrors.Error] from headers set by [SetHeaders] or [SetTrailers].
+//
+// When decoding trailers, pass the response's Trailer field after the body has been read to EOF.  The second return
+// value is false if the headers do not contain an error code.
+func DecodeHeaders(h http.Header) (xerrors.Error, bool) {
+	code, err := strconv.Atoi(h.Get(CodeHeader))
+	if err != nil {
+		return nil, false
+	}
+	message, err := url.PathUnescape(h.Get(MessageHeader))
+	if err != nil {
+		message = h.Get(MessageHeader)
+	}
+	xerr := xerrors.NewFactory(xerrors.WithDomain(h.Get(DomainHeader))).New(code, message)
+	if id := h.Get(IDHeader); id != "" {
+		xerr.WithID(id)
+	}
+	if d, ok := parseRetryAfter(h.Get("Retry-After"), time.Now()); ok {
+		xerr.WithRetryAfter(d)
+	}
+	return xerr, true
+}
+
+// SetHeaders sets headers describing the given error.
+//
+// This is intended for cases where the response body is a stream which cannot carry the error document.  Headers
+// must be set before the response status is written; use [SetTrailers] if the error occurs after the body has
+// started.
+func SetHeaders(h http.Header, err xerrors.Error) {
+	setHeaders(h, "", err)
+}
+
+// SetTrailers sets trailers describing the given error on the response.
+//
+// Trailers can be set at any point before the handler returns, even after the body has been written, and do not
+// need to be declared in advance.  Note that HTTP/1.1 only sends trailers with chunked responses, ie: once the body
+// has been flushed or has grown too large to be buffered.
+func SetTrailers(w http.ResponseWriter, err xerrors.Error) {
+	setHeaders(w.Header(), http.TrailerPrefix, err)
+}
+
+// setHeaders sets the headers describing the given error, adding the prefix to each header name.
+func setHeaders(h http.Header, prefix string, err xerrors.Error) {
+	h.Set(prefix+CodeHeader, strconv.Itoa(err.Code()))
+	h.Set(prefix+MessageHeader, url.PathEscape(err.Error()))
+	if domain := err.Domain(); domain != "" {
+		h.Set(prefix+DomainHeader, domain)
+	}
+	if id := err.ID(); id != "" {
+		h.Set(prefix+IDHeader, id)
+	}
+	if d := err.RetryAfter(); d > 0 && prefix == "" {
+		h.Set("Retry-After", strconv.Itoa(int((d+time.Second-1)/time.Second)))
+	}
+}
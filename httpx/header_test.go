@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// headerError returns an error with all of the fields carried by the headers.
+func headerError() xerrors.Error {
+	return xerrors.NewFactory(xerrors.WithDomain("billing")).New(1042, "quota exceeded: 100% used / ünïcode\r\n").
+		WithID("01J9ZQ3V5X8Y2K6M4N7P0R1S3T").WithRetryAfter(1500 * time.Millisecond)
+}
+
+// checkHeaderError checks that the decoded error matches the one returned by headerError.
+func checkHeaderError(t *testing.T, decoded xerrors.Error, ok bool, wantRetry time.Duration) {
+	t.Helper()
+	if !ok {
+		t.Fatal("the error was not decoded")
+	}
+	want := headerError()
+	if decoded.Code() != want.Code() || decoded.Error() != want.Error() || decoded.Domain() != want.Domain() ||
+		decoded.ID() != want.ID() {
+		t.Errorf("decoded %d %q in domain %q with ID %q", decoded.Code(), decoded.Error(), decoded.Domain(),
+			decoded.ID())
+	}
+	if decoded.RetryAfter() != wantRetry {
+		t.Errorf("RetryAfter() = %v, want %v", decoded.RetryAfter(), wantRetry)
+	}
+}
+
+func TestHeadersRoundTrip(t *testing.T) {
+	h := http.Header{}
+	SetHeaders(h, headerError())
+	if got := h.Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want the delay rounded up to 2 seconds", got)
+	}
+	decoded, ok := DecodeHeaders(h)
+	checkHeaderError(t, decoded, ok, 2*time.Second)
+}
+
+func TestTrailersRoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial output")
+		w.(http.Flusher).Flush()
+		SetTrailers(w, headerError())
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, ok := DecodeHeaders(resp.Header); ok {
+		t.Error("the error was sent in the headers")
+	}
+	io.ReadAll(resp.Body)
+	// trailers do not carry the Retry-After header
+	decoded, ok := DecodeHeaders(resp.Trailer)
+	checkHeaderError(t, decoded, ok, 0)
+}
+
+func TestDecodeHeadersWithoutCode(t *testing.T) {
+	if _, ok := DecodeHeaders(http.Header{MessageHeader: {"failed"}}); ok {
+		t.Error("expected headers without a code not to be decoded")
+	}
+	if _, ok := DecodeHeaders(http.Header{CodeHeader: {"abc"}}); ok {
+		t.Error("expected headers with an invalid code not to be decoded")
+	}
+	decoded, ok := DecodeHeaders(http.Header{CodeHeader: {"7"}, MessageHeader: {"50%"}})
+	if !ok || decoded.Code() != 7 || decoded.Error() != "50%" {
+		t.Errorf("a message which is not percent-encoded was not kept: %v", decoded)
+	}
+}
@@ -0,0 +1,101 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// problemWriter holds the configuration of the documents written by [WriteProblem].
+type problemWriter struct {
+	// unexported variables
+	profile *xerrors.MarshalProfile // profile used to prepare the attributes and translate the code and message
+}
+
+// ProblemOption is a function which configures the document written by [WriteProblem].
+type ProblemOption func(*problemWriter)
+
+// WithProblemProfile sets the marshal profile used to prepare the attributes of the error (see
+// [xerrors.PrepareAttrs]) and to translate its code and message (see [xerrors.Translator]), replacing the
+// [DefaultProblemProfile].
+func WithProblemProfile(profile *xerrors.MarshalProfile) ProblemOption {
+	return func(p *problemWriter) {
+		p.profile = profile
+	}
+}
+
+// DefaultProblemProfile returns the marshal profile used by [WriteProblem] unless another one is set using
+// [WithProblemProfile], which omits the attributes classified as internal, PII or secret, as problem documents are
+// sent to clients.
+func DefaultProblemProfile() *xerrors.MarshalProfile {
+	profile := xerrors.DefaultMarshalProfile()
+	profile.OmitClassifications = []xerrors.Classification{
+		xerrors.ClassificationInternal, xerrors.ClassificationPII, xerrors.ClassificationSecret,
+	}
+	return profile
+}
+
+// WriteProblem writes the given error to the response as an RFC 9457 problem details document with the given HTTP
+// status code.
+//
+// The "type" member is the documentation URL of the error code in the given registry (see
+// [xerrors.Definition.DocsURL]) or "about:blank" if the registry is nil or has none.  The "title" is the default
+// message of the code, falling back to the status text, the "detail" is the error message and the error code is
+// written in the "code" extension member; the code and the detail are replaced by their translation if the profile
+// has a Translator.  The attributes of the error are prepared using the profile (see [xerrors.PrepareAttrs]) and added
+// as extension members, except those whose names collide with the other members, as is the [RetryHint] for errors
+// which can be retried.  Use [DecodeResponse] to reconstruct the error on the client.  The registered transformers
+// are applied to the error first (see [xerrors.Transform]).
+func WriteProblem(w http.ResponseWriter, status int, err xerrors.Error, registry *xerrors.Registry,
+	opts ...ProblemOption) {
+	p := &problemWriter{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.profile == nil {
+		p.profile = DefaultProblemProfile()
+	}
+
+	err = xerrors.Transform(err)
+	members := xerrors.PrepareAttrs(err, p.profile)
+	if members == nil {
+		members = map[string]any{}
+	}
+	problemType, title := "about:blank", http.StatusText(status)
+	if registry != nil {
+		if def, ok := registry.Lookup(err.Code()); ok {
+			if def.DocsURL != "" {
+				problemType = def.DocsURL
+			}
+			if def.Message != "" {
+				title = def.Message
+			}
+		}
+	}
+	members["type"] = problemType
+	members["title"] = title
+	members["status"] = status
+	members["detail"] = err.Error()
+	members["code"] = err.Code()
+	if p.profile.Translator != nil {
+		if translation, ok := p.profile.Translator.Translate(err); ok {
+			members["code"] = translation.Code
+			if translation.Message != "" {
+				members["detail"] = translation.Message
+			}
+		}
+	}
+	delete(members, RetryMember)
+
+	body, mErr := json.Marshal(members)
+	if mErr != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	body = appendRetryHint(body, err)
+	SetHeaders(w.Header(), err)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
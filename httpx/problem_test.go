@@ -0,0 +1,26 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+func TestWriteProblemOmitsClassifiedAttrsOfCauses(t *testing.T) {
+	cause := xerrors.New(2, "cause").WithClassifiedAttr("email", "alice@example.com", xerrors.ClassificationPII)
+	err := xerrors.New(1, "outer").WithCause("cause", cause)
+	err.WithAttr("self", err)
+
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, http.StatusBadRequest, err, nil)
+	body := rec.Body.String()
+	if strings.Contains(body, "alice@example.com") {
+		t.Errorf("the classified attribute of the cause was not omitted: %s", body)
+	}
+	if !strings.Contains(body, `"message":"cause"`) || !strings.Contains(body, `"self":"`+xerrors.TruncationMarker+`"`) {
+		t.Errorf("unexpected problem document: %s", body)
+	}
+}
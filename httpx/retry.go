@@ -0,0 +1,98 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// RetryMember is the name of the member of the error document holding the [RetryHint].
+	RetryMember = "retry"
+)
+
+// RetryHint is the backoff hint added to the error documents written by [WriteError] for errors which can be retried,
+// so that clients can implement polite retries without parsing the Retry-After header.
+type RetryHint struct {
+	// Retryable is true if the operation which failed can be retried.
+	Retryable bool `json:"retryable"`
+
+	// AfterMillis is the number of milliseconds the client should wait before retrying or 0 if no delay was given.
+	AfterMillis int64 `json:"afterMs,omitempty"`
+}
+
+// newRetryHint returns the backoff hint for the given error.
+//
+// The second return value is false if the error cannot be retried and has no retry delay.
+func newRetryHint(err xerrors.Error) (RetryHint, bool) {
+	delay, hasDelay := xerrors.RetryAfter(err)
+	retryable := xerrors.IsRetryable(err)
+	if !retryable && !hasDelay {
+		return RetryHint{}, false
+	}
+	return RetryHint{
+		Retryable:   retryable,
+		AfterMillis: delay.Milliseconds(),
+	}, true
+}
+
+// appendRetryHint adds the backoff hint for the error to the given JSON object, if the error can be retried.
+//
+// Any member of the object with the same name, eg: a "retry" attribute of an error whose marshal profile flattens the
+// attributes (see [xerrors.MarshalProfile]), is removed first so that the document does not contain the member twice.
+func appendRetryHint(body []byte, err xerrors.Error) []byte {
+	hint, ok := newRetryHint(err)
+	if !ok || len(body) < 2 || body[len(body)-1] != '}' {
+		return body
+	}
+	if bytes.Contains(body, []byte(strconv.Quote(RetryMember)+":")) {
+		body = removeMember(body, RetryMember)
+	}
+	body = body[:len(body)-1]
+	if len(body) > 1 {
+		body = append(body, ',')
+	}
+	body = append(body, strconv.Quote(RetryMember)...)
+	body = append(body, `:{"retryable":`...)
+	body = strconv.AppendBool(body, hint.Retryable)
+	if hint.AfterMillis > 0 {
+		body = append(body, `,"afterMs":`...)
+		body = strconv.AppendInt(body, hint.AfterMillis, 10)
+	}
+	return append(body, "}}"...)
+}
+
+// removeMember returns the given JSON object without the member with the given name.
+//
+// The documents of errors are written with their members in sorted order, so re-encoding the object keeps it
+// unchanged otherwise.  The object is returned as is if it cannot be decoded or has no such member.
+func removeMember(body []byte, name string) []byte {
+	var members map[string]json.RawMessage
+	if json.Unmarshal(body, &members) != nil {
+		return body
+	}
+	if _, ok := members[name]; !ok {
+		return body
+	}
+	delete(members, name)
+	data, err := json.Marshal(members)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// applyRetryHint restores the backoff hint from the given member of an error document onto the error.
+func applyRetryHint(xerr xerrors.Error, member json.RawMessage) {
+	var hint RetryHint
+	if len(member) == 0 || json.Unmarshal(member, &hint) != nil {
+		return
+	}
+	xerr.WithRetryable(hint.Retryable)
+	if hint.AfterMillis > 0 {
+		xerr.WithRetryAfter(time.Duration(hint.AfterMillis) * time.Millisecond)
+	}
+}
@@ -0,0 +1,61 @@
+package httpx
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+func TestAppendRetryHint(t *testing.T) {
+	retryable := xerrors.New(1, "unavailable").WithRetryable(true)
+	delayed := xerrors.New(1, "throttled").WithRetryAfter(1500 * time.Millisecond)
+	tests := []struct {
+		body string
+		err  xerrors.Error
+		want string
+	}{
+		{body: `{}`, err: retryable, want: `{"retry":{"retryable":true}}`},
+		{body: `{"code":1}`, err: delayed, want: `{"code":1,"retry":{"retryable":true,"afterMs":1500}}`},
+		{body: `{"code":1}`, err: xerrors.New(1, "failed"), want: `{"code":1}`},
+		{body: `{"code":1}`, err: xerrors.New(1, "failed").WithRetryable(false), want: `{"code":1}`},
+		{body: `[1]`, err: retryable, want: `[1]`},
+		{body: ``, err: retryable, want: ``},
+		{
+			body: `{"code":1,"retry":"soon"}`,
+			err:  retryable,
+			want: `{"code":1,"retry":{"retryable":true}}`,
+		},
+		{
+			body: `{"attrs":{"retry":"soon"},"code":1}`,
+			err:  retryable,
+			want: `{"attrs":{"retry":"soon"},"code":1,"retry":{"retryable":true}}`,
+		},
+	}
+	for _, test := range tests {
+		if got := appendRetryHint([]byte(test.body), test.err); string(got) != test.want {
+			t.Errorf("appendRetryHint(%s) = %s, want %s", test.body, got, test.want)
+		}
+	}
+}
+
+func TestWriteErrorReplacesFlattenedRetryAttr(t *testing.T) {
+	factory := xerrors.NewFactory(xerrors.WithMarshalProfile(&xerrors.MarshalProfile{FlattenAttrs: true}))
+	err := factory.New(1, "unavailable").WithAttr(RetryMember, "soon").WithRetryable(true)
+	rec := httptest.NewRecorder()
+	WriteError(rec, http.StatusServiceUnavailable, err)
+
+	body := rec.Body.Bytes()
+	if n := bytes.Count(body, []byte(`"retry":`)); n != 1 {
+		t.Fatalf("the document contains %d retry members: %s", n, body)
+	}
+	if !bytes.Contains(body, []byte(`"retry":{"retryable":true}`)) {
+		t.Errorf("the retry hint is missing: %s", body)
+	}
+	if decoded := DecodeResponse(rec.Result()); !decoded.Retryable() || decoded.Code() != 1 {
+		t.Errorf("unexpected decoded error: %v", decoded)
+	}
+}
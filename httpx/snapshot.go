@@ -0,0 +1,250 @@
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// DefaultSnapshotBodyLimit is the default maximum number of bytes of the body captured in a response snapshot.
+	DefaultSnapshotBodyLimit = 1024
+
+	// RedactedValue replaces the values of sensitive headers in a snapshot.
+	RedactedValue = "[REDACTED]"
+
+	// RequestAttr is the name of the attribute holding the snapshot of a request.
+	RequestAttr = "request"
+
+	// ResponseAttr is the name of the attribute holding the snapshot of a response.
+	ResponseAttr = "response"
+)
+
+var (
+	_defaultSnapshotHeaders = []string{
+		"Accept", "Authorization", "Content-Length", "Content-Type", "Cookie", "Location", "Proxy-Authorization",
+		"Retry-After", "Set-Cookie", "User-Agent", "X-Request-Id",
+	}
+	_sensitiveParams = map[string]struct{}{
+		"access_token":         {},
+		"api_key":              {},
+		"apikey":               {},
+		"client_secret":        {},
+		"code":                 {},
+		"id_token":             {},
+		"key":                  {},
+		"password":             {},
+		"refresh_token":        {},
+		"secret":               {},
+		"sig":                  {},
+		"signature":            {},
+		"token":                {},
+		"x-amz-credential":     {},
+		"x-amz-security-token": {},
+		"x-amz-signature":      {},
+	}
+	_sensitiveHeaders = map[string]struct{}{
+		"Authorization":       {},
+		"Cookie":              {},
+		"Proxy-Authorization": {},
+		"Set-Cookie":          {},
+		"X-Api-Key":           {},
+	}
+)
+
+// snapshotter holds the configuration of a snapshot.
+type snapshotter struct {
+	// unexported variables
+	bodyLimit    int                 // maximum number of body bytes captured
+	headers      []string            // names of the headers captured
+	redactParams map[string]struct{} // lowercase names of additional query parameters whose values are redacted
+}
+
+// SnapshotOption is a function which configures the snapshot taken by [WithHTTPRequest] or [WithHTTPResponse].
+type SnapshotOption func(*snapshotter)
+
+// WithSnapshotBodyLimit sets the maximum number of bytes of the body which are captured.  A limit of 0 leaves the body
+// out of the snapshot.
+//
+// The default is [DefaultSnapshotBodyLimit] for responses and 0 for requests, as request bodies often hold
+// credentials or personal data (eg: login forms) which are not redacted.
+func WithSnapshotBodyLimit(limit int) SnapshotOption {
+	return func(s *snapshotter) {
+		s.bodyLimit = max(limit, 0)
+	}
+}
+
+// WithSnapshotHeaders sets the names of the headers which are captured, replacing the default selection of common
+// headers.
+//
+// The values of the Authorization, Cookie, Proxy-Authorization, Set-Cookie and X-Api-Key headers are always replaced
+// with [RedactedValue].
+func WithSnapshotHeaders(names ...string) SnapshotOption {
+	return func(s *snapshotter) {
+		s.headers = names
+	}
+}
+
+// WithSnapshotRedactedParams adds the names of query parameters whose values are replaced with [RedactedValue] in
+// the URL of a request snapshot, eg: a parameter holding a signed token which is specific to an API.
+//
+// Names are matched case-insensitively.  The values of common credential parameters, such as access_token, api_key,
+// client_secret, code, password, signature and token, are always redacted.
+func WithSnapshotRedactedParams(names ...string) SnapshotOption {
+	return func(s *snapshotter) {
+		if s.redactParams == nil {
+			s.redactParams = make(map[string]struct{}, len(names))
+		}
+		for _, name := range names {
+			s.redactParams[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// newSnapshotter creates a new snapshotter with the given default body limit and options.
+func newSnapshotter(bodyLimit int, opts []SnapshotOption) *snapshotter {
+	s := &snapshotter{
+		bodyLimit: bodyLimit,
+		headers:   _defaultSnapshotHeaders,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithHTTPRequest adds a sanitized snapshot of the given outbound request to the error in the [RequestAttr] group and
+// returns the error, eg: when debugging a failed call to another service.
+//
+// The snapshot holds the method, the URL (with any password and the values of sensitive query parameters redacted, see
+// [WithSnapshotRedactedParams]) and the selected headers (with sensitive values redacted).
+//
+// The body is left out unless a limit is set using [WithSnapshotBodyLimit]: it is captured as-is, so it must not be
+// enabled for requests whose body may hold credentials or personal data.  It is also only captured if the request's
+// GetBody function is set, as it is for requests created by [http.NewRequest] with an in-memory body, so that the
+// request body is never consumed.
+func WithHTTPRequest(err xerrors.Error, req *http.Request, opts ...SnapshotOption) xerrors.Error {
+	s := newSnapshotter(0, opts)
+	snapshot := xerrors.AttrGroup{
+		"method": req.Method,
+	}
+	if req.URL != nil {
+		snapshot["url"] = s.snapshotURL(req.URL)
+	}
+	if headers := s.snapshotHeaders(req.Header); len(headers) > 0 {
+		snapshot["headers"] = headers
+	}
+	if s.bodyLimit > 0 && req.GetBody != nil {
+		if body, bErr := req.GetBody(); bErr == nil {
+			data, truncated := readLimited(body, s.bodyLimit)
+			body.Close()
+			if len(data) > 0 {
+				snapshot["body"] = bodyText(data, truncated)
+			}
+		}
+	}
+	return err.WithAttr(RequestAttr, snapshot)
+}
+
+// WithHTTPResponse adds a sanitized snapshot of the given response to the error in the [ResponseAttr] group and
+// returns the error, eg: when debugging a failed call to another service.
+//
+// The snapshot holds the status code, the selected headers (with sensitive values redacted) and the start of the
+// body.  The captured part of the body is put back in front of the remaining body, so the response can still be read
+// in full afterwards.
+func WithHTTPResponse(err xerrors.Error, resp *http.Response, opts ...SnapshotOption) xerrors.Error {
+	s := newSnapshotter(DefaultSnapshotBodyLimit, opts)
+	snapshot := xerrors.AttrGroup{
+		"status": resp.StatusCode,
+	}
+	if headers := s.snapshotHeaders(resp.Header); len(headers) > 0 {
+		snapshot["headers"] = headers
+	}
+	if s.bodyLimit > 0 && resp.Body != nil && resp.Body != http.NoBody {
+		data, truncated := readLimited(resp.Body, s.bodyLimit)
+		resp.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), Closer: resp.Body}
+		if len(data) > 0 {
+			snapshot["body"] = bodyText(data, truncated)
+		}
+	}
+	return err.WithAttr(ResponseAttr, snapshot)
+}
+
+// snapshotURL returns the URL with any password and the values of sensitive query parameters redacted.
+//
+// The query is rewritten in place rather than re-encoded, so the order and the encoding of the other parameters are
+// kept.
+func (s *snapshotter) snapshotURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		rawName, _, _ := strings.Cut(param, "=")
+		name := rawName
+		if unescaped, err := url.QueryUnescape(rawName); err == nil {
+			name = unescaped
+		}
+		name = strings.ToLower(name)
+		_, sensitive := _sensitiveParams[name]
+		if _, ok := s.redactParams[name]; ok || sensitive {
+			params[i] = rawName + "=" + RedactedValue
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = strings.Join(params, "&")
+	return redacted.Redacted()
+}
+
+// snapshotHeaders returns the selected headers, with the values of sensitive headers redacted.
+func (s *snapshotter) snapshotHeaders(h http.Header) xerrors.AttrGroup {
+	headers := xerrors.AttrGroup{}
+	for _, name := range s.headers {
+		name = http.CanonicalHeaderKey(name)
+		values := h.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if _, ok := _sensitiveHeaders[name]; ok {
+			headers[name] = RedactedValue
+		} else {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+// replayBody is a response body which replays the captured part of the body before the rest of it.
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// readLimited reads up to limit bytes from the reader and reports whether there was more data to read.
+//
+// Up to limit+1 bytes are read, so that the extra byte must be replayed by the caller if the reader is kept.
+func readLimited(r io.Reader, limit int) ([]byte, bool) {
+	data, _ := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	return data, len(data) > limit
+}
+
+// bodyText returns the captured body as a string, cut short to the limit and ending with the
+// [xerrors.TruncationMarker] if it was truncated.
+func bodyText(data []byte, truncated bool) string {
+	if truncated {
+		data = data[:len(data)-1]
+		for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+			if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size > 1 {
+				break
+			}
+			data = data[:len(data)-1]
+		}
+		return string(data) + xerrors.TruncationMarker
+	}
+	return string(data)
+}
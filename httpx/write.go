@@ -0,0 +1,51 @@
+package httpx
+
+import (
+	"net/http"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// WriteEncoded writes the given error to the response with the given HTTP status code, encoded using the encoder
+// registered with the given name (see [xerrors.RegisterEncoder]).
+//
+// The registered transformers are applied to the error first (see [xerrors.Transform]).  The headers set by
+// [SetHeaders] are also written.  If there is no such encoder or the error cannot be encoded, the
+// error message is written as plain text instead.
+func WriteEncoded(w http.ResponseWriter, status int, err xerrors.Error, encoder string) {
+	err = xerrors.Transform(err)
+	enc, ok := xerrors.LookupEncoder(encoder)
+	if !ok {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	body, eErr := enc.Encode(err)
+	if eErr != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	SetHeaders(w.Header(), err)
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// WriteError writes the given error to the response as a JSON document with the given HTTP status code.
+//
+// The registered transformers are applied to the error first (see [xerrors.Transform]).  The headers set by
+// [SetHeaders] are also written so that clients can identify the error (including its unique ID,
+// if there is one) without parsing the body.  If the error can be retried (see [xerrors.IsRetryable]), a [RetryHint]
+// is added to the document in the [RetryMember] member.  Use [DecodeResponse] to reconstruct the error on the client.
+func WriteError(w http.ResponseWriter, status int, err xerrors.Error) {
+	err = xerrors.Transform(err)
+	body, mErr := err.MarshalJSON()
+	if mErr != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	body = appendRetryHint(body, err)
+	SetHeaders(w.Header(), err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
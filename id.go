@@ -0,0 +1,67 @@
+package xerrors
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	_crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// IDGenerator is a function which generates a unique ID for a new [Error].
+type IDGenerator func() string
+
+// SetIDGenerator sets the function used to generate a unique ID for each new error so that a user-reported error
+// can be correlated with internal logs.
+//
+// Passing nil (the default) disables ID generation.  Individual factories can override this setting using
+// [WithIDGenerator].
+//
+// This function affects all errors generated globally by this package.  This call is thread-safe.
+func SetIDGenerator(gen IDGenerator) {
+	UpdateConfig(func(c *Config) {
+		c.IDGenerator = gen
+	})
+}
+
+// UUID generates a random (version 4) UUID.  It can be used as an [IDGenerator].
+func UUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// ULID generates a Universally Unique Lexicographically Sortable Identifier from the current time and random data.
+// It can be used as an [IDGenerator].
+func ULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[0:8], uint64(time.Now().UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	// encode the 128 bits as 26 Crockford base32 characters, the first of which holds the 3 most significant bits
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	var buf [26]byte
+	for i := 25; i >= 0; i-- {
+		buf[i] = _crockfordAlphabet[lo&0x1f]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(buf[:])
+}
@@ -0,0 +1,156 @@
+// Package document converts [xerrors.Error] objects into the representation shared by the modules which encode
+// errors in binary formats, eg: CBOR and MessagePack.
+package document
+
+import (
+	"go.innotegrity.dev/xerrors"
+)
+
+// Caller is the representation of [xerrors.CallerInfo].
+type Caller struct {
+	// File is the name of the file in which the error occurred.
+	File string `cbor:"file" msgpack:"file"`
+
+	// Line is the line number at which the error occurred.
+	Line int `cbor:"line" msgpack:"line"`
+
+	// Func is the name of the function in which the error occurred.
+	Func string `cbor:"func" msgpack:"func"`
+
+	// Package is the import path of the package containing the function.
+	Package string `cbor:"package,omitempty" msgpack:"package,omitempty"`
+
+	// Receiver is the receiver type of the method, if any.
+	Receiver string `cbor:"receiver,omitempty" msgpack:"receiver,omitempty"`
+
+	// Synthetic is true if the frame does not correspond to a location in the source code.
+	Synthetic bool `cbor:"synthetic,omitempty" msgpack:"synthetic,omitempty"`
+}
+
+// Document is the representation of an error.
+type Document struct {
+	// Attrs is a map of attributes associated with the error.
+	Attrs map[string]any `cbor:"attrs,omitempty" msgpack:"attrs,omitempty"`
+
+	// Caller contains the information on where the error was generated.
+	Caller *Caller `cbor:"caller,omitempty" msgpack:"caller,omitempty"`
+
+	// Code is the error code.
+	Code int `cbor:"code" msgpack:"code"`
+
+	// Domain is the domain the error belongs to, if any.
+	Domain string `cbor:"domain,omitempty" msgpack:"domain,omitempty"`
+
+	// ID is the unique ID of the error, if any.
+	ID string `cbor:"id,omitempty" msgpack:"id,omitempty"`
+
+	// Kind is the broad category of the failure, if any.
+	Kind string `cbor:"kind,omitempty" msgpack:"kind,omitempty"`
+
+	// Message is the message of the error itself, without the messages of the errors it wraps.
+	Message string `cbor:"message" msgpack:"message"`
+
+	// Op is the name of the operation which failed, if any.
+	Op string `cbor:"op,omitempty" msgpack:"op,omitempty"`
+
+	// Severity is how serious the failure is, if known.
+	Severity string `cbor:"severity,omitempty" msgpack:"severity,omitempty"`
+
+	// Stack contains the stack frames captured when the error was generated, if any.
+	Stack []Caller `cbor:"stack,omitempty" msgpack:"stack,omitempty"`
+
+	// WrappedError is the wrapped error, if any.
+	WrappedError *Document `cbor:"wrappedError,omitempty" msgpack:"wrappedError,omitempty"`
+}
+
+// New converts the given error and its wrapped errors into a document.
+//
+// Errors which do not implement [xerrors.Error] only include their message, which is expected to contain the messages
+// of the errors they wrap.  The attributes of each error are prepared using the marshal profile of the factory which
+// created it (see [xerrors.PrepareAttrs]), with the errors stored as attribute values, eg: secondary causes, converted
+// into documents which do not include the errors they wrap, as in the JSON output.  If the chain is too deep or
+// contains a cycle, the innermost document only contains the [xerrors.TruncationMarker] as its message.
+func New(err error) *Document {
+	chain, truncated := xerrors.Chain(err)
+	var root *Document
+	next := &root
+	for _, err := range chain {
+		xerr, ok := err.(xerrors.Error)
+		if !ok {
+			*next = &Document{
+				Message: err.Error(),
+			}
+			return root
+		}
+		doc := newLayer(xerr, xerrors.PrepareAttrs(xerr, nil))
+		*next = doc
+		next = &doc.WrappedError
+	}
+	if truncated {
+		*next = &Document{
+			Message: xerrors.TruncationMarker,
+		}
+	}
+	return root
+}
+
+// newLayer converts the given error, without the errors it wraps, into a document with the given prepared attributes.
+func newLayer(err xerrors.Error, attrs map[string]any) *Document {
+	doc := &Document{
+		Attrs:    convertAttrs(attrs),
+		Code:     err.Code(),
+		Domain:   err.Domain(),
+		ID:       err.ID(),
+		Kind:     string(err.Kind()),
+		Message:  err.Message(),
+		Op:       err.Op(),
+		Severity: err.Severity().String(),
+	}
+	if info := err.Caller(); info != *xerrors.DefaultCallerInfo() {
+		doc.Caller = newCaller(info)
+	}
+	for _, frame := range err.StackTrace() {
+		doc.Stack = append(doc.Stack, *newCaller(frame))
+	}
+	return doc
+}
+
+// convertAttrs converts the errors in the given prepared attributes into documents in place.
+func convertAttrs(attrs map[string]any) map[string]any {
+	for k, v := range attrs {
+		attrs[k] = convertValue(v)
+	}
+	return attrs
+}
+
+// convertValue converts the errors in the given prepared attribute value into documents.
+//
+// Causes prepared by [xerrors.PrepareAttrs] are protected against cycles, so other errors are not converted along with
+// their attributes.
+func convertValue(value any) any {
+	switch v := value.(type) {
+	case xerrors.AttrGroup:
+		convertAttrs(v)
+	case *xerrors.PreparedCause:
+		return newLayer(v.Err, v.Attrs)
+	case xerrors.Error:
+		return newLayer(v, nil)
+	case error:
+		return &Document{
+			Message: v.Error(),
+		}
+	}
+	return value
+}
+
+// newCaller converts the given caller information into its representation.
+func newCaller(info xerrors.CallerInfo) *Caller {
+	return &Caller{
+		File:      info.File,
+		Line:      info.Line,
+		Func:      info.Func,
+		Package:   info.Package,
+		Receiver:  info.Receiver,
+		Synthetic: info.Synthetic,
+	}
+}
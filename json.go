@@ -0,0 +1,527 @@
+package xerrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	_hex = "0123456789abcdef"
+)
+
+// jsonFieldType identifies the value written for a field of the JSON document.
+type jsonFieldType int
+
+const (
+	jsonAttr jsonFieldType = iota
+	jsonAttrs
+	jsonBuildID
+	jsonCaller
+	jsonCode
+	jsonDomain
+	jsonHints
+	jsonID
+	jsonKind
+	jsonMessage
+	jsonOp
+	jsonPosition
+	jsonSchema
+	jsonSeverity
+	jsonStack
+	jsonVersion
+	jsonWrappedError
+)
+
+// jsonField is a single field of the JSON document.
+type jsonField struct {
+	key   string        // name of the field
+	typ   jsonFieldType // type of value written for the field
+	value any           // attribute value for jsonAttr fields
+}
+
+// AppendJSON appends the JSON encoding of the error to dst and returns the extended buffer.
+//
+// The document is the same as the one produced by MarshalJSON but it is written directly into the buffer, so reusing a
+// buffer avoids the intermediate allocations made by [json.Marshal].  Only attribute values which are not strings,
+// booleans, integers or nil are encoded using [json.Marshal].  Attribute values which cannot be marshaled are
+// replaced with a placeholder, so encoding never fails.
+func (e *xerr) AppendJSON(dst []byte) ([]byte, error) {
+	return e.appendJSON(dst, e.profile.resolve())
+}
+
+// appendJSON appends the JSON encoding of the error to dst using the given resolved profile.
+//
+// Fields are written in sorted order, matching the output of [json.Marshal] for a map.
+func (e *xerr) appendJSON(dst []byte, profile *MarshalProfile) ([]byte, error) {
+	causes := newNesting(e, profile)
+	return e.appendNestedJSON(dst, &causes), nil
+}
+
+// appendNestedJSON appends the JSON encoding of the error to dst using the profile of the outermost error being
+// marshaled, which is also used for the causes stored in its attributes.
+func (e *xerr) appendNestedJSON(dst []byte, causes *nesting) []byte {
+	e.markInspected()
+	profile := causes.profile
+	var buf [16]jsonField
+	fields := buf[:0]
+	fields = append(fields, jsonField{key: profile.CodeField, typ: jsonCode})
+	fields = addJSONField(fields, profile.MessageField, jsonMessage)
+	var translation Translation
+	translated := false
+	code := e.code
+	if profile.Registry != nil {
+		code = profile.Registry.Current(code)
+	}
+	if profile.Translator != nil {
+		translation, translated = profile.Translator.translate(code)
+	}
+	if e.domain != "" {
+		fields = addJSONField(fields, profile.DomainField, jsonDomain)
+	}
+	if len(e.hints) > 0 {
+		fields = addJSONField(fields, profile.HintsField, jsonHints)
+	}
+	if e.id != "" {
+		fields = addJSONField(fields, profile.IDField, jsonID)
+	}
+	if e.kind != "" {
+		fields = addJSONField(fields, profile.KindField, jsonKind)
+	}
+	op := opPath(e)
+	if op != "" {
+		fields = addJSONField(fields, profile.OpField, jsonOp)
+	}
+	if e.position != nil {
+		fields = addJSONField(fields, profile.PositionField, jsonPosition)
+	}
+	if e.severity != SeverityUnknown {
+		fields = addJSONField(fields, profile.SeverityField, jsonSeverity)
+	}
+	if e.caller != nil && !profile.OmitCaller {
+		fields = addJSONField(fields, profile.CallerField, jsonCaller)
+	}
+	if len(e.stack) > 0 && !profile.OmitStack {
+		fields = addJSONField(fields, profile.StackField, jsonStack)
+	}
+	if profile.Version > 0 {
+		fields = addJSONField(fields, profile.VersionField, jsonVersion)
+	}
+	if profile.Symbolicate && (e.caller != nil || len(e.stack) > 0) {
+		if buildID := BuildID(); buildID != "" {
+			fields = addJSONField(fields, profile.BuildIDField, jsonBuildID)
+		}
+	}
+	if e.wrappedErr != nil && !profile.OmitWrappedError {
+		if _, ok := e.wrappedErr.(Error); !ok {
+			fields = addJSONField(fields, profile.WrappedErrorField, jsonWrappedError)
+		}
+	}
+	var attrBuf [16]jsonField
+	attrs := attrBuf[:0]
+	var schemas []jsonField
+	if len(e.attrs) > 0 && !profile.OmitAttrs {
+		var registered map[string]AttrSchema
+		if profile.AttrSchemas {
+			registered = AttrSchemas()
+		}
+		for k, v := range e.attrs {
+			v, ok := profile.prepareAttr(e.classes, k, v)
+			if !ok {
+				continue
+			}
+			schema, hasSchema := registered[k]
+			k = profile.KeyNormalizer.Normalize(k)
+			if hasSchema {
+				schemas = addJSONAttr(schemas, k, schema)
+			}
+			if profile.FlattenAttrs {
+				fields = addJSONAttr(fields, k, v)
+			} else {
+				attrs = addJSONAttr(attrs, k, v)
+			}
+		}
+		if !profile.FlattenAttrs && len(attrs) > 0 {
+			fields = addJSONField(fields, profile.AttrsField, jsonAttrs)
+		}
+		if len(schemas) > 0 {
+			fields = addJSONField(fields, profile.SchemaField, jsonSchema)
+		}
+	}
+
+	dst = append(dst, '{')
+	for i, field := range fields {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, field.key)
+		dst = append(dst, ':')
+		switch field.typ {
+		case jsonAttr:
+			dst = appendJSONValue(dst, field.value, causes)
+		case jsonAttrs:
+			dst = appendJSONObject(dst, attrs, causes)
+		case jsonBuildID:
+			dst = appendJSONString(dst, BuildID())
+		case jsonCaller:
+			dst = appendJSONFrame(dst, profile, e.caller)
+		case jsonCode:
+			if translated {
+				dst = appendJSONString(dst, translation.Code)
+			} else {
+				dst = Code(code).appendJSON(dst)
+			}
+		case jsonDomain:
+			dst = appendJSONString(dst, e.domain)
+		case jsonHints:
+			dst = append(dst, '[')
+			for i, hint := range e.hints {
+				if i > 0 {
+					dst = append(dst, ',')
+				}
+				dst = appendJSONString(dst, hint)
+			}
+			dst = append(dst, ']')
+		case jsonID:
+			dst = appendJSONString(dst, e.id)
+		case jsonKind:
+			dst = appendJSONString(dst, string(e.kind))
+		case jsonMessage:
+			if translated && translation.Message != "" {
+				dst = appendJSONString(dst, translation.Message)
+			} else {
+				dst = appendJSONString(dst, e.message)
+			}
+		case jsonOp:
+			dst = appendJSONString(dst, op)
+		case jsonPosition:
+			dst = appendJSONPosition(dst, e.position)
+		case jsonSchema:
+			dst = appendJSONObject(dst, schemas, causes)
+		case jsonSeverity:
+			dst = appendJSONString(dst, e.severity.String())
+		case jsonStack:
+			dst = append(dst, '[')
+			for i := range e.stack {
+				if i > 0 {
+					dst = append(dst, ',')
+				}
+				dst = appendJSONFrame(dst, profile, &e.stack[i])
+			}
+			dst = append(dst, ']')
+		case jsonVersion:
+			dst = strconv.AppendInt(dst, int64(profile.Version), 10)
+		case jsonWrappedError:
+			if multi, ok := e.wrappedErr.(*MultiError); ok {
+				dst = appendJSONMultiError(dst, multi)
+				break
+			}
+			dst = append(dst, `{"message":`...)
+			dst = appendJSONString(dst, e.wrappedErr.Error())
+			dst = append(dst, '}')
+		}
+	}
+	return append(dst, '}')
+}
+
+// appendJSONMultiError appends the document written by [MultiError.WriteJSON] to dst, so that the aggregated errors
+// are kept as an array.  If one of the errors cannot be marshaled, only the message of the aggregated errors is
+// appended instead.
+func appendJSONMultiError(dst []byte, m *MultiError) []byte {
+	buf := bytes.NewBuffer(dst)
+	if err := m.WriteJSON(buf); err != nil {
+		dst = append(dst, `{"message":`...)
+		dst = appendJSONString(dst, m.Error())
+		return append(dst, '}')
+	}
+	return buf.Bytes()
+}
+
+// addJSONField inserts the field into the sorted list of fields, replacing any field with the same key.
+func addJSONField(fields []jsonField, key string, typ jsonFieldType) []jsonField {
+	i := 0
+	for i < len(fields) && fields[i].key < key {
+		i++
+	}
+	if i < len(fields) && fields[i].key == key {
+		fields[i].typ = typ
+		return fields
+	}
+	fields = append(fields, jsonField{})
+	copy(fields[i+1:], fields[i:])
+	fields[i] = jsonField{key: key, typ: typ}
+	return fields
+}
+
+// addJSONAttr inserts the attribute into the sorted list of fields unless a field with the same key already exists.
+func addJSONAttr(fields []jsonField, key string, value any) []jsonField {
+	i := 0
+	for i < len(fields) && fields[i].key < key {
+		i++
+	}
+	if i < len(fields) && fields[i].key == key {
+		return fields
+	}
+	fields = append(fields, jsonField{})
+	copy(fields[i+1:], fields[i:])
+	fields[i] = jsonField{key: key, typ: jsonAttr, value: value}
+	return fields
+}
+
+// appendJSONCaller appends the JSON encoding of the caller information to dst.
+func appendJSONCaller(dst []byte, c *CallerInfo) []byte {
+	dst = append(dst, `{"file":`...)
+	dst = appendJSONString(dst, c.File)
+	dst = append(dst, `,"line":`...)
+	dst = strconv.AppendInt(dst, int64(c.Line), 10)
+	dst = append(dst, `,"func":`...)
+	dst = appendJSONString(dst, c.Func)
+	if c.Package != "" {
+		dst = append(dst, `,"package":`...)
+		dst = appendJSONString(dst, c.Package)
+	}
+	if c.Receiver != "" {
+		dst = append(dst, `,"receiver":`...)
+		dst = appendJSONString(dst, c.Receiver)
+	}
+	if c.Synthetic {
+		dst = append(dst, `,"synthetic":true`...)
+	}
+	return append(dst, '}')
+}
+
+// appendJSONFrame appends the JSON encoding of the caller information to dst in the form selected by the profile.
+func appendJSONFrame(dst []byte, profile *MarshalProfile, c *CallerInfo) []byte {
+	if profile.CompactCaller {
+		return appendJSONString(dst, c.Compact())
+	}
+	if profile.Symbolicate && c.Entry != 0 && c.PC >= c.Entry {
+		dst = appendJSONCaller(dst, c)
+		dst = append(dst[:len(dst)-1], `,"entry":"0x`...)
+		dst = strconv.AppendUint(dst, uint64(c.Entry), 16)
+		dst = append(dst, `","offset":`...)
+		dst = strconv.AppendUint(dst, uint64(c.PC-c.Entry), 10)
+		return append(dst, '}')
+	}
+	return appendJSONCaller(dst, c)
+}
+
+// appendJSONPosition appends the JSON encoding of the position to dst, leaving out the fields which are not known.
+func appendJSONPosition(dst []byte, p *Position) []byte {
+	dst = append(dst, '{')
+	if p.Column > 0 {
+		dst = append(dst, `"column":`...)
+		dst = strconv.AppendInt(dst, int64(p.Column), 10)
+	}
+	if p.File != "" {
+		if dst[len(dst)-1] != '{' {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `"file":`...)
+		dst = appendJSONString(dst, p.File)
+	}
+	if p.Line > 0 {
+		if dst[len(dst)-1] != '{' {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `"line":`...)
+		dst = strconv.AppendInt(dst, int64(p.Line), 10)
+	}
+	if p.Offset > 0 {
+		if dst[len(dst)-1] != '{' {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `"offset":`...)
+		dst = strconv.AppendInt(dst, p.Offset, 10)
+	}
+	return append(dst, '}')
+}
+
+// appendJSONObject appends the sorted list of attributes to dst as a JSON object.
+func appendJSONObject(dst []byte, attrs []jsonField, causes *nesting) []byte {
+	dst = append(dst, '{')
+	for i, attr := range attrs {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, attr.key)
+		dst = append(dst, ':')
+		dst = appendJSONValue(dst, attr.value, causes)
+	}
+	return append(dst, '}')
+}
+
+// appendJSONValue appends the JSON encoding of an attribute value to dst.
+//
+// Errors created by this package are encoded using the profile of the outermost error being marshaled (see
+// nesting) or, if causes is nil, as the outermost error using their own profile.  Values which cannot be
+// marshaled are replaced with a placeholder string containing their type and, unless the value contains a cycle,
+// their fmt "%v" form.
+func appendJSONValue(dst []byte, value any, causes *nesting) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case string:
+		return appendJSONString(dst, v)
+	case bool:
+		return strconv.AppendBool(dst, v)
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(dst, v, 10)
+	case AttrGroup:
+		return appendJSONMap(dst, value, v, causes)
+	case map[string]any:
+		if v == nil {
+			return append(dst, "null"...)
+		}
+		return appendJSONMap(dst, value, v, causes)
+	case []any:
+		// slices and maps are encoded here rather than by json.Marshal, so that the errors they hold are protected
+		// against cycles as well
+		if v == nil {
+			return append(dst, "null"...)
+		}
+		if causes == nil {
+			n := newNesting(nil, nil)
+			causes = &n
+		}
+		if !causes.enterContainer(v) {
+			return appendJSONString(dst, unmarshalablePlaceholder(value, errCycle))
+		}
+		dst = append(dst, '[')
+		for i, item := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONValue(dst, item, causes)
+		}
+		causes.leaveContainer()
+		return append(dst, ']')
+	case *xerr:
+		if causes == nil || causes.profile == nil {
+			data, _ := v.appendJSON(dst, v.profile.resolve())
+			return data
+		}
+		if !causes.enter(v) {
+			return appendJSONString(dst, TruncationMarker)
+		}
+		dst = v.appendNestedJSON(dst, causes)
+		causes.leave()
+		return dst
+	case Error:
+		if data, err := v.AppendJSON(dst); err == nil {
+			return data
+		}
+	case error:
+		dst = append(dst, `{"message":`...)
+		dst = appendJSONString(dst, v.Error())
+		return append(dst, '}')
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return appendJSONString(dst, unmarshalablePlaceholder(value, err))
+	}
+	return append(dst, data...)
+}
+
+// appendJSONMap appends the map to dst as a JSON object with its keys in sorted order.  The value is the map before
+// its conversion, which is used in the placeholder of a map which contains itself.
+func appendJSONMap(dst []byte, value any, m map[string]any, causes *nesting) []byte {
+	if causes == nil {
+		n := newNesting(nil, nil)
+		causes = &n
+	}
+	if !causes.enterContainer(m) {
+		return appendJSONString(dst, unmarshalablePlaceholder(value, errCycle))
+	}
+	dst = append(dst, '{')
+	for i, k := range slices.Sorted(maps.Keys(m)) {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, k)
+		dst = append(dst, ':')
+		dst = appendJSONValue(dst, m[k], causes)
+	}
+	causes.leaveContainer()
+	return append(dst, '}')
+}
+
+// errCycle is the error used in the placeholder of a map or slice which contains itself.
+var errCycle = &json.UnsupportedValueError{Str: "encountered a cycle"}
+
+// unmarshalablePlaceholder returns the placeholder used in place of a value which could not be marshaled.
+func unmarshalablePlaceholder(value any, err error) string {
+	var unsupported *json.UnsupportedValueError
+	if errors.As(err, &unsupported) && strings.Contains(unsupported.Str, "cycle") {
+		return fmt.Sprintf("!UNMARSHALABLE(%T)", value)
+	}
+	return fmt.Sprintf("!UNMARSHALABLE(%T): %v", value, value)
+}
+
+// appendJSONString appends the string to dst as a quoted JSON string, escaped in the same way as [json.Marshal].
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', _hex[b>>4], _hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', _hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
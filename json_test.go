@@ -0,0 +1,87 @@
+package xerrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// canonicalJSON returns the document re-encoded using json.Marshal, which sorts the keys of objects.
+func canonicalJSON(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("invalid JSON %q: %v", data, err)
+	}
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to re-encode %q: %v", data, err)
+	}
+	return canonical
+}
+
+func FuzzAppendJSONString(f *testing.F) {
+	for _, seed := range []string{"", "plain", "quote \" and \\ backslash", "<html> & co", "\x00\x1f\b\f\n\r\t",
+		"  ", "invalid \xff utf-8", "emoji 🙂"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		want, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appendJSONString(nil, s); !bytes.Equal(got, want) {
+			t.Errorf("appendJSONString(%q) = %s, json.Marshal = %s", s, got, want)
+		}
+	})
+}
+
+func FuzzAppendJSONValue(f *testing.F) {
+	f.Add("key", "value", int64(-1), uint64(1), 1.5, true)
+	f.Add("<&>", "\x00\xff", int64(-9223372036854775808), uint64(18446744073709551615), 1e-300, false)
+	f.Fuzz(func(t *testing.T, key, s string, i int64, u uint64, fl float64, b bool) {
+		for _, value := range []any{nil, s, b, int(i), int32(i), i, uint(u), uint32(u), u, fl, []string{s},
+			map[string]any{key: s}, AttrGroup{key: AttrGroup{key: i}}, []any{s, i, nil, []any{b}}, []any(nil),
+			map[string]any(nil), map[string]any{key: []any{fl}}} {
+			got := appendJSONValue(nil, value, nil)
+			want, err := json.Marshal(value)
+			if err != nil {
+				if !json.Valid(got) {
+					t.Errorf("appendJSONValue(%#v) = %s is not valid JSON", value, got)
+				}
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("appendJSONValue(%#v) = %s, json.Marshal = %s", value, got, want)
+			}
+		}
+	})
+}
+
+func FuzzAppendJSON(f *testing.F) {
+	f.Add(1042, "quota exceeded", "user", "alice", "db", "op", "hint")
+	f.Add(-1, "\x00<>&\xff", "", " ", "group", "", "")
+	f.Fuzz(func(t *testing.T, code int, message, key, value, group, op, hint string) {
+		// invalid UTF-8 is replaced when the document is decoded, which changes the order of the keys
+		key, group = strings.ToValidUTF8(key, "?"), strings.ToValidUTF8(group, "?")
+		err := Wrap(code, New(code+1, value), message).WithAttr(key, value).WithAttr("n", code).
+			WithGroup(group).WithAttr(key, message).WithOp(op).WithHint(hint).WithPosition(code, code)
+		data, mErr := err.MarshalJSON()
+		if mErr != nil {
+			t.Fatal(mErr)
+		}
+		if canonical := canonicalJSON(t, data); !bytes.Equal(data, canonical) {
+			t.Errorf("encoder output differs from json.Marshal:\n got: %s\nwant: %s", data, canonical)
+		}
+		appended, aErr := err.AppendJSON([]byte("prefix"))
+		if aErr != nil {
+			t.Fatal(aErr)
+		}
+		if !bytes.Equal(appended[len("prefix"):], data) {
+			t.Errorf("AppendJSON differs from MarshalJSON:\n got: %s\nwant: %s", appended[len("prefix"):], data)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package xerrors
+
+import (
+	"encoding/json/jsontext"
+)
+
+// MarshalJSONTo marshals the error to the given encoder using the marshal profile of the factory which created it,
+// which lets the error be encoded directly by encoding/json/v2.
+//
+// The document is the same as the one produced by MarshalJSON, except that strings are escaped according to the
+// options of the encoder.  This method is only available when building with Go 1.27 or later and GOEXPERIMENT=jsonv2.
+func (e *xerr) MarshalJSONTo(enc *jsontext.Encoder) error {
+	data, err := e.appendJSON(enc.AvailableBuffer(), e.profile.resolve())
+	if err != nil {
+		return err
+	}
+	return enc.WriteValue(data)
+}
+
+// MarshalJSONTo marshals the aggregated errors to the given encoder, which lets them be encoded directly by
+// encoding/json/v2.
+//
+// The document is the same as the one produced by MarshalJSON, except that strings are escaped according to the
+// options of the encoder.  This method is only available when building with Go 1.27 or later and GOEXPERIMENT=jsonv2.
+func (m *MultiError) MarshalJSONTo(enc *jsontext.Encoder) error {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return enc.WriteValue(data)
+}
@@ -0,0 +1,132 @@
+package xerrors
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeyCase is the naming convention enforced on attribute keys by a [KeyNormalizer].
+type KeyCase int
+
+const (
+	// KeyCasePreserve leaves the case of the keys unchanged.
+	KeyCasePreserve KeyCase = iota
+
+	// KeyCaseSnake converts the keys to snake_case, eg: "userID" becomes "user_id".
+	KeyCaseSnake
+
+	// KeyCaseCamel converts the keys to camelCase, eg: "user_id" becomes "userId".
+	KeyCaseCamel
+)
+
+// KeyNormalizer rewrites attribute keys so that they follow a consistent policy, eg: to keep the field mappings of an
+// Elasticsearch index consistent across services.
+//
+// Disallowed characters are replaced first, then the case is converted and finally the key is cut short to the
+// maximum length.  A normalizer is applied either when attributes are added, using [SetKeyNormalizer], or when errors
+// are marshaled, by setting it on a [MarshalProfile].  If two keys normalize to the same key, only one of them is
+// kept.
+type KeyNormalizer struct {
+	// Case is the naming convention enforced on the keys.
+	Case KeyCase
+
+	// Disallowed holds the characters which are not allowed in keys.
+	Disallowed string
+
+	// Replacement replaces each disallowed character.  An empty replacement removes them.
+	Replacement string
+
+	// MaxLength is the maximum number of characters in a key or 0 for no limit.
+	MaxLength int
+}
+
+// SetKeyNormalizer sets the normalizer applied to the keys of the attributes (and the names of the groups) as they are
+// added to any error, or nil (the default) to leave the keys unchanged.
+//
+// Classified attributes are labeled using their normalized keys.  This call is thread-safe.
+func SetKeyNormalizer(normalizer *KeyNormalizer) {
+	UpdateConfig(func(c *Config) {
+		c.KeyNormalizer = normalizer
+	})
+}
+
+// Normalize returns the normalized form of the given key.
+//
+// A nil normalizer returns the key unchanged.
+func (n *KeyNormalizer) Normalize(key string) string {
+	if n == nil {
+		return key
+	}
+	if n.Disallowed != "" {
+		key = replaceDisallowed(key, n.Disallowed, n.Replacement)
+	}
+	switch n.Case {
+	case KeyCaseSnake:
+		key = strings.ToLower(strings.Join(keyWords(key), "_"))
+	case KeyCaseCamel:
+		words := keyWords(key)
+		for i, word := range words {
+			word = strings.ToLower(word)
+			if i > 0 {
+				r, size := utf8.DecodeRuneInString(word)
+				word = string(unicode.ToUpper(r)) + word[size:]
+			}
+			words[i] = word
+		}
+		key = strings.Join(words, "")
+	}
+	if n.MaxLength > 0 && utf8.RuneCountInString(key) > n.MaxLength {
+		key = string([]rune(key)[:n.MaxLength])
+	}
+	return key
+}
+
+// normalizeKey returns the key normalized using the normalizer set by SetKeyNormalizer, if any.
+func normalizeKey(key string) string {
+	return loadConfig().KeyNormalizer.Normalize(key)
+}
+
+// replaceDisallowed replaces each of the disallowed characters in the key with the replacement.
+func replaceDisallowed(key, disallowed, replacement string) string {
+	var sb strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(disallowed, r) {
+			sb.WriteString(replacement)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// keyWords splits the key into words at separators (any character which is not a letter or digit) and at case
+// changes, eg: "HTTPStatus_code" becomes "HTTP", "Status" and "code".
+func keyWords(key string) []string {
+	runes := []rune(key)
+	var words []string
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,15 @@
+package xerrors
+
+// Kind is a broad category of failure which is independent of the error code, eg: the operation timed out.
+type Kind string
+
+const (
+	// KindCanceled indicates that the operation was canceled.
+	KindCanceled Kind = "canceled"
+
+	// KindDeadlineExceeded indicates that the operation did not complete before its deadline.
+	KindDeadlineExceeded Kind = "deadline_exceeded"
+
+	// KindPanic indicates that the operation panicked.
+	KindPanic Kind = "panic"
+)
@@ -0,0 +1,158 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MarshalLogfmt marshals the given error to a single logfmt line, eg: code=1 message="not found" user=bob.
+//
+// The error's code, message, domain, hints, id, kind, op, position, severity, caller and non-[Error] wrapped error are
+// followed by its attributes in sorted order; attributes in groups use dotted keys (eg: db.query=...).  The field
+// names and omissions of the marshal profile of the factory which created the error are used.  Errors which were not
+// created by this package only include their code and message.
+func MarshalLogfmt(err Error) []byte {
+	xerr, ok := err.(*xerr)
+	if !ok {
+		dst := appendLogfmtPair(nil, "code", strconv.Itoa(err.Code()))
+		return appendLogfmtPair(dst, "message", err.Error())
+	}
+	return xerr.appendLogfmt(nil, xerr.profile.resolve())
+}
+
+// appendLogfmt appends the logfmt encoding of the error to dst using the given resolved profile.
+func (e *xerr) appendLogfmt(dst []byte, profile *MarshalProfile) []byte {
+	e.markInspected()
+	code := e.code
+	if profile.Registry != nil {
+		code = profile.Registry.Current(code)
+	}
+	codeStr := strconv.Itoa(code)
+	message := e.message
+	if profile.Translator != nil {
+		if translation, ok := profile.Translator.translate(code); ok {
+			codeStr = translation.Code
+			if translation.Message != "" {
+				message = translation.Message
+			}
+		}
+	}
+	dst = appendLogfmtPair(dst, profile.CodeField, codeStr)
+	dst = appendLogfmtPair(dst, profile.MessageField, message)
+	if e.domain != "" {
+		dst = appendLogfmtPair(dst, profile.DomainField, e.domain)
+	}
+	if len(e.hints) > 0 {
+		dst = appendLogfmtPair(dst, profile.HintsField, logfmtValue(e.hints))
+	}
+	if e.id != "" {
+		dst = appendLogfmtPair(dst, profile.IDField, e.id)
+	}
+	if e.kind != "" {
+		dst = appendLogfmtPair(dst, profile.KindField, string(e.kind))
+	}
+	if op := opPath(e); op != "" {
+		dst = appendLogfmtPair(dst, profile.OpField, op)
+	}
+	if e.position != nil {
+		dst = appendLogfmtPair(dst, profile.PositionField, e.position.String())
+	}
+	if e.severity != SeverityUnknown {
+		dst = appendLogfmtPair(dst, profile.SeverityField, e.severity.String())
+	}
+	if e.caller != nil && !profile.OmitCaller {
+		caller := e.caller.String()
+		if profile.CompactCaller {
+			caller = e.caller.Compact()
+		}
+		dst = appendLogfmtPair(dst, profile.CallerField, caller)
+	}
+	if e.wrappedErr != nil && !profile.OmitWrappedError {
+		if _, ok := e.wrappedErr.(Error); !ok {
+			dst = appendLogfmtPair(dst, profile.WrappedErrorField, e.wrappedErr.Error())
+		}
+	}
+	if len(e.attrs) > 0 && !profile.OmitAttrs {
+		dst = appendLogfmtAttrs(dst, profile, e.classes, e.attrs)
+	}
+	return dst
+}
+
+// appendLogfmtAttrs appends the attributes to dst in sorted order, using dotted keys for the attributes in groups.
+func appendLogfmtAttrs(dst []byte, profile *MarshalProfile, classes map[string]Classification,
+	attrs map[string]any) []byte {
+	visitAttrs(profile, classes, "", "", attrs, func(key string, value any) {
+		dst = appendLogfmtPair(dst, key, logfmtValue(value))
+	})
+	return dst
+}
+
+// visitAttrs calls fn for each of the attributes in sorted order, with the attributes in groups flattened using
+// dotted keys, eg: "db.query".
+//
+// The path is used to look up the classifications of the attributes, while the key prefix is the normalized form of
+// the path which is passed to fn.  The values are prepared using the profile and omitted attributes are skipped.
+func visitAttrs(profile *MarshalProfile, classes map[string]Classification, prefix, keyPrefix string,
+	attrs map[string]any, fn func(key string, value any)) {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		path := prefix + k
+		key := keyPrefix + profile.KeyNormalizer.Normalize(k)
+		if group, ok := attrs[k].(AttrGroup); ok {
+			if !profile.omitsClassification(classes[path]) {
+				visitAttrs(profile, classes, path+".", key+".", group, fn)
+			}
+			continue
+		}
+		if v, ok := profile.prepareAttr(classes, path, attrs[k]); ok {
+			fn(key, v)
+		}
+	}
+}
+
+// appendLogfmtPair appends a key=value pair to dst, quoting the value if necessary.
+func appendLogfmtPair(dst []byte, key, value string) []byte {
+	if len(dst) > 0 {
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, key...)
+	dst = append(dst, '=')
+	if value == "" || strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, func(r rune) bool {
+		return r < 0x20 || r == 0x7f || r == utf8.RuneError
+	}) >= 0 {
+		return strconv.AppendQuote(dst, value)
+	}
+	return append(dst, value...)
+}
+
+// logfmtValue formats an attribute value for logfmt.
+//
+// Strings, booleans and numbers are formatted as-is; any other value is formatted as compact JSON or, if it cannot
+// be marshaled, using the %v verb.
+func logfmtValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
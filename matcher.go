@@ -0,0 +1,74 @@
+package xerrors
+
+import (
+	"strconv"
+)
+
+// Matcher matches errors in a chain by their code or kind using the standard [errors.Is] and [errors.As] functions,
+// so that code which does not otherwise use this package can match its errors, eg:
+//
+//	if errors.Is(err, xerrors.CodeMatcher(1042)) {
+//		...
+//	}
+//
+//	m := xerrors.KindMatcher(xerrors.KindDeadlineExceeded)
+//	if errors.As(err, &m) {
+//		log.Printf("timed out after %v", m.Err.Attrs()[xerrors.ElapsedAttr])
+//	}
+//
+// When used with errors.As, the target is set to a copy of the matcher whose Err field is the first matching [Error]
+// in the chain, so matchers can be shared.
+type Matcher struct {
+	// Err is the first matching error in the chain once the matcher has been used with errors.As.
+	Err Error
+
+	// unexported variables
+	code    int  // the code to match
+	hasCode bool // whether or not the code is matched
+	kind    Kind // the kind to match or an empty string
+}
+
+// CodeMatcher returns a [Matcher] which matches errors with the given code.
+func CodeMatcher(code int) *Matcher {
+	return &Matcher{
+		code:    code,
+		hasCode: true,
+	}
+}
+
+// KindMatcher returns a [Matcher] which matches errors of the given kind.
+func KindMatcher(kind Kind) *Matcher {
+	return &Matcher{
+		kind: kind,
+	}
+}
+
+// Error returns a description of the errors matched by the matcher.
+func (m *Matcher) Error() string {
+	if m.hasCode {
+		return "error with code " + strconv.Itoa(m.code)
+	}
+	return "error of kind " + string(m.kind)
+}
+
+// matches returns true if the given error is matched by the matcher.
+func (m *Matcher) matches(err *xerr) bool {
+	if m.hasCode {
+		return err.code == m.code
+	}
+	return err.kind == m.kind
+}
+
+// As sets the target to a copy of the matcher holding the error if the target is a [Matcher] which matches the
+// error, so that matchers can be used with [errors.As].
+func (e *xerr) As(target any) bool {
+	m, ok := target.(**Matcher)
+	if !ok || *m == nil || !(*m).matches(e) {
+		return false
+	}
+	e.markInspected()
+	matched := **m
+	matched.Err = e
+	*m = &matched
+	return true
+}
@@ -0,0 +1,56 @@
+package xerrors
+
+import (
+	"errors"
+	"strings"
+)
+
+// Merge combines two errors, eg: when an operation fails and the cleanup after it fails as well and both failures
+// must be surfaced.
+//
+// The primary error keeps its code and message and gains the attributes (and their classifications) of the secondary
+// error which it does not already have.  The secondary error is joined to the primary's wrapped error using
+// [errors.Join], so [errors.Is] and [errors.As] match either cause.  The primary error is modified and returned; if
+// either error is nil, the other one is returned instead.
+//
+// Errors which were not created by this package cannot be modified, so a new error with the primary's code and
+// message is created which wraps both errors.
+func Merge(primary, secondary Error) Error {
+	if primary == nil {
+		return secondary
+	}
+	if secondary == nil {
+		return primary
+	}
+	markWrappedInspected(secondary)
+	p, ok := primary.(*xerr)
+	if !ok {
+		return newError(nil, nil, 0, primary.Code(), primary.Error(), errors.Join(primary, secondary)).
+			WithAttrs(secondary.Attrs()).WithAttrs(primary.Attrs())
+	}
+
+	merged := map[string]struct{}{}
+	for k, v := range secondary.Attrs() {
+		if _, exists := p.attrs[k]; exists {
+			continue
+		}
+		if p.attrs == nil {
+			p.attrs = make(map[string]any)
+		}
+		p.attrs[k] = v
+		merged[k] = struct{}{}
+	}
+	for path, class := range secondary.Classifications() {
+		key, _, _ := strings.Cut(path, ".")
+		if _, ok := merged[key]; !ok {
+			continue
+		}
+		ext := p.mutable()
+		if ext.classes == nil {
+			ext.classes = make(map[string]Classification)
+		}
+		ext.classes[path] = class
+	}
+	p.wrappedErr = errors.Join(p.wrappedErr, secondary)
+	return p
+}
@@ -0,0 +1,14 @@
+package xerrors
+
+// ComposeMessages controls whether the Error() method of newly generated errors includes the messages of the
+// wrapped errors, eg: "failed to load config: open config.yaml: no such file or directory".
+//
+// By default, Error() only returns the error's own message; the composed message is always available using the
+// FullMessage() method.  Individual factories can override this setting using [WithComposedMessages].
+//
+// This function enables or disables message composition globally for this package.  This call is thread-safe.
+func ComposeMessages(enable bool) {
+	UpdateConfig(func(c *Config) {
+		c.ComposeMessages = enable
+	})
+}
@@ -0,0 +1,12 @@
+module go.innotegrity.dev/xerrors/msgpack
+
+go 1.23
+
+replace go.innotegrity.dev/xerrors => ../
+
+require (
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	go.innotegrity.dev/xerrors v0.0.0-00010101000000-000000000000
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
@@ -0,0 +1,191 @@
+package xerrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MultiError aggregates multiple errors, eg: the failures of the individual records in a batch import.
+//
+// The errors are joined in the same way as [errors.Join], so [errors.Is] and [errors.As] match any of them.  A
+// MultiError is safe for concurrent use.
+type MultiError struct {
+	// unexported variables
+	errs  []error    // the aggregated errors
+	limit int        // maximum number of errors rendered or 0 for no limit
+	mutex sync.Mutex // guards errs
+}
+
+// MultiErrorOption is a function which configures a [MultiError].
+type MultiErrorOption func(*MultiError)
+
+// WithMaxRenderedErrors limits the number of errors rendered by Error(), MarshalJSON and WriteJSON.
+//
+// The remaining errors are replaced with a summary such as "…and 4,212 more errors".  A limit of 0 (the default)
+// renders every error.
+func WithMaxRenderedErrors(limit int) MultiErrorOption {
+	return func(m *MultiError) {
+		m.limit = max(limit, 0)
+	}
+}
+
+// NewMultiError creates a new, empty [MultiError] with the given options.
+func NewMultiError(opts ...MultiErrorOption) *MultiError {
+	m := &MultiError{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Add adds the given errors to the aggregate, ignoring any nil errors.
+func (m *MultiError) Add(errs ...error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, err := range errs {
+		if err != nil {
+			markWrappedInspected(err)
+			m.errs = append(m.errs, err)
+		}
+	}
+}
+
+// Error returns the messages of the aggregated errors, separated by "; ".
+func (m *MultiError) Error() string {
+	errs, omitted := m.rendered()
+	var sb strings.Builder
+	for i, err := range errs {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.Error())
+	}
+	if omitted > 0 {
+		sb.WriteString("; ")
+		sb.WriteString(omittedSummary(omitted))
+	}
+	return sb.String()
+}
+
+// ErrorOrNil returns the [MultiError] or nil if no errors have been added.
+func (m *MultiError) ErrorOrNil() error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Errors returns a copy of the aggregated errors.
+func (m *MultiError) Errors() []error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]error(nil), m.errs...)
+}
+
+// Len returns the number of aggregated errors.
+func (m *MultiError) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.errs)
+}
+
+// MarshalJSON marshals the aggregated errors to JSON.
+//
+// See WriteJSON for the format of the document.
+func (m *MultiError) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.WriteJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unwrap returns the aggregated errors.
+func (m *MultiError) Unwrap() []error {
+	return m.Errors()
+}
+
+// WriteJSON writes the aggregated errors to w as a JSON document, one error at a time, so that the whole document is
+// never held in memory.
+//
+// The document has the form {"errors":[...]}.  If the number of errors exceeds the limit set by
+// [WithMaxRenderedErrors], the document also contains the number of errors which were left out in the "omitted"
+// field and a summary in the "summary" field.  Errors which were not created by this package are written as
+// {"message":"..."}.
+func (m *MultiError) WriteJSON(w io.Writer) error {
+	errs, omitted := m.rendered()
+	buf := make([]byte, 0, 1024)
+	buf = append(buf, `{"errors":[`...)
+	for i, err := range errs {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		var mErr error
+		switch err := err.(type) {
+		case *xerr:
+			buf, mErr = err.AppendJSON(buf)
+		case json.Marshaler:
+			var data []byte
+			if data, mErr = err.MarshalJSON(); mErr == nil {
+				buf = append(buf, data...)
+			}
+		default:
+			buf = append(buf, `{"message":`...)
+			buf = appendJSONString(buf, err.Error())
+			buf = append(buf, '}')
+		}
+		if mErr != nil {
+			return mErr
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+	buf = append(buf, ']')
+	if omitted > 0 {
+		buf = append(buf, `,"omitted":`...)
+		buf = strconv.AppendInt(buf, int64(omitted), 10)
+		buf = append(buf, `,"summary":`...)
+		buf = appendJSONString(buf, omittedSummary(omitted))
+	}
+	buf = append(buf, '}')
+	_, err := w.Write(buf)
+	return err
+}
+
+// rendered returns the errors which should be rendered and the number of errors which were left out.
+func (m *MultiError) rendered() ([]error, int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.limit == 0 || len(m.errs) <= m.limit {
+		return m.errs[:len(m.errs):len(m.errs)], 0
+	}
+	return m.errs[:m.limit:m.limit], len(m.errs) - m.limit
+}
+
+// omittedSummary returns the summary used in place of the given number of errors which were left out.
+func omittedSummary(omitted int) string {
+	noun := "errors"
+	if omitted == 1 {
+		noun = "error"
+	}
+	return "…and " + formatCount(omitted) + " more " + noun
+}
+
+// formatCount formats the given count with thousands separators, eg: 4,212.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	var sb strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
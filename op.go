@@ -0,0 +1,33 @@
+package xerrors
+
+import (
+	"strings"
+)
+
+const (
+	// OpSeparator separates the operations in the breadcrumb path rendered for an error.
+	OpSeparator = " / "
+)
+
+// Ops returns the trail of operations recorded using WithOp on the errors in the chain of the given error, from the
+// outermost to the innermost, eg: ["svc.user.Create", "db.Insert"].
+//
+// If the chain is too deep or contains a cycle, the trail ends with the [TruncationMarker].
+func Ops(err error) []string {
+	var ops []string
+	truncated := walkChain(err, func(err error) bool {
+		if xerr, ok := err.(Error); ok && xerr.Op() != "" {
+			ops = append(ops, xerr.Op())
+		}
+		return true
+	})
+	if truncated {
+		ops = append(ops, TruncationMarker)
+	}
+	return ops
+}
+
+// opPath returns the breadcrumb path of the operations in the chain of the given error.
+func opPath(err error) string {
+	return strings.Join(Ops(err), OpSeparator)
+}
@@ -0,0 +1,199 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+const (
+	// OpenAPIErrorSchema is the name of the schema of the error document in the components produced by
+	// [OpenAPIComponents].
+	OpenAPIErrorSchema = "Error"
+)
+
+// OpenAPIComponents returns an OpenAPI 3.1 components object describing the JSON documents produced when an
+// [Error] is marshaled using the given profile (or the default profile if it is nil), so that API documentation stays
+// in sync with the actual serialization.
+//
+// The "schemas" member holds the [OpenAPIErrorSchema] schema along with the schemas it references.  The code property
+// is restricted to the codes defined in the registry, if one is given, and the "examples" member holds an example
+// payload for each definition, named after the definition (or "Error" followed by the code if it has no name).
+// Codes which the registry of the profile replaces are left out of the allowed codes.  The result can be marshaled
+// to JSON or YAML and merged into an API description, eg:
+//
+//	data, _ := json.MarshalIndent(map[string]any{"components": xerrors.OpenAPIComponents(registry, nil)}, "", "  ")
+func OpenAPIComponents(registry *Registry, profile *MarshalProfile) map[string]any {
+	profile = profile.resolve()
+	ref := func(name string) map[string]any {
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+
+	code := map[string]any{"type": "integer", "description": "The error code."}
+	var defs []Definition
+	if registry != nil {
+		defs = registry.Definitions()
+		codes := make([]int, 0, len(defs))
+		for _, def := range defs {
+			if profile.Registry == nil || profile.Registry.Current(def.Code) == def.Code {
+				codes = append(codes, def.Code)
+			}
+		}
+		if len(codes) > 0 {
+			code["enum"] = codes
+		}
+	}
+	if profile.Translator != nil {
+		code = map[string]any{
+			"description": "The error code or its published form.",
+			"oneOf":       []any{code, map[string]any{"type": "string"}},
+		}
+	}
+
+	frame := ref("Caller")
+	if profile.CompactCaller {
+		frame = map[string]any{"type": "string", "description": `The location in the form "file.go:123 pkg.Func".`}
+	}
+	properties := map[string]any{
+		profile.CodeField:    code,
+		profile.MessageField: map[string]any{"type": "string", "description": "The error message."},
+		profile.DomainField:  map[string]any{"type": "string", "description": "The domain the error belongs to."},
+		profile.HintsField: map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Suggested next steps for resolving the failure.",
+		},
+		profile.IDField:       map[string]any{"type": "string", "description": "The unique ID of the error."},
+		profile.KindField:     map[string]any{"type": "string", "description": "The broad category of the failure."},
+		profile.OpField:       map[string]any{"type": "string", "description": "The operations which failed."},
+		profile.PositionField: ref("Position"),
+		profile.SeverityField: map[string]any{
+			"type":        "string",
+			"enum":        []string{"debug", "info", "warning", "error", "critical"},
+			"description": "How serious the failure is.",
+		},
+	}
+	if !profile.OmitCaller {
+		properties[profile.CallerField] = frame
+	}
+	if !profile.OmitStack {
+		properties[profile.StackField] = map[string]any{"type": "array", "items": frame}
+	}
+	if profile.Symbolicate {
+		properties[profile.BuildIDField] = map[string]any{"type": "string", "description": "The build ID of the binary."}
+	}
+	if profile.Version > 0 {
+		properties[profile.VersionField] = map[string]any{"type": "integer", "const": profile.Version}
+	}
+	if !profile.OmitWrappedError {
+		properties[profile.WrappedErrorField] = map[string]any{
+			"description": "The wrapped error.",
+			"oneOf":       []any{ref(OpenAPIErrorSchema), ref("StandardError")},
+		}
+	}
+	errorSchema := map[string]any{
+		"type":       "object",
+		"required":   []string{profile.CodeField, profile.MessageField},
+		"properties": properties,
+	}
+	if !profile.OmitAttrs {
+		if profile.AttrSchemas {
+			properties[profile.SchemaField] = map[string]any{
+				"type":                 "object",
+				"additionalProperties": ref("AttrSchema"),
+				"description":          "The schemas of the attributes of the error by attribute name.",
+			}
+		}
+		if profile.FlattenAttrs {
+			errorSchema["additionalProperties"] = true
+		} else {
+			properties[profile.AttrsField] = map[string]any{
+				"type":                 "object",
+				"additionalProperties": true,
+				"description":          "The attributes of the error.",
+			}
+		}
+	}
+
+	schemas := map[string]any{
+		OpenAPIErrorSchema: errorSchema,
+		"AttrSchema": map[string]any{
+			"type":     "object",
+			"required": []string{"type"},
+			"properties": map[string]any{
+				"type": map[string]any{
+					"type": "string",
+					"enum": []AttrType{
+						AttrTypeBool, AttrTypeDuration, AttrTypeEnum, AttrTypeFloat, AttrTypeInt, AttrTypeString,
+						AttrTypeTime,
+					},
+				},
+				"values":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"description": map[string]any{"type": "string"},
+			},
+		},
+		"Position": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"file":   map[string]any{"type": "string"},
+				"line":   map[string]any{"type": "integer"},
+				"column": map[string]any{"type": "integer"},
+				"offset": map[string]any{"type": "integer"},
+			},
+		},
+		"StandardError": map[string]any{
+			"type":       "object",
+			"required":   []string{"message"},
+			"properties": map[string]any{"message": map[string]any{"type": "string"}},
+		},
+	}
+	if !profile.CompactCaller {
+		callerProperties := map[string]any{
+			"file":     map[string]any{"type": "string"},
+			"line":     map[string]any{"type": "integer"},
+			"func":     map[string]any{"type": "string"},
+			"package":  map[string]any{"type": "string"},
+			"receiver": map[string]any{"type": "string"},
+			"synthetic": map[string]any{
+				"type":        "boolean",
+				"description": "Whether the frame does not correspond to a location in the source code.",
+			},
+		}
+		if profile.Symbolicate {
+			callerProperties["entry"] = map[string]any{"type": "string", "description": "The function entry address."}
+			callerProperties["offset"] = map[string]any{"type": "integer", "description": "The offset from the entry."}
+		}
+		schemas["Caller"] = map[string]any{
+			"type":       "object",
+			"required":   []string{"file", "line", "func"},
+			"properties": callerProperties,
+		}
+	}
+	components := map[string]any{"schemas": schemas}
+	if len(defs) > 0 {
+		examples := make(map[string]any, len(defs))
+		for _, def := range defs {
+			name := def.Name
+			if name == "" {
+				name = "Error" + strconv.Itoa(def.Code)
+			}
+			message := def.Message
+			if message == "" {
+				message = name
+			}
+			payload, _ := (&xerr{xerrExt: _noExt, code: def.Code, message: message}).appendJSON(nil, profile)
+			example := map[string]any{
+				"summary": name,
+				"value":   json.RawMessage(payload),
+			}
+			if def.Deprecated {
+				example["summary"] = name + " (deprecated)"
+			}
+			if def.DocsURL != "" {
+				example["description"] = "See " + def.DocsURL
+			}
+			examples[name] = example
+		}
+		components["examples"] = examples
+	}
+	return components
+}
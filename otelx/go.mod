@@ -0,0 +1,12 @@
+module go.innotegrity.dev/xerrors/otelx
+
+go 1.23
+
+replace go.innotegrity.dev/xerrors => ../
+
+require (
+	go.innotegrity.dev/xerrors v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/metric v1.35.0
+	go.opentelemetry.io/otel/trace v1.35.0
+)
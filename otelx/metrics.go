@@ -0,0 +1,58 @@
+package otelx
+
+import (
+	"context"
+
+	"go.innotegrity.dev/xerrors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// ErrorsMetric is the name of the counter of created errors.
+	ErrorsMetric = "xerrors.errors"
+
+	// ElapsedMetric is the name of the histogram of the time elapsed before errors created with a start time (see
+	// [xerrors.WithStartTime]) occurred.
+	ElapsedMetric = "xerrors.error.elapsed"
+)
+
+// MetricsHook returns an [xerrors.Hook] which records every created error using instruments from the given meter.
+//
+// Each error increments the [ErrorsMetric] counter with the code, domain and kind of the error as metric attributes;
+// fields which have not been set are left out.  The severity is not recorded, as it is usually set using WithSeverity
+// after the error has been created.  Errors created with a done context and a start time (see [xerrors.Elapsed]) also
+// record the elapsed time in seconds in the [ElapsedMetric] histogram.  Like all hooks, it only sees the fields set
+// while the error is being created (eg: by a factory or context enricher).  Register the hook globally using
+// xerrors.RegisterHook.
+func MetricsHook(meter metric.Meter) (xerrors.Hook, error) {
+	counter, err := meter.Int64Counter(ErrorsMetric,
+		metric.WithDescription("Number of errors created."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+	elapsed, err := meter.Float64Histogram(ElapsedMetric,
+		metric.WithDescription("Time elapsed before the operation failed."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(xerr xerrors.Error) {
+		attrs := []attribute.KeyValue{
+			attribute.Int("code", xerr.Code()),
+		}
+		if domain := xerr.Domain(); domain != "" {
+			attrs = append(attrs, attribute.String("domain", domain))
+		}
+		if kind := xerr.Kind(); kind != "" {
+			attrs = append(attrs, attribute.String("kind", string(kind)))
+		}
+		set := metric.WithAttributes(attrs...)
+		counter.Add(context.Background(), 1, set)
+		if d, ok := xerrors.Elapsed(xerr); ok {
+			elapsed.Record(context.Background(), d.Seconds(), set)
+		}
+	}, nil
+}
@@ -0,0 +1,39 @@
+// Package otelx integrates [xerrors.Error] objects with OpenTelemetry.
+//
+// This package is distributed as a separate module so that the core xerrors module does not depend on
+// OpenTelemetry.
+package otelx
+
+import (
+	"context"
+
+	"go.innotegrity.dev/xerrors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// SpanIDAttr is the name of the attribute holding the span ID.
+	SpanIDAttr = "spanId"
+
+	// TraceIDAttr is the name of the attribute holding the trace ID.
+	TraceIDAttr = "traceId"
+)
+
+// TraceEnricher is an [xerrors.ContextEnricher] which adds the trace ID and span ID of the active span in the
+// context as attributes.
+//
+// Enable it globally using xerrors.RegisterContextEnricher(otelx.TraceEnricher) or for a single factory using the
+// xerrors.WithContextEnricher option.
+func TraceEnricher(ctx context.Context) map[string]any {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return map[string]any{
+		SpanIDAttr:  sc.SpanID().String(),
+		TraceIDAttr: sc.TraceID().String(),
+	}
+}
+
+// ensure TraceEnricher can be registered as a context enricher
+var _ xerrors.ContextEnricher = TraceEnricher
@@ -0,0 +1,110 @@
+package xerrors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const (
+	// _maxPanicFrames is the maximum number of frames searched for the location which panicked.
+	_maxPanicFrames = 16
+
+	// BadKeyAttr is the attribute key used for a value which was not preceded by a string key in a list of key-value
+	// pairs.
+	BadKeyAttr = "!BADKEY"
+
+	// PanicAttr is the attribute holding the original value of a recovered panic.
+	PanicAttr = "panic"
+)
+
+// Panic panics with a new [Error] with the given code, message and attributes.
+//
+// The attributes are given as alternating keys and values in the same way as [log/slog], eg: "user", id.  This is
+// intended for structured "impossible state" assertions which still carry a code and attributes when they are caught
+// at the top level using [Recover].
+func Panic(code int, message string, attrs ...any) {
+	xerr := newError(nil, nil, 0, code, message, nil)
+	if len(attrs) > 0 {
+		xerr.WithAttrs(argsToAttrs(attrs))
+	}
+	panic(Error(xerr))
+}
+
+// Recover recovers from a panic and stores it in the error pointed to by errp.
+//
+// It must be deferred directly, eg: defer xerrors.Recover(&err, 500).  A panic value which is an [Error] (eg: from
+// [Panic]) is stored intact.  Any other value is stored as a new [Error] with the given code and [KindPanic]: errors
+// are wrapped, while other values are kept in the [PanicAttr] attribute, and the caller information points at the
+// code which panicked, even if the panic was raised by the runtime (eg: a write to a nil map).  If there is no panic,
+// the error is left unchanged.
+func Recover(errp *error, code int) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if xerr, ok := r.(Error); ok {
+		*errp = xerr
+		return
+	}
+	var xerr *xerr
+	if err, ok := r.(error); ok {
+		xerr = newError(nil, nil, 1, code, "panic", err)
+	} else {
+		xerr = newError(nil, nil, 1, code, fmt.Sprintf("panic: %v", r), nil)
+		xerr.WithAttr(PanicAttr, r)
+	}
+	ext := xerr.mutable()
+	ext.kind = KindPanic
+	if ext.caller != nil || ext.site != nil {
+		site := panicSite(1)
+		if ext.caller != nil {
+			ext.caller = site
+		} else {
+			ext.site = site
+		}
+	}
+	*errp = xerr
+}
+
+// panicSite returns the location which panicked, skipping the frames of the runtime between the deferred call and
+// that location, eg: runtime.mapassign_faststr for a write to a nil map or runtime.sigpanic for a nil dereference.
+//
+// The 'skip' parameter indicates how many stack frames to ascend with 0 being the immediate caller of this function.
+func panicSite(skip int) *CallerInfo {
+	var pcs [_maxPanicFrames]uintptr
+	n := runtime.Callers(2+skip, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for n > 0 {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			return newCallerInfo(frame.Function, frame.File, frame.Line, frame.PC, frame.Entry)
+		}
+		if !more {
+			break
+		}
+	}
+	return DefaultCallerInfo()
+}
+
+// argsToAttrs converts a list of alternating keys and values into a map of attributes.
+//
+// A value which is not preceded by a string key is stored using the [BadKeyAttr] key.
+func argsToAttrs(args []any) map[string]any {
+	attrs := make(map[string]any, (len(args)+1)/2)
+	for len(args) > 0 {
+		key, ok := args[0].(string)
+		if !ok || len(args) == 1 {
+			if ok {
+				attrs[BadKeyAttr] = key
+			} else {
+				attrs[BadKeyAttr] = args[0]
+			}
+			args = args[1:]
+			continue
+		}
+		attrs[key] = args[1]
+		args = args[2:]
+	}
+	return attrs
+}
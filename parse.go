@@ -0,0 +1,161 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// jsonXErr is a version of [xerr] that is used to unmarshal the object from JSON.
+type jsonXErr struct {
+	// Attrs is a map of attributes associated with the error.
+	Attrs map[string]any `json:"attrs"`
+
+	// Caller contains the information on where the error was generated.
+	Caller *CallerInfo `json:"caller"`
+
+	// Code is the error code, either as a number or as its symbolic name (see MarshalSymbolicCodes).
+	Code Code `json:"code"`
+
+	// Domain is the domain the error belongs to.
+	Domain string `json:"domain"`
+
+	// Hints are the suggested next steps for resolving the failure.
+	Hints []string `json:"hints"`
+
+	// ID is the unique ID of the error.
+	ID string `json:"id"`
+
+	// Kind is the broad category of the failure.
+	Kind Kind `json:"kind"`
+
+	// Message is the error message.
+	Message *string `json:"message"`
+
+	// Op is the breadcrumb path of the operations in the error chain.
+	Op string `json:"op"`
+
+	// Position is the location in the input the error refers to.
+	Position *Position `json:"position"`
+
+	// Severity is how serious the failure is.
+	Severity Severity `json:"severity"`
+
+	// Stack contains the stack frames captured when the error was generated.
+	Stack []CallerInfo `json:"stack"`
+
+	// Version is the format version of the document or 0 for the unversioned format.
+	Version int `json:"version"`
+
+	// WrappedError is the wrapped error, if any.
+	WrappedError json.RawMessage `json:"wrappedError"`
+}
+
+// ParseJSON reconstructs an [Error] from a JSON document produced using [DefaultMarshalProfile], with or without a
+// version set.
+//
+// Documents in all of the supported formats, up to [LatestMarshalVersion], are accepted; an error is returned for
+// documents with a newer version.  Callers and stack frames may be in their compact form.  Wrapped errors which only
+// have a message are reconstructed as standard Go errors.  Hooks are not called for parsed errors.
+//
+// Documents compressed using [CompressJSON] (eg: by a [MarshalProfile] with CompressAbove set) are decompressed
+// transparently, provided that their compressor is registered.  An error is returned if the wrapped errors are nested
+// deeper than the maximum depth set by [SetMaxChainDepth].
+func ParseJSON(data []byte) (Error, error) {
+	data, err := DecompressJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	maxDepth := loadConfig().MaxChainDepth
+	xerr, err := parseJSON(data, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	return xerr, nil
+}
+
+// parseJSON reconstructs an [Error] from a JSON document, allowing up to depth levels of wrapped errors.
+func parseJSON(data []byte, depth int) (*xerr, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("invalid error document: wrapped errors are nested too deeply")
+	}
+	var doc jsonXErr
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version < 0 || doc.Version > LatestMarshalVersion {
+		return nil, fmt.Errorf("invalid error document: unsupported version %d", doc.Version)
+	}
+	if doc.Message == nil {
+		return nil, fmt.Errorf("invalid error document: missing message")
+	}
+
+	xerr := &xerr{
+		xerrExt: &xerrExt{
+			caller:   doc.Caller,
+			domain:   doc.Domain,
+			hints:    doc.Hints,
+			id:       doc.ID,
+			kind:     doc.Kind,
+			op:       doc.Op,
+			position: doc.Position,
+			severity: doc.Severity,
+			stack:    doc.Stack,
+		},
+		attrs:   doc.Attrs,
+		code:    int(doc.Code),
+		message: *doc.Message,
+	}
+	if len(doc.WrappedError) > 0 && string(doc.WrappedError) != "null" {
+		wrapped, err := parseWrappedJSON(doc.WrappedError, depth-1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wrapped error: %w", err)
+		}
+		xerr.wrappedErr = wrapped
+	}
+	return xerr, nil
+}
+
+// parseWrappedJSON reconstructs a wrapped error from its JSON document.
+func parseWrappedJSON(data []byte, depth int) (error, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["code"]; ok {
+		xerr, err := parseJSON(data, depth)
+		if err != nil {
+			return nil, err
+		}
+		return xerr, nil
+	}
+	if errs, ok := fields["errors"]; ok {
+		return parseMultiErrorJSON(errs, depth)
+	}
+
+	var std jsonStdError
+	if err := json.Unmarshal(data, &std); err != nil {
+		return nil, err
+	}
+	return errors.New(std.Message), nil
+}
+
+// parseMultiErrorJSON reconstructs a [MultiError] from the array of errors written by [MultiError.WriteJSON].
+func parseMultiErrorJSON(data []byte, depth int) (error, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("invalid error document: wrapped errors are nested too deeply")
+	}
+	var docs []json.RawMessage
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, err
+	}
+	multi := NewMultiError()
+	for i, doc := range docs {
+		err, pErr := parseWrappedJSON(doc, depth-1)
+		if pErr != nil {
+			return nil, fmt.Errorf("invalid aggregated error %d: %w", i, pErr)
+		}
+		multi.Add(err)
+	}
+	return multi, nil
+}
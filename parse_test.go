@@ -0,0 +1,33 @@
+package xerrors
+
+import (
+	"testing"
+)
+
+func FuzzParseJSON(f *testing.F) {
+	for _, seed := range SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"code":1,"message":"x","error":{"code":2,"message":"y","error":{"message":"z"}}}`))
+	f.Add([]byte(`{"code":"QuotaExceeded","message":"x"}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		err, pErr := ParseJSON(data)
+		if pErr != nil {
+			if err != nil {
+				t.Fatalf("ParseJSON returned both an error and a parse error: %v", pErr)
+			}
+			return
+		}
+		if err == nil {
+			t.Fatal("ParseJSON returned neither an error nor a parse error")
+		}
+		data, mErr := err.MarshalJSON()
+		if mErr != nil {
+			t.Fatalf("failed to marshal parsed error: %v", mErr)
+		}
+		if _, pErr := ParseJSON(data); pErr != nil {
+			t.Fatalf("failed to parse marshaled error %s: %v", data, pErr)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package xerrors
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// FailedAttr is the name of the attribute holding the number of items which failed in a partly successful
+	// operation.
+	FailedAttr = "failed"
+
+	// SucceededAttr is the name of the attribute holding the number of items which succeeded in a partly successful
+	// operation.
+	SucceededAttr = "succeeded"
+
+	// SucceededIDsAttr is the name of the attribute holding the IDs of the items which succeeded in a partly
+	// successful operation.
+	SucceededIDsAttr = "succeededIds"
+
+	// TotalAttr is the name of the attribute holding the total number of items in a partly successful operation.
+	TotalAttr = "total"
+)
+
+// PartialError represents an operation which partly succeeded, eg: a bulk API call in which N of M items failed.
+//
+// The failures of the individual items are aggregated in a [MultiError], so [errors.Is] and [errors.As] match any of
+// them.  A PartialError is safe for concurrent use.
+type PartialError struct {
+	// unexported variables
+	errs      *MultiError // failures of the items
+	failedIDs []string    // IDs of the failed items, in the same order as the failures
+	mutex     sync.Mutex  // guards the IDs
+	succeeded []string    // IDs of the items which succeeded
+	total     int         // total number of items or 0 to count the recorded items
+}
+
+// NewPartialError creates a new [PartialError] for an operation on the given total number of items.
+//
+// A total of 0 uses the number of items which have been recorded as succeeded or failed.  The options configure the
+// aggregated failures, eg: [WithMaxRenderedErrors].
+func NewPartialError(total int, opts ...MultiErrorOption) *PartialError {
+	return &PartialError{
+		errs:  NewMultiError(opts...),
+		total: max(total, 0),
+	}
+}
+
+// Fail records the failure of the item with the given ID.  Nil errors are ignored.
+func (p *PartialError) Fail(id string, err error) {
+	if err == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.failedIDs = append(p.failedIDs, id)
+	p.errs.Add(err)
+}
+
+// Succeed records the success of the items with the given IDs.
+func (p *PartialError) Succeed(ids ...string) {
+	p.mutex.Lock()
+	p.succeeded = append(p.succeeded, ids...)
+	p.mutex.Unlock()
+}
+
+// AsError returns an [Error] with the given code and message which wraps the partial error and holds its counts and
+// the IDs of the items which succeeded in the [TotalAttr], [SucceededAttr], [FailedAttr] and [SucceededIDsAttr]
+// attributes, or nil if no items failed.
+func (p *PartialError) AsError(code int, message string) Error {
+	if p.Failed() == 0 {
+		return nil
+	}
+	xerr := newError(nil, nil, 0, code, message, p)
+	xerr.WithAttrs(map[string]any{
+		FailedAttr:       p.Failed(),
+		SucceededAttr:    len(p.SucceededIDs()),
+		SucceededIDsAttr: p.SucceededIDs(),
+		TotalAttr:        p.Total(),
+	})
+	return xerr
+}
+
+// Error returns a summary of the counts followed by the messages of the failures, eg: "2 of 10 items failed: ...".
+func (p *PartialError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(formatCount(p.Failed()))
+	sb.WriteString(" of ")
+	sb.WriteString(formatCount(p.Total()))
+	sb.WriteString(" items failed")
+	if p.Failed() > 0 {
+		sb.WriteString(": ")
+		sb.WriteString(p.errs.Error())
+	}
+	return sb.String()
+}
+
+// ErrorOrNil returns the [PartialError] or nil if no items failed.
+func (p *PartialError) ErrorOrNil() error {
+	if p.Failed() == 0 {
+		return nil
+	}
+	return p
+}
+
+// Errors returns the failures of the items.
+func (p *PartialError) Errors() *MultiError {
+	return p.errs
+}
+
+// Failed returns the number of items which failed.
+func (p *PartialError) Failed() int {
+	return p.errs.Len()
+}
+
+// FailedIDs returns a copy of the IDs of the items which failed, in the same order as the failures.
+func (p *PartialError) FailedIDs() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.failedIDs...)
+}
+
+// MarshalJSON marshals the partial error to a JSON document suited to the responses of bulk APIs, eg:
+//
+//	{"total":3,"succeeded":1,"failed":2,"succeededIds":["a"],"errors":[{"id":"b","error":{...}},...]}
+//
+// Errors which were not created by this package are written as {"message":"..."}.  If the number of failures exceeds
+// the limit set by [WithMaxRenderedErrors], the document also contains the number of failures which were left out
+// in the "omitted" field.
+func (p *PartialError) MarshalJSON() ([]byte, error) {
+	p.mutex.Lock()
+	ids := append([]string(nil), p.failedIDs...)
+	succeeded := append([]string(nil), p.succeeded...)
+	errs, omitted := p.errs.rendered()
+	p.mutex.Unlock()
+
+	dst := append([]byte(nil), `{"total":`...)
+	dst = strconv.AppendInt(dst, int64(p.Total()), 10)
+	dst = append(dst, `,"succeeded":`...)
+	dst = strconv.AppendInt(dst, int64(len(succeeded)), 10)
+	dst = append(dst, `,"failed":`...)
+	dst = strconv.AppendInt(dst, int64(len(ids)), 10)
+	dst = append(dst, `,"succeededIds":[`...)
+	for i, id := range succeeded {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, id)
+	}
+	dst = append(dst, `],"errors":[`...)
+	for i, err := range errs {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `{"id":`...)
+		dst = appendJSONString(dst, ids[i])
+		dst = append(dst, `,"error":`...)
+		dst = appendJSONValue(dst, err, nil)
+		dst = append(dst, '}')
+	}
+	dst = append(dst, ']')
+	if omitted > 0 {
+		dst = append(dst, `,"omitted":`...)
+		dst = strconv.AppendInt(dst, int64(omitted), 10)
+	}
+	return append(dst, '}'), nil
+}
+
+// SucceededIDs returns a copy of the IDs of the items which succeeded.
+func (p *PartialError) SucceededIDs() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.succeeded...)
+}
+
+// Total returns the total number of items.
+func (p *PartialError) Total() int {
+	if p.total > 0 {
+		return p.total
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.succeeded) + len(p.failedIDs)
+}
+
+// Unwrap returns the failures of the items.
+func (p *PartialError) Unwrap() []error {
+	return p.errs.Errors()
+}
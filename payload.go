@@ -0,0 +1,44 @@
+package xerrors
+
+// WithPayload attaches a typed payload to the error, eg: a validation result or a partially built response, which
+// can be retrieved using [PayloadAs] without converting it to and from untyped attributes.
+//
+// If the error is an [Error], the payload is attached to it and the error is returned; otherwise it is wrapped in a
+// new [Error] with code 0 and the same message.  A nil error returns nil.  Payloads stay in the process: they are not
+// marshaled, so they are lost when the error is sent elsewhere.
+func WithPayload[T any](err error, payload T) Error {
+	if err == nil {
+		return nil
+	}
+	e, ok := err.(*xerr)
+	if !ok {
+		e = newError(nil, nil, 0, 0, err.Error(), err)
+	}
+	ext := e.mutable()
+	ext.payloads = append(ext.payloads, payload)
+	return e
+}
+
+// PayloadAs returns the first payload of type T attached using [WithPayload] to any error in the chain of the given
+// error, starting with the outermost error.  If T is an interface type, any payload which implements it matches.
+//
+// If several payloads of type T are attached to the same error, the most recently attached one is returned.  The
+// second return value is false if no payload of type T was found.
+func PayloadAs[T any](err error) (T, bool) {
+	var payload T
+	found := false
+	walkChain(err, func(err error) bool {
+		e, ok := err.(*xerr)
+		if !ok {
+			return true
+		}
+		for i := len(e.payloads) - 1; i >= 0; i-- {
+			if payload, found = e.payloads[i].(T); found {
+				e.markInspected()
+				return false
+			}
+		}
+		return true
+	})
+	return payload, found
+}
@@ -0,0 +1,135 @@
+package xerrors
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+const (
+	_shimStackDepth = 32
+)
+
+var (
+	_shimFactory      = NewFactory(WithComposedMessages(true))
+	_shimStackFactory = NewFactory(WithComposedMessages(true), WithStackDepth(_shimStackDepth))
+	_stackTracers     sync.Map // index of the StackTrace method of each error type or -1 if it has none
+)
+
+// Cause returns the innermost error in the chain of the given error, mirroring the function of the same name in
+// github.com/pkg/errors.
+func Cause(err error) error {
+	walkChain(err, func(e error) bool {
+		err = e
+		return true
+	})
+	return err
+}
+
+// Errorf creates a new [Error] with code 0 and the formatted message, capturing a stack trace.
+//
+// This mirrors the function of the same name in github.com/pkg/errors to ease migration; like the original, the %w
+// verb is not supported, so use [Wrapf] to wrap an error instead.
+func Errorf(format string, args ...any) error {
+	return newError(nil, _shimStackFactory, 0, 0, fmt.Sprintf(format, args...), nil)
+}
+
+// WithMessage wraps the given error in a new [Error] with code 0 and the given message, or returns nil if the error
+// is nil.
+//
+// This mirrors the function of the same name in github.com/pkg/errors to ease migration: the Error() method of the
+// returned error includes the messages of the wrapped errors.
+func WithMessage(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return newError(nil, _shimFactory, 0, 0, message, err)
+}
+
+// WithStack wraps the given error in a new [Error] with code 0 and no message of its own, capturing a stack trace, or
+// returns nil if the error is nil.
+//
+// This mirrors the function of the same name in github.com/pkg/errors to ease migration: the Error() method of the
+// returned error returns the message of the wrapped error.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return newError(nil, _shimStackFactory, 0, 0, "", err)
+}
+
+// causer is implemented by errors created using github.com/pkg/errors.
+type causer interface {
+	Cause() error
+}
+
+// unwrap returns the error wrapped by the given error using [errors.Unwrap], falling back to the Cause method used
+// by github.com/pkg/errors.
+func unwrap(err error) error {
+	if wrapped := errors.Unwrap(err); wrapped != nil {
+		return wrapped
+	}
+	if c, ok := err.(causer); ok {
+		return c.Cause()
+	}
+	return nil
+}
+
+// foreignStackTrace returns the stack trace of the innermost error in the chain which implements the StackTrace
+// method used by github.com/pkg/errors or nil if there is none.
+//
+// The method is detected using reflection since its return type is defined by github.com/pkg/errors: any method
+// named StackTrace which returns a slice of program counters is accepted.  The walk stops at the first [Error].  The
+// result of the detection is cached for each type, so that only errors which have the method pay for reflection.
+func foreignStackTrace(err error) []CallerInfo {
+	var pcs []uintptr
+	walkChain(err, func(err error) bool {
+		if _, ok := err.(Error); ok {
+			return false
+		}
+		index := stackTracerIndex(reflect.TypeOf(err))
+		if index < 0 {
+			return true
+		}
+		frames := reflect.ValueOf(err).Method(index).Call(nil)[0]
+		pcs = make([]uintptr, frames.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(frames.Index(i).Uint())
+		}
+		return true
+	})
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	stack := make([]CallerInfo, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, *newCallerInfo(frame.Function, frame.File, frame.Line, frame.PC, frame.Entry))
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// stackTracerIndex returns the index of the StackTrace method of the given type or -1 if it does not have a method
+// named StackTrace which returns a slice of program counters.
+func stackTracerIndex(typ reflect.Type) int {
+	if index, ok := _stackTracers.Load(typ); ok {
+		return index.(int)
+	}
+	index := -1
+	if method, ok := typ.MethodByName("StackTrace"); ok {
+		mtyp := method.Type
+		if mtyp.NumIn() == 1 && mtyp.NumOut() == 1 && mtyp.Out(0).Kind() == reflect.Slice &&
+			mtyp.Out(0).Elem().Kind() == reflect.Uintptr {
+			index = method.Index
+		}
+	}
+	_stackTracers.Store(typ, index)
+	return index
+}
@@ -0,0 +1,53 @@
+package xerrors
+
+import (
+	"strconv"
+)
+
+// Position identifies a location in an input, eg: the line and column of a syntax error in a configuration file or
+// the byte offset of a corrupt record in a stream.
+type Position struct {
+	// File is the name of the input, if known.
+	File string `json:"file,omitempty"`
+
+	// Line is the 1-based line number or 0 if it is not known.
+	Line int `json:"line,omitempty"`
+
+	// Column is the 1-based column number or 0 if it is not known.
+	Column int `json:"column,omitempty"`
+
+	// Offset is the 0-based byte offset in the input.
+	Offset int64 `json:"offset,omitempty"`
+}
+
+// String returns the position in the form used by compilers and editors, eg: "config.yaml:12:3".
+//
+// The column is left out if it is not known.  If the line is not known either, the offset is used instead, eg:
+// "data.bin (offset 1234)".
+func (p Position) String() string {
+	if p.Line > 0 {
+		s := p.File
+		if s != "" {
+			s += ":"
+		}
+		s += strconv.Itoa(p.Line)
+		if p.Column > 0 {
+			s += ":" + strconv.Itoa(p.Column)
+		}
+		return s
+	}
+	offset := "offset " + strconv.FormatInt(p.Offset, 10)
+	if p.File == "" {
+		return offset
+	}
+	return p.File + " (" + offset + ")"
+}
+
+// positionTarget returns the position of the error, allocating it if it has not been set.
+func (e *xerr) positionTarget() *Position {
+	ext := e.mutable()
+	if ext.position == nil {
+		ext.position = &Position{}
+	}
+	return ext.position
+}
@@ -0,0 +1,197 @@
+package xerrors
+
+const (
+	// MarshalVersion1 is the first versioned format of the documents produced when an [Error] is marshaled to JSON.
+	//
+	// It has the same shape as the unversioned format, with the addition of the version field.
+	MarshalVersion1 = 1
+
+	// LatestMarshalVersion is the most recent format of the documents produced when an [Error] is marshaled to JSON.
+	LatestMarshalVersion = MarshalVersion1
+)
+
+var (
+	_defaultProfile = DefaultMarshalProfile()
+)
+
+// MarshalProfile controls the names and shape of the fields produced when an [Error] is marshaled.
+//
+// Any field name which is left empty uses the name from [DefaultMarshalProfile].
+type MarshalProfile struct {
+	// AttrsField is the name of the field holding the error attributes.
+	AttrsField string
+
+	// BuildIDField is the name of the field holding the build ID of the binary when Symbolicate is set.
+	BuildIDField string
+
+	// CallerField is the name of the field holding the caller information.
+	CallerField string
+
+	// CodeField is the name of the field holding the error code.
+	CodeField string
+
+	// DomainField is the name of the field holding the error domain.
+	DomainField string
+
+	// HintsField is the name of the field holding the suggested next steps for resolving the failure.
+	HintsField string
+
+	// IDField is the name of the field holding the unique error ID.
+	IDField string
+
+	// KindField is the name of the field holding the error kind.
+	KindField string
+
+	// MessageField is the name of the field holding the error message.
+	MessageField string
+
+	// OpField is the name of the field holding the breadcrumb path of the operations in the error chain.
+	OpField string
+
+	// PositionField is the name of the field holding the location in the input the error refers to.
+	PositionField string
+
+	// SchemaField is the name of the field holding the schemas of the attributes when AttrSchemas is set.
+	SchemaField string
+
+	// SeverityField is the name of the field holding the error severity.
+	SeverityField string
+
+	// StackField is the name of the field holding the stack trace.
+	StackField string
+
+	// VersionField is the name of the field holding the format version of the document.
+	VersionField string
+
+	// WrappedErrorField is the name of the field holding the wrapped error.
+	WrappedErrorField string
+
+	// Version is the format version of the document (eg: [MarshalVersion1]), which is included in the document so
+	// that consumers can keep parsing older formats as the format evolves.  A version of 0 produces the original
+	// unversioned format without a version field.
+	Version int
+
+	// Registry replaces deprecated codes with the codes which replace them (see [Registry.Current]), if set.  The
+	// replacement happens before the Translator is applied.
+	Registry *Registry
+
+	// Translator rewrites the error code and message into their externally published forms, if set.
+	Translator *Translator
+
+	// AttrSchemas adds the schemas of the attributes in the document which have one registered (see
+	// [RegisterAttrSchema]) as an object keyed by the attribute names in the document, so that machine consumers can
+	// validate and parse the attribute values without knowing the types of the attributes in advance.
+	AttrSchemas bool
+
+	// CompressAbove compresses documents longer than the given number of bytes produced by MarshalJSON (see
+	// [CompressJSON]), which keeps errors with huge attributes within the size limits of message buses.  A limit of 0
+	// disables compression.  Documents produced by AppendJSON, or nested in other documents, are never compressed.
+	CompressAbove int
+
+	// Compression is the name of the compressor used when CompressAbove is set (see [RegisterCompressor]).
+	Compression string
+
+	// CompactCaller writes the caller information and stack frames as single strings of the form
+	// "file.go:123 pkg.Func" (see [CallerInfo.Compact]) instead of nested objects, which keeps the documents of
+	// high-volume services small.  The package and receiver of the frames are not included in this form.
+	CompactCaller bool
+
+	// FlattenAttrs places the attributes at the top level of the document instead of in a nested object.
+	//
+	// Attributes whose names collide with another field in the document are dropped.
+	FlattenAttrs bool
+
+	// KeyNormalizer rewrites the keys of the attributes (and the names of the groups) in the document, if set.
+	// Attributes are omitted due to their classification using their original keys.
+	KeyNormalizer *KeyNormalizer
+
+	// MaxAttrBytes limits the length of string and byte slice attribute values, including those nested in maps and
+	// slices.  Longer values are cut short and end with the [TruncationMarker].  A limit of 0 disables truncation.
+	MaxAttrBytes int
+
+	// MaxAttrEntries limits the number of entries in map and slice attribute values, including nested ones.  The
+	// entries of longer maps with the lowest keys are kept along with a [TruncationMarker] entry holding the number of
+	// dropped entries; longer slices end with the [TruncationMarker].  A limit of 0 disables truncation.
+	MaxAttrEntries int
+
+	// Symbolicate adds the build ID of the binary (see [BuildID]) to the document and the address of the function
+	// entry and the offset of the program counter from it to each caller and stack frame, so that the stacks of
+	// stripped binaries can be symbolicated offline (see [SymbolTable]).  It has no effect on frames in the compact
+	// form.
+	Symbolicate bool
+
+	// OmitClassifications removes the attributes labeled with any of the given classifications from the document.
+	OmitClassifications []Classification
+
+	// OmitAttrs removes the attributes from the document.
+	OmitAttrs bool
+
+	// OmitCaller removes the caller information from the document.
+	OmitCaller bool
+
+	// OmitStack removes the stack trace from the document.
+	OmitStack bool
+
+	// OmitWrappedError removes the wrapped error from the document.
+	OmitWrappedError bool
+}
+
+// DefaultMarshalProfile returns the profile used when no other profile has been configured.
+func DefaultMarshalProfile() *MarshalProfile {
+	return &MarshalProfile{
+		AttrsField:        "attrs",
+		BuildIDField:      "buildId",
+		CallerField:       "caller",
+		CodeField:         "code",
+		Compression:       CompressionGzip,
+		DomainField:       "domain",
+		HintsField:        "hints",
+		IDField:           "id",
+		KindField:         "kind",
+		MessageField:      "message",
+		OpField:           "op",
+		PositionField:     "position",
+		SchemaField:       "$schema",
+		SeverityField:     "severity",
+		StackField:        "stack",
+		VersionField:      "version",
+		WrappedErrorField: "wrappedError",
+	}
+}
+
+// fieldName returns the given name or the fallback name if it is empty.
+func fieldName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// resolve returns a copy of the profile with all empty field names replaced with their default names.
+//
+// The returned profile must not be modified.
+func (p *MarshalProfile) resolve() *MarshalProfile {
+	def := _defaultProfile
+	if p == nil {
+		return def
+	}
+	resolved := *p
+	resolved.AttrsField = fieldName(p.AttrsField, def.AttrsField)
+	resolved.BuildIDField = fieldName(p.BuildIDField, def.BuildIDField)
+	resolved.CallerField = fieldName(p.CallerField, def.CallerField)
+	resolved.CodeField = fieldName(p.CodeField, def.CodeField)
+	resolved.Compression = fieldName(p.Compression, def.Compression)
+	resolved.DomainField = fieldName(p.DomainField, def.DomainField)
+	resolved.HintsField = fieldName(p.HintsField, def.HintsField)
+	resolved.IDField = fieldName(p.IDField, def.IDField)
+	resolved.KindField = fieldName(p.KindField, def.KindField)
+	resolved.MessageField = fieldName(p.MessageField, def.MessageField)
+	resolved.OpField = fieldName(p.OpField, def.OpField)
+	resolved.PositionField = fieldName(p.PositionField, def.PositionField)
+	resolved.SchemaField = fieldName(p.SchemaField, def.SchemaField)
+	resolved.SeverityField = fieldName(p.SeverityField, def.SeverityField)
+	resolved.StackField = fieldName(p.StackField, def.StackField)
+	resolved.VersionField = fieldName(p.VersionField, def.VersionField)
+	resolved.WrappedErrorField = fieldName(p.WrappedErrorField, def.WrappedErrorField)
+	return &resolved
+}
@@ -0,0 +1,116 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Recorder keeps the most recently recorded errors in memory so they can be dumped for post-mortem analysis.
+//
+// Errors can be recorded as they are created by registering [Recorder.Record] as a hook using [RegisterHook].
+type Recorder struct {
+	// unexported variables
+	mutex      sync.Mutex         // guards the recorder
+	records    *ring[RecentError] // the most recently recorded errors
+	sampleRate float64            // fraction of errors which are recorded
+}
+
+// RecorderOption is a function which configures a [Recorder].
+type RecorderOption func(*Recorder)
+
+// WithSampleRate sets the fraction of errors which are recorded by the recorder.
+//
+// The rate must be between 0 (no errors are recorded) and 1 (every error is recorded).  The default is 1.
+func WithSampleRate(rate float64) RecorderOption {
+	return func(r *Recorder) {
+		r.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// NewRecorder creates a new [Recorder] which keeps up to the given number of errors.
+func NewRecorder(size int, opts ...RecorderOption) *Recorder {
+	r := &Recorder{
+		records:    newRing[RecentError](size),
+		sampleRate: 1,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Dump writes the recorded errors to the given writer as a JSON array, from oldest to newest.
+//
+// This call is thread-safe.
+func (r *Recorder) Dump(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r.Errors())
+}
+
+// DumpOnSignal dumps the recorded errors to the given writer whenever one of the given signals is received.
+//
+// If no signals are given, SIGQUIT is used.  Note that handling SIGQUIT disables the default Go runtime behavior of
+// dumping all goroutine stacks and exiting.
+//
+// The returned function stops handling the signals.
+func (r *Recorder) DumpOnSignal(w io.Writer, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGQUIT}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				r.Dump(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Errors returns the recorded errors, from oldest to newest.
+//
+// This call is thread-safe.
+func (r *Recorder) Errors() []RecentError {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.records.slice()
+}
+
+// Record records a copy of the given error, subject to the recorder's sample rate.
+//
+// The copy is taken when the error is recorded, so that it can be dumped safely while the error is still being
+// modified; attributes added to the error afterwards are not included.
+//
+// This call is thread-safe.
+func (r *Recorder) Record(err Error) {
+	if err == nil || r.sampleRate == 0 || (r.sampleRate < 1 && rand.Float64() >= r.sampleRate) {
+		return
+	}
+	now := time.Now()
+	err = snapshotError(err)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.records.add(RecentError{
+		Time:  now,
+		Error: err,
+	})
+}
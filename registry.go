@@ -0,0 +1,247 @@
+package xerrors
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Definition describes an error code registered in a [Registry].
+type Definition struct {
+	// Code is the error code.
+	Code int `json:"code"`
+
+	// Name is the symbolic name of the code (eg: "UserNotFound"), if any.
+	Name string `json:"name,omitempty"`
+
+	// Message is the default message for errors with the code, if any.
+	Message string `json:"message,omitempty"`
+
+	// Deprecated indicates that the code should no longer be used.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// ReplacedBy is the code which replaces a deprecated code or 0 if there is no replacement.
+	ReplacedBy int `json:"replacedBy,omitempty"`
+
+	// DocsURL is the URL of the documentation (eg: the runbook) for errors with the code, if any.
+	//
+	// It is used as the type of the problem details documents written by httpx.WriteProblem and is linked from the
+	// output of a [TextFormatter] created with [WithRegistry].
+	DocsURL string `json:"docsUrl,omitempty"`
+
+	// Domain is the domain which errors with the code belong to, if any.  The sentinel of the code (see
+	// [Registry.Sentinel]) only matches errors in the domain.
+	Domain string `json:"domain,omitempty"`
+
+	// Parent is the code of the category which the code belongs to (eg: 1400 "bad request" for 1401) or 0 if it is
+	// not part of a category.  The sentinel of a category matches errors with any of the codes beneath it.
+	Parent int `json:"parent,omitempty"`
+}
+
+// Registry keeps track of the definitions of the error codes used by an application.
+type Registry struct {
+	// unexported variables
+	defs      map[int]Definition // definitions by code
+	mutex     sync.RWMutex       // guards the registry
+	sentinels map[int]*xerr      // sentinels by code
+}
+
+// NewRegistry creates a new empty [Registry].
+func NewRegistry() *Registry {
+	return &Registry{
+		defs:      make(map[int]Definition),
+		sentinels: make(map[int]*xerr),
+	}
+}
+
+// Current returns the code which replaces the given code, following the chain of replacements of deprecated codes.
+//
+// The code is returned unchanged if it is not deprecated or has no replacement.  This call is thread-safe.
+func (r *Registry) Current(code int) int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for range len(r.defs) {
+		def, ok := r.defs[code]
+		if !ok || !def.Deprecated || def.ReplacedBy == 0 {
+			break
+		}
+		code = def.ReplacedBy
+	}
+	return code
+}
+
+// Definitions returns the registered definitions sorted by their code.
+//
+// This call is thread-safe.
+func (r *Registry) Definitions() []Definition {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	defs := make([]Definition, 0, len(r.defs))
+	for _, def := range r.defs {
+		defs = append(defs, def)
+	}
+	slices.SortFunc(defs, func(a, b Definition) int {
+		return cmp.Compare(a.Code, b.Code)
+	})
+	return defs
+}
+
+// Deprecate marks the given code as deprecated, replaced by the given code (or 0 for no replacement).
+//
+// An error is returned if either code has not been registered.  This call is thread-safe.
+func (r *Registry) Deprecate(code, replacement int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	def, ok := r.defs[code]
+	if !ok {
+		return fmt.Errorf("code %d has not been registered", code)
+	}
+	if _, ok := r.defs[replacement]; replacement != 0 && !ok {
+		return fmt.Errorf("replacement code %d has not been registered", replacement)
+	}
+	def.Deprecated = true
+	def.ReplacedBy = replacement
+	r.defs[code] = def
+	return nil
+}
+
+// DeprecationHook returns a [Hook] which calls the given function whenever an error is created using a deprecated
+// code, eg:
+//
+//	xerrors.RegisterHook(registry.DeprecationHook(func(err xerrors.Error, def xerrors.Definition) {
+//		log.Printf("deprecated error code %d used at %s", def.Code, err.Caller())
+//	}))
+func (r *Registry) DeprecationHook(fn func(err Error, def Definition)) Hook {
+	return func(err Error) {
+		if def, ok := r.Lookup(err.Code()); ok && def.Deprecated {
+			fn(err, def)
+		}
+	}
+}
+
+// InCategory returns true if the given code is the category code or one of the codes beneath it (see
+// [Definition.Parent]).
+//
+// This call is thread-safe.
+func (r *Registry) InCategory(code, category int) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.inCategory(code, category)
+}
+
+// Lookup returns the definition of the given code, if it has been registered.
+//
+// This call is thread-safe.
+func (r *Registry) Lookup(code int) (Definition, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	def, ok := r.defs[code]
+	return def, ok
+}
+
+// LookupName returns the definition with the given symbolic name, if one has been registered.
+//
+// This call is thread-safe.
+func (r *Registry) LookupName(name string) (Definition, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, def := range r.defs {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// MustRegister is like [Registry.Register] but panics if the definitions cannot be registered.
+//
+// This is intended for registering definitions in package-level variable declarations or init functions.
+func (r *Registry) MustRegister(defs ...Definition) {
+	if err := r.Register(defs...); err != nil {
+		panic(err.Error())
+	}
+}
+
+// Parents returns the codes of the categories which the given code belongs to (see [Definition.Parent]), starting
+// with its parent and ending with the top-level category, or nil if it is not part of a category.
+//
+// This call is thread-safe.
+func (r *Registry) Parents(code int) []int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var parents []int
+	for range len(r.defs) {
+		def, ok := r.defs[code]
+		if !ok || def.Parent == 0 {
+			break
+		}
+		code = def.Parent
+		parents = append(parents, code)
+	}
+	return parents
+}
+
+// Register adds the given definitions to the registry.
+//
+// An error is returned if any of the codes has already been registered or if the parent of a definition is neither
+// registered nor one of the given definitions, in which case none of the definitions are added.  Categories must not
+// contain themselves.  This call is thread-safe.
+func (r *Registry) Register(defs ...Definition) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, def := range defs {
+		if _, ok := r.defs[def.Code]; ok {
+			return fmt.Errorf("code %d has already been registered", def.Code)
+		}
+		for _, other := range defs[:i] {
+			if other.Code == def.Code {
+				return fmt.Errorf("code %d is defined more than once", def.Code)
+			}
+		}
+	}
+	for _, def := range defs {
+		if def.Parent == 0 {
+			continue
+		}
+		_, ok := r.defs[def.Parent]
+		if !ok && !slices.ContainsFunc(defs, func(other Definition) bool { return other.Code == def.Parent }) {
+			return fmt.Errorf("parent code %d of code %d has not been registered", def.Parent, def.Code)
+		}
+	}
+	for _, def := range defs {
+		// only the new definitions can form a cycle, since the existing ones cannot have a new code as parent
+		seen := map[int]bool{def.Code: true}
+		for parent := def.Parent; parent != 0; {
+			if seen[parent] {
+				return fmt.Errorf("code %d is its own category", def.Code)
+			}
+			seen[parent] = true
+			j := slices.IndexFunc(defs, func(other Definition) bool { return other.Code == parent })
+			if j < 0 {
+				break
+			}
+			parent = defs[j].Parent
+		}
+	}
+	for _, def := range defs {
+		r.defs[def.Code] = def
+	}
+	return nil
+}
+
+// inCategory returns true if the given code is the category code or one of the codes beneath it.  The registry must
+// be locked.
+func (r *Registry) inCategory(code, category int) bool {
+	for range len(r.defs) + 1 {
+		if code == category {
+			return true
+		}
+		def, ok := r.defs[code]
+		if !ok || def.Parent == 0 {
+			break
+		}
+		code = def.Parent
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package xerrors
+
+import (
+	"context"
+)
+
+// Reporter is the interface implemented by objects which ship errors to an external error tracking service.
+//
+// Reference implementations can be found in the reporter subpackage.
+type Reporter interface {
+	// Report should send the given error to the service, returning an error if it could not be sent.
+	Report(ctx context.Context, err Error) error
+}
+
+// ReportAndWrap wraps the given error in a new [Error] with the given code and message, reports the new error using
+// the given reporter and returns it.
+//
+// The reporter receives the error after the registered transformers have been applied (see [Transform]), while the
+// returned error is left untransformed.  Any failure to report the error is ignored so that the original error is
+// never lost.
+func ReportAndWrap(ctx context.Context, reporter Reporter, code int, err error, message string) Error {
+	xerr := newError(ctx, nil, 0, code, message, err)
+	if reporter != nil {
+		reporter.Report(ctx, Transform(xerr))
+	}
+	return xerr
+}
@@ -0,0 +1,221 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// DefaultAlertFrames is the default number of stack frames included in an alert.
+	DefaultAlertFrames = 5
+
+	// _slackHeaderLimit is the maximum length of the text of a Slack header block.
+	_slackHeaderLimit = 150
+)
+
+// AlertFormat is the chat service an [AlertReporter] formats its alerts for.
+type AlertFormat int
+
+const (
+	// AlertSlack formats alerts as Slack Block Kit messages for Slack incoming webhooks.
+	AlertSlack AlertFormat = iota
+
+	// AlertTeams formats alerts as Adaptive Cards for Microsoft Teams incoming webhooks.
+	AlertTeams
+)
+
+// AlertReporter is an [xerrors.Reporter] which posts errors as formatted cards to a Slack or Microsoft Teams incoming
+// webhook.
+//
+// Each card holds the summary of the error (see [xerrors.Summarize]), its code, severity, domain and unique ID, the
+// top frames of its stack trace and an optional link to the logs.  Repeated occurrences of errors with the same
+// [xerrors.Fingerprint] are not posted again within the rate limit window, so a failing dependency does not flood
+// the channel.
+type AlertReporter struct {
+	// unexported variables
+	client  *http.Client          // client used to send requests
+	dedup   *xerrors.Deduplicator // rate limiter of repeated errors
+	format  AlertFormat           // chat service the alerts are formatted for
+	frames  int                   // maximum number of stack frames included
+	logsURL *template.Template    // template of the link to the logs, if any
+	url     string                // URL of the webhook
+}
+
+// AlertOption is a function which configures an [AlertReporter].
+type AlertOption func(*AlertReporter)
+
+// WithAlertClient sets the HTTP client used to send requests.  The default is [http.DefaultClient].
+func WithAlertClient(client *http.Client) AlertOption {
+	return func(r *AlertReporter) {
+		r.client = client
+	}
+}
+
+// WithAlertFrames sets the maximum number of stack frames included in each alert.  The default is
+// [DefaultAlertFrames]; 0 leaves the stack trace out.
+func WithAlertFrames(frames int) AlertOption {
+	return func(r *AlertReporter) {
+		r.frames = max(frames, 0)
+	}
+}
+
+// WithAlertLogsURL sets the template of the link to the logs included in each alert.
+//
+// The template is executed with the [xerrors.Error] being reported, eg:
+// "https://logs.example.com/search?q={{.ID}}".  If the template fails to execute, the link is left out.
+func WithAlertLogsURL(tmpl *template.Template) AlertOption {
+	return func(r *AlertReporter) {
+		r.logsURL = tmpl
+	}
+}
+
+// WithAlertRateLimit sets the deduplicator which decides whether an error is posted, eg: to share one window across
+// several reporters.  The default suppresses repeated errors for [xerrors.DefaultDedupTTL].
+func WithAlertRateLimit(dedup *xerrors.Deduplicator) AlertOption {
+	return func(r *AlertReporter) {
+		r.dedup = dedup
+	}
+}
+
+// NewAlertReporter creates a new [AlertReporter] which posts alerts in the given format to the given webhook URL.
+func NewAlertReporter(url string, format AlertFormat, opts ...AlertOption) *AlertReporter {
+	r := &AlertReporter{
+		client: http.DefaultClient,
+		dedup:  xerrors.NewDeduplicator(xerrors.DefaultDedupTTL),
+		format: format,
+		frames: DefaultAlertFrames,
+		url:    url,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Report posts the given error to the webhook, unless an error with the same fingerprint was posted within the rate
+// limit window.
+func (r *AlertReporter) Report(ctx context.Context, err xerrors.Error) error {
+	if !r.dedup.ShouldReport(err) {
+		return nil
+	}
+	var payload any
+	switch r.format {
+	case AlertTeams:
+		payload = r.teamsPayload(err)
+	default:
+		payload = r.slackPayload(err)
+	}
+	body, mErr := json.Marshal(payload)
+	if mErr != nil {
+		return mErr
+	}
+	return postJSON(ctx, r.client, r.url, nil, body)
+}
+
+// alertFact is a single labeled detail of an alert.
+type alertFact struct {
+	// Title is the label of the detail.
+	Title string `json:"title"`
+
+	// Value is the value of the detail.
+	Value string `json:"value"`
+}
+
+// facts returns the labeled details of the error which are included in an alert.
+func facts(err xerrors.Error) []alertFact {
+	facts := []alertFact{{Title: "Code", Value: strconv.Itoa(err.Code())}}
+	if severity := err.Severity(); severity != xerrors.SeverityUnknown {
+		facts = append(facts, alertFact{Title: "Severity", Value: severity.String()})
+	}
+	if domain := err.Domain(); domain != "" {
+		facts = append(facts, alertFact{Title: "Domain", Value: domain})
+	}
+	if id := err.ID(); id != "" {
+		facts = append(facts, alertFact{Title: "ID", Value: id})
+	}
+	return facts
+}
+
+// stackText returns the top frames of the stack trace of the error, one per line.
+func (r *AlertReporter) stackText(err xerrors.Error) string {
+	stack := err.StackTrace()
+	lines := make([]string, 0, min(len(stack), r.frames))
+	for i := 0; i < len(stack) && i < r.frames; i++ {
+		lines = append(lines, stack[i].String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// logsLink returns the link to the logs of the error or an empty string if there is none.
+func (r *AlertReporter) logsLink(err xerrors.Error) string {
+	if r.logsURL == nil {
+		return ""
+	}
+	var sb strings.Builder
+	if tErr := r.logsURL.Execute(&sb, err); tErr != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+// slackPayload returns the Slack Block Kit message for the error.
+func (r *AlertReporter) slackPayload(err xerrors.Error) map[string]any {
+	summary := xerrors.Summarize(err, _slackHeaderLimit)
+	fields := []map[string]any{}
+	for _, fact := range facts(err) {
+		fields = append(fields, map[string]any{"type": "mrkdwn", "text": "*" + fact.Title + "*\n" + fact.Value})
+	}
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": summary}},
+		{"type": "section", "fields": fields},
+	}
+	if stack := r.stackText(err); stack != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": "```" + stack + "```"},
+		})
+	}
+	if link := r.logsLink(err); link != "" {
+		blocks = append(blocks, map[string]any{
+			"type":     "context",
+			"elements": []map[string]any{{"type": "mrkdwn", "text": "<" + link + "|View logs>"}},
+		})
+	}
+	return map[string]any{
+		"text":   summary,
+		"blocks": blocks,
+	}
+}
+
+// teamsPayload returns the Microsoft Teams Adaptive Card message for the error.
+func (r *AlertReporter) teamsPayload(err xerrors.Error) map[string]any {
+	body := []map[string]any{
+		{"type": "TextBlock", "text": xerrors.Summarize(err, 0), "weight": "Bolder", "size": "Medium", "wrap": true},
+		{"type": "FactSet", "facts": facts(err)},
+	}
+	if stack := r.stackText(err); stack != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": stack, "fontType": "Monospace", "wrap": true})
+	}
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if link := r.logsLink(err); link != "" {
+		card["actions"] = []map[string]any{{"type": "Action.OpenUrl", "title": "View logs", "url": link}}
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
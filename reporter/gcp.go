@@ -0,0 +1,142 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	_gcpEndpoint = "https://clouderrorreporting.googleapis.com/v1beta1/projects/%s/events:report"
+)
+
+// GoogleCloudReporter is an [xerrors.Reporter] which sends errors to Google Cloud Error Reporting using its REST
+// API.
+//
+// The reporter does not handle authentication itself: either supply an API key using [WithGoogleCloudAPIKey] or an
+// HTTP client which adds OAuth2 credentials to each request using [WithGoogleCloudClient].
+type GoogleCloudReporter struct {
+	// unexported variables
+	apiKey    string       // API key used to authenticate requests, if any
+	client    *http.Client // client used to send requests
+	projectID string       // ID of the Google Cloud project
+	service   string       // name of the service reporting the error
+	version   string       // version of the service reporting the error
+}
+
+// GoogleCloudOption is a function which configures a [GoogleCloudReporter].
+type GoogleCloudOption func(*GoogleCloudReporter)
+
+// WithGoogleCloudAPIKey sets the API key used to authenticate requests.
+func WithGoogleCloudAPIKey(key string) GoogleCloudOption {
+	return func(r *GoogleCloudReporter) {
+		r.apiKey = key
+	}
+}
+
+// WithGoogleCloudClient sets the HTTP client used to send requests.  The default is [http.DefaultClient].
+func WithGoogleCloudClient(client *http.Client) GoogleCloudOption {
+	return func(r *GoogleCloudReporter) {
+		r.client = client
+	}
+}
+
+// WithGoogleCloudVersion sets the version of the service reporting the error.
+func WithGoogleCloudVersion(version string) GoogleCloudOption {
+	return func(r *GoogleCloudReporter) {
+		r.version = version
+	}
+}
+
+// NewGoogleCloudReporter creates a new [GoogleCloudReporter] for the given project and service.
+func NewGoogleCloudReporter(projectID, service string, opts ...GoogleCloudOption) *GoogleCloudReporter {
+	r := &GoogleCloudReporter{
+		client:    http.DefaultClient,
+		projectID: projectID,
+		service:   service,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// gcpEvent is the ReportedErrorEvent object accepted by the Error Reporting API.
+type gcpEvent struct {
+	// Context contains information on where the error occurred.
+	Context gcpContext `json:"context"`
+
+	// EventTime is when the error occurred.
+	EventTime string `json:"eventTime"`
+
+	// Message is the error message.
+	Message string `json:"message"`
+
+	// ServiceContext identifies the service which reported the error.
+	ServiceContext gcpServiceContext `json:"serviceContext"`
+}
+
+// gcpContext is the ErrorContext object accepted by the Error Reporting API.
+type gcpContext struct {
+	// ReportLocation is the location in the source code where the error was generated.
+	ReportLocation gcpLocation `json:"reportLocation"`
+}
+
+// gcpLocation is the SourceLocation object accepted by the Error Reporting API.
+type gcpLocation struct {
+	// FilePath is the source file in which the error was generated.
+	FilePath string `json:"filePath"`
+
+	// FunctionName is the function in which the error was generated.
+	FunctionName string `json:"functionName"`
+
+	// LineNumber is the line number at which the error was generated.
+	LineNumber int `json:"lineNumber"`
+}
+
+// gcpServiceContext is the ServiceContext object accepted by the Error Reporting API.
+type gcpServiceContext struct {
+	// Service is the name of the service.
+	Service string `json:"service"`
+
+	// Version is the version of the service.
+	Version string `json:"version,omitempty"`
+}
+
+// Report sends the given error to Google Cloud Error Reporting.
+//
+// The event message contains the string representation of the error so that its code and attributes are visible
+// in the Error Reporting console.
+func (r *GoogleCloudReporter) Report(ctx context.Context, err xerrors.Error) error {
+	caller := err.Caller()
+	event := gcpEvent{
+		Context: gcpContext{
+			ReportLocation: gcpLocation{
+				FilePath:     caller.File,
+				FunctionName: caller.Func,
+				LineNumber:   caller.Line,
+			},
+		},
+		EventTime: time.Now().UTC().Format(time.RFC3339Nano),
+		Message:   err.String(),
+		ServiceContext: gcpServiceContext{
+			Service: r.service,
+			Version: r.version,
+		},
+	}
+	body, mErr := json.Marshal(event)
+	if mErr != nil {
+		return mErr
+	}
+
+	endpoint := fmt.Sprintf(_gcpEndpoint, url.PathEscape(r.projectID))
+	if r.apiKey != "" {
+		endpoint += "?key=" + url.QueryEscape(r.apiKey)
+	}
+	return postJSON(ctx, r.client, endpoint, nil, body)
+}
@@ -0,0 +1,65 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// WebhookReporter is an [xerrors.Reporter] which posts the JSON representation of each error to a URL.
+type WebhookReporter struct {
+	// unexported variables
+	client *http.Client // client used to send requests
+	header http.Header  // additional headers sent with each request
+	url    string       // URL to which errors are posted
+}
+
+// WebhookOption is a function which configures a [WebhookReporter].
+type WebhookOption func(*WebhookReporter)
+
+// WithWebhookClient sets the HTTP client used to send requests.  The default is [http.DefaultClient].
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(r *WebhookReporter) {
+		r.client = client
+	}
+}
+
+// WithWebhookHeader adds a header which is sent with each request (eg: for authentication).
+func WithWebhookHeader(key, value string) WebhookOption {
+	return func(r *WebhookReporter) {
+		r.header.Add(key, value)
+	}
+}
+
+// NewWebhookReporter creates a new [WebhookReporter] which posts errors to the given URL.
+func NewWebhookReporter(url string, opts ...WebhookOption) *WebhookReporter {
+	r := &WebhookReporter{
+		client: http.DefaultClient,
+		header: http.Header{},
+		url:    url,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Report posts the given error to the webhook URL.
+func (r *WebhookReporter) Report(ctx context.Context, err xerrors.Error) error {
+	body, mErr := err.MarshalJSON()
+	if mErr != nil {
+		return mErr
+	}
+	return postJSON(ctx, r.client, r.url, r.header, body)
+}
+
+// ReportBatch posts the given errors to the webhook URL as a JSON array.
+func (r *WebhookReporter) ReportBatch(ctx context.Context, errs []xerrors.Error) error {
+	body, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, r.client, r.url, r.header, body)
+}
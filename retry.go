@@ -0,0 +1,88 @@
+package xerrors
+
+import (
+	"time"
+)
+
+// IsRetryable returns true if the operation which failed with the given error can be retried.
+//
+// The first [Error] in the chain which has explicitly been marked as retryable or not retryable (using
+// WithRetryable) decides the result, so an outer error can override the classification of the errors it wraps.
+// Errors with a retry delay (see WithRetryAfter) which have not otherwise been classified are considered retryable.
+func IsRetryable(err error) bool {
+	delay := false
+	var result *bool
+	walkChain(err, func(err error) bool {
+		if xerr, ok := err.(*xerr); ok {
+			if xerr.retryable != nil {
+				result = xerr.retryable
+				return false
+			}
+			delay = delay || xerr.retryAfter > 0
+		} else if xerr, ok := err.(Error); ok {
+			if xerr.Retryable() {
+				retryable := true
+				result = &retryable
+				return false
+			}
+			delay = delay || xerr.RetryAfter() > 0
+		}
+		return true
+	})
+	if result != nil {
+		return *result
+	}
+	return delay
+}
+
+// RetryAfter returns the delay set on the first [Error] in the chain of the given error which has one.
+//
+// The second return value is false if no error in the chain has a delay.
+func RetryAfter(err error) (time.Duration, bool) {
+	var delay time.Duration
+	walkChain(err, func(err error) bool {
+		if xerr, ok := err.(Error); ok && xerr.RetryAfter() > 0 {
+			delay = xerr.RetryAfter()
+			return false
+		}
+		return true
+	})
+	return delay, delay > 0
+}
+
+// IsSafeToRetry returns whether or not the operation which failed with the given error is safe to retry, ie:
+// idempotent.
+//
+// The first [Error] in the chain which has explicitly been marked as safe or unsafe to retry (using WithSafeToRetry)
+// decides the result.  The second return value is false if no error in the chain has been marked.
+func IsSafeToRetry(err error) (bool, bool) {
+	var result *bool
+	walkChain(err, func(err error) bool {
+		if xerr, ok := err.(*xerr); ok {
+			result = xerr.safe
+		} else if xerr, ok := err.(Error); ok && xerr.SafeToRetry() {
+			safe := true
+			result = &safe
+		}
+		return result == nil
+	})
+	if result != nil {
+		return *result, true
+	}
+	return false, false
+}
+
+// ShouldRetry returns true if the operation which failed with the given error can be retried (see [IsRetryable])
+// and is safe to retry (see [IsSafeToRetry]).
+//
+// If no error in the chain has been marked as safe or unsafe to retry, the idempotent parameter decides, which lets
+// client libraries pass what they know about the request, eg: whether its method is idempotent.
+func ShouldRetry(err error, idempotent bool) bool {
+	if !IsRetryable(err) {
+		return false
+	}
+	if safe, ok := IsSafeToRetry(err); ok {
+		return safe
+	}
+	return idempotent
+}
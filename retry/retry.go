@@ -0,0 +1,123 @@
+// Package retry retries operations based on the retry classification of the [xerrors.Error] objects they return.
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+const (
+	// AttemptsAttr is the name of the attribute holding the number of attempts made before giving up.
+	AttemptsAttr = "attempts"
+)
+
+var (
+	// _factory attributes the final failure to the code which called Do.
+	_factory = xerrors.NewFactory(xerrors.WithCallerSkip(1))
+)
+
+// Policy controls how an operation is retried.
+//
+// The zero value is usable and retries up to 3 attempts with an exponential backoff starting at 100 milliseconds.
+type Policy struct {
+	// MaxAttempts is the maximum number of times the operation is attempted, including the first attempt.  The
+	// default is 3.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the second attempt.  The default is 100 milliseconds.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between attempts.  The default is 10 seconds.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor by which the delay grows after each attempt.  The default is 2.
+	Multiplier float64
+
+	// Jitter is the fraction of each delay (between 0 and 1) which is randomized to avoid synchronized retries.
+	Jitter float64
+
+	// Code is the code of the error which wraps the final failure.
+	Code int
+
+	// Retryable decides whether errors which are not classified as retryable by [xerrors.IsRetryable] (eg: standard
+	// errors) should be retried.  By default, they are not.
+	Retryable func(error) bool
+}
+
+// Do calls the given function until it succeeds, returns an error which cannot be retried, the maximum number of
+// attempts has been made or the context is done.
+//
+// Whether or not an error can be retried is decided by [xerrors.IsRetryable] (and the policy's Retryable function).
+// If the error has a retry delay (see [xerrors.RetryAfter]), it is used instead of the backoff delay as long as it
+// does not exceed MaxBackoff.
+//
+// The final failure is wrapped in a new [xerrors.Error] with the policy's code and the number of attempts made as
+// an attribute.  If the context is done, the wrapping error is annotated as described by [xerrors.WrapContext].
+func Do(ctx context.Context, fn func(ctx context.Context) error, policy Policy) error {
+	policy = policy.withDefaults()
+	backoff := policy.InitialBackoff
+
+	var err error
+	attempt := 0
+	for attempt < policy.MaxAttempts {
+		attempt++
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) || ctx.Err() != nil {
+			break
+		}
+
+		delay := policy.jitter(backoff)
+		if d, ok := xerrors.RetryAfter(err); ok {
+			delay = min(d, policy.MaxBackoff)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		backoff = min(time.Duration(float64(backoff)*policy.Multiplier), policy.MaxBackoff)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return _factory.WrapContextf(ctx, policy.Code, err, "operation failed after %d attempt(s)", attempt).
+		WithAttr(AttemptsAttr, attempt)
+}
+
+// jitter randomizes the given delay by the policy's jitter fraction.
+func (p Policy) jitter(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return delay
+	}
+	spread := float64(delay) * min(p.Jitter, 1)
+	return time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+}
+
+// retryable returns true if the given error can be retried.
+func (p Policy) retryable(err error) bool {
+	return xerrors.IsRetryable(err) || (p.Retryable != nil && p.Retryable(err))
+}
+
+// withDefaults returns a copy of the policy with the default values filled in.
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 10 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	return p
+}
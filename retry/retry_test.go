@@ -0,0 +1,148 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// failingOperation returns an operation which fails with the given error until it has been called the given number
+// of times, along with a pointer to the number of calls.
+func failingOperation(err error, failures int) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= failures {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+// fastPolicy is a policy whose delays do not slow down the tests.
+var fastPolicy = Policy{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Code: 99}
+
+// checkFailure checks that the error returned by Do wraps the given cause after the given number of attempts.
+func checkFailure(t *testing.T, err, cause error, attempts int) {
+	t.Helper()
+	var xerr xerrors.Error
+	if !errors.As(err, &xerr) || xerr.Code() != 99 {
+		t.Fatalf("Do() = %v, want an error with code 99", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("the error does not wrap the last failure: %v", err)
+	}
+	if got := xerr.Attrs()[AttemptsAttr]; got != attempts {
+		t.Errorf("%s = %v, want %d", AttemptsAttr, got, attempts)
+	}
+}
+
+func TestDoRetriesRetryableErrors(t *testing.T) {
+	fn, calls := failingOperation(xerrors.New(1, "unavailable").WithRetryable(true), 2)
+	if err := Do(context.Background(), fn, fastPolicy); err != nil {
+		t.Errorf("Do() = %v, want nil", err)
+	}
+	if *calls != 3 {
+		t.Errorf("the operation was called %d times, want 3", *calls)
+	}
+}
+
+func TestDoStopsAtMaxAttempts(t *testing.T) {
+	cause := xerrors.New(1, "unavailable").WithRetryable(true)
+	fn, calls := failingOperation(cause, 10)
+	policy := fastPolicy
+	policy.MaxAttempts = 4
+	checkFailure(t, Do(context.Background(), fn, policy), cause, 4)
+	if *calls != 4 {
+		t.Errorf("the operation was called %d times, want 4", *calls)
+	}
+}
+
+func TestDoDoesNotRetryPermanentErrors(t *testing.T) {
+	tests := map[string]error{
+		"not retryable": xerrors.New(1, "invalid request").WithRetryable(false),
+		"standard":      errors.New("invalid request"),
+	}
+	for name, cause := range tests {
+		fn, calls := failingOperation(cause, 10)
+		checkFailure(t, Do(context.Background(), fn, fastPolicy), cause, 1)
+		if *calls != 1 {
+			t.Errorf("%s: the operation was called %d times, want 1", name, *calls)
+		}
+	}
+}
+
+func TestDoUsesPolicyRetryable(t *testing.T) {
+	cause := errors.New("connection reset")
+	fn, calls := failingOperation(cause, 1)
+	policy := fastPolicy
+	policy.Retryable = func(err error) bool {
+		return errors.Is(err, cause)
+	}
+	if err := Do(context.Background(), fn, policy); err != nil || *calls != 2 {
+		t.Errorf("Do() = %v after %d calls, want nil after 2", err, *calls)
+	}
+}
+
+func TestDoCapsDelays(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		policy Policy
+	}{
+		// without the cap, the second delay would be 1 second
+		"backoff": {
+			err: xerrors.New(1, "unavailable").WithRetryable(true),
+			policy: Policy{
+				MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, Multiplier: 1000,
+			},
+		},
+		"retry after": {
+			err:    xerrors.New(1, "throttled").WithRetryAfter(time.Hour),
+			policy: Policy{MaxAttempts: 2, MaxBackoff: time.Millisecond},
+		},
+	}
+	for name, test := range tests {
+		fn, _ := failingOperation(test.err, 10)
+		start := time.Now()
+		Do(context.Background(), fn, test.policy)
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%s: Do() took %v, want the delays capped at MaxBackoff", name, elapsed)
+		}
+	}
+}
+
+func TestDoStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cause := xerrors.New(1, "unavailable").WithRetryable(true)
+	calls := 0
+	fn := func(context.Context) error {
+		calls++
+		cancel()
+		return cause
+	}
+
+	start := time.Now()
+	err := Do(ctx, fn, Policy{InitialBackoff: time.Hour, MaxBackoff: time.Hour, Code: 99})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond || calls != 1 {
+		t.Errorf("Do() returned after %v and %d calls, want it to stop once the context is canceled", elapsed, calls)
+	}
+	checkFailure(t, err, cause, 1)
+	if kind := err.(xerrors.Error).Kind(); kind != xerrors.KindCanceled {
+		t.Errorf("kind = %q, want %q", kind, xerrors.KindCanceled)
+	}
+}
+
+func TestPolicyJitter(t *testing.T) {
+	policy := Policy{Jitter: 0.5}
+	for range 100 {
+		if d := policy.jitter(time.Second); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("jitter(1s) = %v, want a delay between 0.5s and 1.5s", d)
+		}
+	}
+	if d := (Policy{}).jitter(time.Second); d != time.Second {
+		t.Errorf("jitter(1s) = %v without jitter, want 1s", d)
+	}
+}
@@ -0,0 +1,44 @@
+package xerrors
+
+// ring is a fixed-size buffer which overwrites its oldest item once full.
+//
+// A ring is not thread-safe; callers must provide their own synchronization.
+type ring[T any] struct {
+	// unexported variables
+	items []T  // the buffered items
+	next  int  // index of the slot to write next
+	full  bool // whether or not every slot has been written at least once
+}
+
+// newRing creates a new ring holding up to size items.
+func newRing[T any](size int) *ring[T] {
+	if size < 0 {
+		size = 0
+	}
+	return &ring[T]{
+		items: make([]T, size),
+	}
+}
+
+// add adds an item to the ring, overwriting the oldest item if the ring is full.
+func (r *ring[T]) add(item T) {
+	if len(r.items) == 0 {
+		return
+	}
+	r.items[r.next] = item
+	r.next++
+	if r.next == len(r.items) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// slice returns a copy of the items in the ring, from oldest to newest.
+func (r *ring[T]) slice() []T {
+	if !r.full {
+		return append([]T{}, r.items[:r.next]...)
+	}
+	items := make([]T, 0, len(r.items))
+	items = append(items, r.items[r.next:]...)
+	return append(items, r.items[:r.next]...)
+}
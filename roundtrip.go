@@ -0,0 +1,296 @@
+package xerrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// CheckRoundTrip checks that the given error survives being marshaled to JSON and reconstructed using [ParseJSON],
+// returning an error describing the first difference found.
+//
+// The error is marshaled using [DefaultMarshalProfile], since that is the format ParseJSON accepts.  The reconstructed
+// error must have the same code, message, domain, ID, kind, op path, severity, position, hints, caller, stack trace
+// and attributes, subject to the following predictable degradations:
+//
+//   - invalid UTF-8 in strings is replaced with U+FFFD
+//   - attribute values take their JSON-decoded form, eg: all numbers become float64 (so integers beyond ±2^53 lose
+//     precision), structs become maps and values which cannot be marshaled become placeholder strings
+//   - severities without a name become [SeverityUnknown]
+//   - wrapped errors which are not [Error] objects only keep their message and wrapped [Error] objects are not part
+//     of the document
+//
+// Marshaling the reconstructed error must also produce a document which is a fixed point of further round trips.
+// Together with [SeedCorpus] and [CorpusErrors], this is intended for fuzz and property tests of code which
+// serializes errors, eg:
+//
+//	func FuzzParseJSON(f *testing.F) {
+//		for _, seed := range xerrors.SeedCorpus() {
+//			f.Add(seed)
+//		}
+//		f.Fuzz(func(t *testing.T, data []byte) {
+//			if err, pErr := xerrors.ParseJSON(data); pErr == nil {
+//				if cErr := xerrors.CheckRoundTrip(err); cErr != nil {
+//					t.Fatal(cErr)
+//				}
+//			}
+//		})
+//	}
+func CheckRoundTrip(err Error) error {
+	data, mErr := marshalDefault(err)
+	if mErr != nil {
+		return fmt.Errorf("failed to marshal error: %w", mErr)
+	}
+	parsed, pErr := ParseJSON(data)
+	if pErr != nil {
+		return fmt.Errorf("failed to parse marshaled error: %w: %s", pErr, data)
+	}
+	if e, ok := err.(*xerr); ok {
+		if diff := diffRoundTrip(expectedRoundTrip(e), parsed.(*xerr)); diff != "" {
+			return fmt.Errorf("round trip changed the %s: %s", diff, data)
+		}
+	}
+
+	again, mErr := marshalDefault(parsed)
+	if mErr != nil {
+		return fmt.Errorf("failed to marshal parsed error: %w", mErr)
+	}
+	reparsed, pErr := ParseJSON(again)
+	if pErr != nil {
+		return fmt.Errorf("failed to parse remarshaled error: %w: %s", pErr, again)
+	}
+	final, mErr := marshalDefault(reparsed)
+	if mErr != nil {
+		return fmt.Errorf("failed to marshal reparsed error: %w", mErr)
+	}
+	if !bytes.Equal(again, final) {
+		return fmt.Errorf("round trip is not stable: %s became %s", again, final)
+	}
+	return nil
+}
+
+// CorpusErrors returns a set of errors which exercise the edge cases of the JSON format, eg: unusual Unicode
+// messages, attribute values of every kind, nested groups and fully populated fields.
+//
+// A new set is returned on each call, so the errors may be modified.  Hooks are not called for the errors.
+func CorpusErrors() []Error {
+	type record struct {
+		Name  string
+		Count int
+	}
+	strs := []string{
+		"", "plain message", "unicode: héllo wörld — 日本語 🎉", "rtl: שלום עולם", "combining: é",
+		"controls: \x00\x01\t\n\r\x1b\x7f", "invalid utf-8: \xff\xfe\xc3", "html: <script>&amp;</script>",
+		"separators:   ", "quotes: \"'\\", strings.Repeat("long ", 200),
+	}
+	var errs []Error
+	for i, s := range strs {
+		errs = append(errs, &xerr{xerrExt: _noExt, code: i, message: s})
+	}
+	errs = append(errs,
+		&xerr{
+			xerrExt: &xerrExt{
+				domain:   "billing",
+				id:       "01J9ZQ3V5X8Y2K6M4N7P0R1S3T",
+				kind:     KindDeadlineExceeded,
+				op:       "svc.user.Create",
+				severity: SeverityCritical,
+				position: &Position{File: "config.yaml", Line: 12, Column: 3, Offset: 204},
+				hints:    []string{"retry later", "check \xff the logs"},
+				caller:   &CallerInfo{File: "main.go", Line: 10, Func: "main", Package: "example.com/app"},
+				stack: []CallerInfo{
+					{File: "main.go", Line: 10, Func: "main", Package: "example.com/app"},
+					{File: "user.go", Line: 42, Func: "Create", Package: "example.com/app/user", Receiver: "*Service"},
+					{File: "<autogenerated>", Line: 1, Func: "0x4a1b2c", Synthetic: true},
+				},
+			},
+			code:       -1,
+			message:    "every field",
+			wrappedErr: errors.New("wrapped: \xff"),
+		},
+		&xerr{xerrExt: &xerrExt{severity: Severity(42)}, code: math.MaxInt32, message: "extreme values", attrs: map[string]any{
+			"maxInt64": int64(math.MaxInt64),
+			"minInt64": int64(math.MinInt64),
+			"maxUint":  uint64(math.MaxUint64),
+			"float":    math.SmallestNonzeroFloat64,
+			"nan":      math.NaN(),
+			"inf":      math.Inf(1),
+		}},
+		&xerr{xerrExt: _noExt, code: 1, message: "attribute kinds", attrs: map[string]any{
+			"nil":      nil,
+			"bool":     true,
+			"string":   "value \xff",
+			"bytes":    []byte("raw\x00bytes"),
+			"slice":    []any{1, "two", 3.5, nil, []int{4}},
+			"map":      map[string]any{"nested": map[string]int{"deep": 1}},
+			"struct":   record{Name: "bob", Count: 2},
+			"pointer":  &record{Name: "alice"},
+			"error":    errors.New("cause"),
+			"xerror":   &xerr{xerrExt: _noExt, code: 2, message: "secondary"},
+			"channel":  make(chan int),
+			"function": func() {},
+			"":         "empty key",
+			"\xff":     "invalid key",
+			"group":    AttrGroup{"query": "SELECT 1", "inner": AttrGroup{"rows": 3}},
+		}},
+		&xerr{xerrExt: _noExt, code: 3, message: "empty collections", attrs: map[string]any{
+			"emptySlice": []string{},
+			"nilSlice":   []string(nil),
+			"emptyMap":   map[string]any{},
+			"emptyGroup": AttrGroup{},
+		}},
+	)
+	return errs
+}
+
+// SeedCorpus returns a set of JSON documents which can be used as the seed corpus of fuzz tests of [ParseJSON] and
+// code which consumes error documents (see [CheckRoundTrip]).
+//
+// The corpus holds the documents of the [CorpusErrors] along with documents which are only produced by other
+// versions or configurations, eg: versioned documents, compact callers and deeply nested wrapped errors.
+func SeedCorpus() [][]byte {
+	var corpus [][]byte
+	for _, err := range CorpusErrors() {
+		if data, mErr := marshalDefault(err); mErr == nil {
+			corpus = append(corpus, data)
+		}
+	}
+	nested := `{"code":0,"message":"root"}`
+	for i := 1; i <= 32; i++ {
+		nested = fmt.Sprintf(`{"code":%d,"message":"level %d","wrappedError":%s}`, i, i, nested)
+	}
+	corpus = append(corpus,
+		[]byte(`{"code":1,"message":"versioned","version":1}`),
+		[]byte(`{"code":1,"message":"compact","caller":"main.go:10 main.main","stack":["main.go:10 main.main"]}`),
+		[]byte(`{"code":1,"message":"wrapped","wrappedError":{"message":"plain"}}`),
+		[]byte(`{"code":1,"message":"nulls","attrs":null,"caller":null,"hints":null,"position":null}`),
+		[]byte(nested),
+	)
+	return corpus
+}
+
+// marshalDefault marshals the error using the default profile.
+func marshalDefault(err Error) ([]byte, error) {
+	if e, ok := err.(*xerr); ok {
+		return e.marshalJSON(_defaultProfile)
+	}
+	return err.MarshalJSON()
+}
+
+// expectedRoundTrip returns the error expected to be reconstructed from the document of the given error.
+func expectedRoundTrip(e *xerr) *xerr {
+	want := &xerr{
+		xerrExt: &xerrExt{
+			domain:   jsonString(e.domain),
+			id:       jsonString(e.id),
+			kind:     Kind(jsonString(string(e.kind))),
+			op:       jsonString(opPath(e)),
+			severity: e.severity,
+		},
+		code:    e.code,
+		message: jsonString(e.message),
+	}
+	if _, err := ParseSeverity(e.severity.String()); err != nil || e.severity.String() == "" {
+		want.severity = SeverityUnknown
+	}
+	if e.position != nil {
+		want.position = &Position{File: jsonString(e.position.File), Line: e.position.Line,
+			Column: e.position.Column, Offset: e.position.Offset}
+	}
+	for _, hint := range e.hints {
+		want.hints = append(want.hints, jsonString(hint))
+	}
+	if e.caller != nil {
+		caller := roundTripCaller(*e.caller)
+		want.caller = &caller
+	}
+	for _, frame := range e.stack {
+		want.stack = append(want.stack, roundTripCaller(frame))
+	}
+	if e.wrappedErr != nil {
+		if _, ok := e.wrappedErr.(Error); !ok {
+			want.wrappedErr = errors.New(jsonString(e.wrappedErr.Error()))
+		}
+	}
+	if len(e.attrs) > 0 {
+		keys := make([]string, 0, len(e.attrs))
+		for k := range e.attrs {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		want.attrs = make(map[string]any, len(keys))
+		for _, k := range keys {
+			var v any
+			json.Unmarshal(appendJSONValue(nil, e.attrs[k], nil), &v)
+			want.attrs[jsonString(k)] = v
+		}
+	}
+	return want
+}
+
+// diffRoundTrip returns the name of the first field which differs between the errors or an empty string if they
+// match.
+func diffRoundTrip(want, got *xerr) string {
+	var wantWrapped, gotWrapped string
+	if want.wrappedErr != nil {
+		wantWrapped = want.wrappedErr.Error()
+	}
+	if got.wrappedErr != nil {
+		if _, ok := got.wrappedErr.(Error); !ok {
+			gotWrapped = got.wrappedErr.Error()
+		}
+	}
+	switch {
+	case want.code != got.code:
+		return "code"
+	case want.message != got.message:
+		return "message"
+	case want.domain != got.domain:
+		return "domain"
+	case want.id != got.id:
+		return "ID"
+	case want.kind != got.kind:
+		return "kind"
+	case want.op != got.op:
+		return "op path"
+	case want.severity != got.severity:
+		return "severity"
+	case !reflect.DeepEqual(want.position, got.position):
+		return "position"
+	case len(want.hints) != len(got.hints) || (len(want.hints) > 0 && !reflect.DeepEqual(want.hints, got.hints)):
+		return "hints"
+	case !reflect.DeepEqual(want.caller, got.caller):
+		return "caller"
+	case len(want.stack) != len(got.stack) || (len(want.stack) > 0 && !reflect.DeepEqual(want.stack, got.stack)):
+		return "stack trace"
+	case wantWrapped != gotWrapped:
+		return "wrapped error"
+	case len(want.attrs) != len(got.attrs) || (len(want.attrs) > 0 && !reflect.DeepEqual(want.attrs, got.attrs)):
+		return "attributes"
+	}
+	return ""
+}
+
+// roundTripCaller returns the caller information as it is reconstructed from JSON.
+func roundTripCaller(c CallerInfo) CallerInfo {
+	return CallerInfo{
+		File:      jsonString(c.File),
+		Line:      c.Line,
+		Func:      jsonString(c.Func),
+		Package:   jsonString(c.Package),
+		Receiver:  jsonString(c.Receiver),
+		Synthetic: c.Synthetic,
+	}
+}
+
+// jsonString returns the string as it is reconstructed from JSON, ie: with invalid UTF-8 replaced.
+func jsonString(s string) string {
+	var decoded string
+	json.Unmarshal(appendJSONString(nil, s), &decoded)
+	return decoded
+}
@@ -0,0 +1,28 @@
+package xerrors
+
+import (
+	"testing"
+)
+
+func TestCorpusErrorsRoundTrip(t *testing.T) {
+	for _, err := range CorpusErrors() {
+		if rtErr := CheckRoundTrip(err); rtErr != nil {
+			t.Errorf("%s: %v", err.Error(), rtErr)
+		}
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		err, pErr := ParseJSON(data)
+		if pErr != nil {
+			return
+		}
+		if rtErr := CheckRoundTrip(err); rtErr != nil {
+			t.Fatalf("%s: %v", data, rtErr)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package xerrors
+
+import (
+	"strconv"
+)
+
+// Sentinel returns the canonical sentinel error of the given code, for use with [errors.Is], eg:
+//
+//	var ErrQuotaExceeded = registry.Sentinel(1042)
+//	...
+//	if errors.Is(err, ErrQuotaExceeded) { ... }
+//
+// Unlike a sentinel created with [New], which only matches errors which wrap the sentinel itself, it matches any
+// [Error] in the chain with the same code, so the check keeps working after the error has been serialized and
+// reconstructed in another process.  Deprecated codes match the sentinels of the codes which replace them (see
+// [Registry.Current]) and vice versa.  The sentinel of a category (see [Definition.Parent]) also matches errors with
+// any of the codes beneath it, eg:
+//
+//	var ErrBadRequest = registry.Sentinel(1400)
+//	...
+//	if errors.Is(err, ErrBadRequest) { ... } // true for codes 1401, 1402, ...
+//
+// If the definition of the code has a domain, only errors in that domain match.
+//
+// The same sentinel is returned on each call for a code.  Its message is the default message of the code if it was
+// registered before the first call, eg: "quota exceeded", or otherwise the code, eg: "error 1042".  The sentinel must
+// not be modified.  This call is thread-safe.
+func (r *Registry) Sentinel(code int) Error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if sentinel, ok := r.sentinels[code]; ok {
+		return sentinel
+	}
+	sentinel := &xerr{xerrExt: &xerrExt{sentinel: r}, code: code, message: "error " + strconv.Itoa(code)}
+	if def, ok := r.defs[code]; ok && def.Message != "" {
+		sentinel.message = def.Message
+	}
+	r.sentinels[code] = sentinel
+	return sentinel
+}
+
+// matchesSentinel returns true if the given error matches the sentinel.
+func (e *xerr) matchesSentinel(err *xerr) bool {
+	if err == e || !e.sentinel.InCategory(e.sentinel.Current(err.code), e.sentinel.Current(e.code)) {
+		return false
+	}
+	def, ok := e.sentinel.Lookup(e.code)
+	return !ok || def.Domain == "" || def.Domain == err.domain
+}
@@ -0,0 +1,60 @@
+package xerrors
+
+import (
+	"iter"
+)
+
+// WrapSeq adapts a sequence of values and errors, eg: the rows of a streaming query, so that every non-nil error is
+// wrapped in a new [Error] with the given code and message.
+//
+// Values are passed through unchanged, alongside a nil [Error] if the original error was nil.  The caller information
+// of the wrapping errors points at the code which yielded the original error.
+func WrapSeq[T any](seq iter.Seq2[T, error], code int, message string) iter.Seq2[T, Error] {
+	return func(yield func(T, Error) bool) {
+		seq(func(v T, err error) bool {
+			if err == nil {
+				return yield(v, nil)
+			}
+			return yield(v, newError(nil, nil, 0, code, message, err))
+		})
+	}
+}
+
+// WrapErrSeq adapts a sequence of errors, eg: the failures reported by concurrent workers, so that every error is
+// wrapped in a new [Error] with the given code and message.
+//
+// Nil errors are skipped.  The caller information of the wrapping errors points at the code which yielded the
+// original error.
+func WrapErrSeq(seq iter.Seq[error], code int, message string) iter.Seq[Error] {
+	return func(yield func(Error) bool) {
+		seq(func(err error) bool {
+			if err == nil {
+				return true
+			}
+			return yield(newError(nil, nil, 0, code, message, err))
+		})
+	}
+}
+
+// StopOnError adapts a sequence of values and errors into a sequence of values which stops at the first error.
+//
+// The error, if any, is stored in errp once the iteration ends, so that the values can be ranged over directly:
+//
+//	var err xerrors.Error
+//	for row := range xerrors.StopOnError(xerrors.WrapSeq(rows, 1, "query failed"), &err) {
+//		...
+//	}
+//	if err != nil {
+//		...
+//	}
+func StopOnError[T any](seq iter.Seq2[T, Error], errp *Error) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		seq(func(v T, err Error) bool {
+			if err != nil {
+				*errp = err
+				return false
+			}
+			return yield(v)
+		})
+	}
+}
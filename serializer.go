@@ -0,0 +1,83 @@
+package xerrors
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	_serializers      = []attrSerializer{}
+	_serializersMutex sync.RWMutex
+)
+
+// attrSerializer is a serializer registered for a specific type.
+type attrSerializer struct {
+	fn  func(any) any // converts the value into the form which is marshaled
+	typ reflect.Type  // the type handled by the serializer
+}
+
+// RegisterAttrSerializer registers a function which converts attribute values of type T into the form which is
+// marshaled to JSON in place of the original value.
+//
+// If T is an interface type, the serializer applies to any value which implements it.  Serializers for the exact type
+// of a value take precedence; otherwise the first matching interface serializer which was registered is used.  For
+// example:
+//
+//	// render times as RFC 3339 strings
+//	xerrors.RegisterAttrSerializer(func(t time.Time) any { return t.Format(time.RFC3339) })
+//
+//	// render any stringer using its String method
+//	xerrors.RegisterAttrSerializer(func(s fmt.Stringer) any { return s.String() })
+//
+//	// render users using a view without their personal details
+//	xerrors.RegisterAttrSerializer(func(u User) any { return u.Redacted() })
+//
+// Serializers only apply to the top-level attribute values, not to values nested inside them.  This function affects
+// all errors marshaled globally by this package.  This call is thread-safe.
+func RegisterAttrSerializer[T any](fn func(T) any) {
+	s := attrSerializer{
+		fn:  func(v any) any { return fn(v.(T)) },
+		typ: reflect.TypeFor[T](),
+	}
+	_serializersMutex.Lock()
+	_serializers = append(_serializers, s)
+	_serializersMutex.Unlock()
+}
+
+// ResetAttrSerializers removes all serializers which were added using [RegisterAttrSerializer].
+//
+// This call is thread-safe.
+func ResetAttrSerializers() {
+	_serializersMutex.Lock()
+	_serializers = []attrSerializer{}
+	_serializersMutex.Unlock()
+}
+
+// serializeAttr returns the attribute value converted by the matching registered serializer, if any.
+func serializeAttr(value any) any {
+	if value == nil {
+		return nil
+	}
+	_serializersMutex.RLock()
+	serializers := _serializers
+	_serializersMutex.RUnlock()
+	if len(serializers) == 0 {
+		return value
+	}
+
+	typ := reflect.TypeOf(value)
+	var match *attrSerializer
+	for i := range serializers {
+		s := &serializers[i]
+		if s.typ == typ {
+			return s.fn(value)
+		}
+		if match == nil && s.typ.Kind() == reflect.Interface && typ.Implements(s.typ) {
+			match = s
+		}
+	}
+	if match != nil {
+		return match.fn(value)
+	}
+	return value
+}
@@ -0,0 +1,83 @@
+package xerrors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity indicates how serious a failure is.
+//
+// Severities are ordered, so they can be compared against a threshold, eg: err.Severity() >= SeverityError.
+type Severity int
+
+const (
+	// SeverityUnknown indicates that the severity has not been set.
+	SeverityUnknown Severity = iota
+
+	// SeverityDebug indicates a failure which is only of interest when debugging.
+	SeverityDebug
+
+	// SeverityInfo indicates an expected failure, eg: a request for a resource which does not exist.
+	SeverityInfo
+
+	// SeverityWarning indicates a failure which was recovered from but may need attention.
+	SeverityWarning
+
+	// SeverityError indicates a failure which prevented an operation from completing.
+	SeverityError
+
+	// SeverityCritical indicates a failure which requires immediate attention.
+	SeverityCritical
+)
+
+// ParseSeverity returns the severity with the given name (eg: "warning"), ignoring case.
+func ParseSeverity(name string) (Severity, error) {
+	var s Severity
+	if err := s.UnmarshalText([]byte(name)); err != nil {
+		return SeverityUnknown, err
+	}
+	return s, nil
+}
+
+// MarshalText returns the name of the severity.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// String returns the name of the severity or an empty string if the severity is unknown.
+func (s Severity) String() string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return ""
+}
+
+// UnmarshalText sets the severity from its name, ignoring case.  An empty name is parsed as [SeverityUnknown].
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "":
+		*s = SeverityUnknown
+	case "debug":
+		*s = SeverityDebug
+	case "info":
+		*s = SeverityInfo
+	case "warning", "warn":
+		*s = SeverityWarning
+	case "error":
+		*s = SeverityError
+	case "critical":
+		*s = SeverityCritical
+	default:
+		return fmt.Errorf("unknown severity: %q", text)
+	}
+	return nil
+}
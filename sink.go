@@ -0,0 +1,129 @@
+package xerrors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Format is an output format for writing errors to an [io.Writer].
+type Format int
+
+const (
+	// FormatJSON writes each error as a single line of JSON (NDJSON).
+	FormatJSON Format = iota
+
+	// FormatLogfmt writes each error as a single logfmt line (see [MarshalLogfmt]).
+	FormatLogfmt
+)
+
+var (
+	_sink        *Sink
+	_sinkEnabled atomic.Bool // set while a sink is set, so creating errors can skip the mutex
+	_sinkMutex   sync.Mutex
+)
+
+// SetErrorSink writes every error created by this package to the given writer in the given format, which is useful
+// for small tools which do not use a logging framework but still want structured output.
+//
+// Errors are written as soon as they are created (after the hooks are called), so attributes added afterwards are
+// not included; use a [Sink] as a [Reporter] to write errors once they are complete.  Passing a nil writer disables
+// the sink.  Failures to write are ignored.  This call is thread-safe.
+func SetErrorSink(w io.Writer, format Format) {
+	var sink *Sink
+	if w != nil {
+		sink = NewSink(w, format)
+	}
+	_sinkMutex.Lock()
+	_sink = sink
+	_sinkEnabled.Store(sink != nil)
+	_sinkMutex.Unlock()
+}
+
+// writeSink writes the given error to the sink set by [SetErrorSink], if any.
+func writeSink(err Error) {
+	if !_sinkEnabled.Load() {
+		return
+	}
+	_sinkMutex.Lock()
+	sink := _sink
+	_sinkMutex.Unlock()
+	if sink != nil {
+		sink.Write(err)
+	}
+}
+
+// Sink writes errors to an [io.Writer], one per line.
+//
+// A Sink implements [Reporter], so it can be used anywhere a reporter is expected.  It is safe for concurrent use;
+// each error is written using a single call to the writer.
+type Sink struct {
+	// unexported variables
+	encoder Encoder    // encoder of the written errors or nil to use the format
+	format  Format     // format of the written errors
+	mutex   sync.Mutex // serializes writes
+	w       io.Writer  // destination of the written errors
+}
+
+// NewSink creates a new [Sink] which writes errors to the given writer in the given format.
+func NewSink(w io.Writer, format Format) *Sink {
+	return &Sink{
+		format: format,
+		w:      w,
+	}
+}
+
+// NewEncoderSink creates a new [Sink] which writes errors to the given writer using the given encoder (see
+// [LookupEncoder]).
+//
+// The encoder should produce a single line for each error, as the errors are separated by newlines.
+func NewEncoderSink(w io.Writer, encoder Encoder) *Sink {
+	return &Sink{
+		encoder: encoder,
+		w:       w,
+	}
+}
+
+// Report writes the error to the sink, returning any error from the writer.
+//
+// Errors below the ReportMinSeverity or outside of the ReportSampleRate of the [Config] are dropped without being
+// written.
+func (s *Sink) Report(ctx context.Context, err Error) error {
+	if !reportable(err) {
+		return nil
+	}
+	return s.Write(err)
+}
+
+// Write writes the error to the sink, after applying the registered transformers (see [Transform]), returning any
+// error from the writer.
+func (s *Sink) Write(err Error) error {
+	err = Transform(err)
+	var line []byte
+	switch {
+	case s.encoder != nil:
+		data, eErr := s.encoder.Encode(err)
+		if eErr != nil {
+			return eErr
+		}
+		line = data
+	case s.format == FormatJSON:
+		data, mErr := err.MarshalJSON()
+		if mErr != nil {
+			return mErr
+		}
+		line = data
+	case s.format == FormatLogfmt:
+		line = MarshalLogfmt(err)
+	default:
+		return fmt.Errorf("unknown format: %d", s.format)
+	}
+	line = append(line, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, wErr := s.w.Write(line)
+	return wErr
+}
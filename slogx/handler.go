@@ -0,0 +1,226 @@
+// Package slogx contains helpers for logging [xerrors.Error] objects using [log/slog].
+package slogx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// Handler is a [slog.Handler] which expands [xerrors.Error] values into structured groups before passing the records
+// on to another handler.
+//
+// An attribute whose value is an [xerrors.Error] (or an error wrapping one) is replaced by a group holding the code,
+// message, domain, id, kind, op, severity, caller and attributes of the error; fields which have not been set are
+// left out.  Attributes added using [slog.Logger.With] are expanded in the same way.
+type Handler struct {
+	// unexported variables
+	codeKey     string       // key of the promoted error code or an empty string to disable promotion
+	errorSource bool         // whether or not the source of records is the caller of their first error
+	next        slog.Handler // the wrapped handler
+	severityKey string       // key of the promoted error severity or an empty string to disable promotion
+}
+
+// HandlerOption is a function which configures a [Handler].
+type HandlerOption func(*Handler)
+
+// WithErrorSource sets the program counter of each record containing an error to that of the caller of the first
+// error (see [xerrors.CaptureCallerInfo]), so the source reported by handlers created with the AddSource option points
+// at where the error originated rather than where it was logged.
+//
+// Records are left unchanged if the error has no caller information, eg: because it was parsed from JSON.  If the
+// caller is a function which the compiler inlined into another function, the source may point at the inlined
+// function instead.
+func WithErrorSource() HandlerOption {
+	return func(h *Handler) {
+		h.errorSource = true
+	}
+}
+
+// WithPromotedCode adds the code of the first error in each record as a separate attribute with the given key, eg:
+// "error_code", so that downstream consumers can filter on it without descending into the group.
+//
+// Promoted attributes are added to the record, so they are nested inside any groups opened using
+// [slog.Logger.WithGroup].
+func WithPromotedCode(key string) HandlerOption {
+	return func(h *Handler) {
+		h.codeKey = key
+	}
+}
+
+// WithPromotedSeverity adds the severity of the first error in each record as a separate attribute with the given
+// key, eg: "error_severity", if the severity has been set.  See [WithPromotedCode] for details.
+func WithPromotedSeverity(key string) HandlerOption {
+	return func(h *Handler) {
+		h.severityKey = key
+	}
+}
+
+// NewHandler creates a new [Handler] which passes the expanded records on to the given handler.
+func NewHandler(next slog.Handler, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		next: next,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle expands the errors in the record and passes it on to the wrapped handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	expanded, first := h.expand(attrs)
+	if first == nil {
+		return h.next.Handle(ctx, r)
+	}
+	pc := r.PC
+	if caller := first.Caller(); h.errorSource && caller.PC != 0 {
+		// the frames of captured callers hold the address of the call instruction, but records hold return addresses
+		pc = caller.PC + 1
+	}
+	record := slog.NewRecord(r.Time, r.Level, r.Message, pc)
+	record.AddAttrs(expanded...)
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs returns a new [Handler] whose wrapped handler has the given attributes, with any errors expanded.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded, _ := h.expand(attrs)
+	clone := *h
+	clone.next = h.next.WithAttrs(expanded)
+	return &clone
+}
+
+// WithGroup returns a new [Handler] whose wrapped handler has the given group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	return &clone
+}
+
+// expand replaces the errors in the given attributes with groups and appends any promoted attributes.
+//
+// The first error which was found is also returned or nil if the attributes did not contain any errors.
+func (h *Handler) expand(attrs []slog.Attr) ([]slog.Attr, xerrors.Error) {
+	var first xerrors.Error
+	expanded := make([]slog.Attr, 0, len(attrs)+2)
+	for _, a := range attrs {
+		a, xerr := expandAttr(a)
+		if xerr != nil && first == nil {
+			first = xerr
+		}
+		expanded = append(expanded, a)
+	}
+	if first == nil {
+		return attrs, nil
+	}
+	if h.codeKey != "" {
+		expanded = append(expanded, slog.Int(h.codeKey, first.Code()))
+	}
+	if h.severityKey != "" && first.Severity() != xerrors.SeverityUnknown {
+		expanded = append(expanded, slog.String(h.severityKey, first.Severity().String()))
+	}
+	return expanded, first
+}
+
+// expandAttr replaces the attribute with a group if its value is an error, descending into groups.
+//
+// The first error which was found is also returned.
+func expandAttr(a slog.Attr) (slog.Attr, xerrors.Error) {
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		var first xerrors.Error
+		group := slices.Clone(a.Value.Group())
+		for i := range group {
+			var xerr xerrors.Error
+			if group[i], xerr = expandAttr(group[i]); first == nil {
+				first = xerr
+			}
+		}
+		if first == nil {
+			return a, nil
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(group...)}, first
+	case slog.KindAny:
+		err, ok := a.Value.Any().(error)
+		if !ok {
+			return a, nil
+		}
+		var xerr xerrors.Error
+		if !errors.As(err, &xerr) {
+			return a, nil
+		}
+		return slog.Attr{Key: a.Key, Value: ErrorValue(err)}, xerr
+	}
+	return a, nil
+}
+
+// ErrorValue returns the structured group used by [Handler] to log the given error.
+//
+// If the error does not wrap an [xerrors.Error], the group only contains the error message.
+func ErrorValue(err error) slog.Value {
+	var xerr xerrors.Error
+	if !errors.As(err, &xerr) {
+		return slog.GroupValue(slog.String("message", err.Error()))
+	}
+	attrs := []slog.Attr{
+		slog.Int("code", xerr.Code()),
+		slog.String("message", err.Error()),
+	}
+	if domain := xerr.Domain(); domain != "" {
+		attrs = append(attrs, slog.String("domain", domain))
+	}
+	if id := xerr.ID(); id != "" {
+		attrs = append(attrs, slog.String("id", id))
+	}
+	if kind := xerr.Kind(); kind != "" {
+		attrs = append(attrs, slog.String("kind", string(kind)))
+	}
+	if ops := xerrors.Ops(err); len(ops) > 0 {
+		attrs = append(attrs, slog.String("op", strings.Join(ops, xerrors.OpSeparator)))
+	}
+	if severity := xerr.Severity(); severity != xerrors.SeverityUnknown {
+		attrs = append(attrs, slog.String("severity", severity.String()))
+	}
+	if caller := xerr.Caller(); caller != *xerrors.DefaultCallerInfo() {
+		attrs = append(attrs, slog.String("caller", caller.String()))
+	}
+	if len(xerr.Attrs()) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "attrs", Value: groupValue(xerr.Attrs())})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// groupValue returns the given attributes as a group with sorted keys, converting attribute groups into nested
+// groups.
+func groupValue(attrs map[string]any) slog.Value {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	group := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		if g, ok := attrs[k].(xerrors.AttrGroup); ok {
+			group = append(group, slog.Attr{Key: k, Value: groupValue(g)})
+		} else {
+			group = append(group, slog.Any(k, attrs[k]))
+		}
+	}
+	return slog.GroupValue(group...)
+}
@@ -0,0 +1,165 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"testing"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+// newTestLogger returns a logger whose records are written as JSON to the returned buffer.
+func newTestLogger(opts ...HandlerOption) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	next := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	return slog.New(NewHandler(next, opts...)), &buf
+}
+
+// decodeRecord decodes the last record written to the buffer.
+func decodeRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var record map[string]any
+	if err := json.Unmarshal(lines[len(lines)-1], &record); err != nil {
+		t.Fatalf("invalid record %s: %v", buf, err)
+	}
+	return record
+}
+
+// testError returns an error with most of the fields expanded by the handler.
+func testError() xerrors.Error {
+	return xerrors.NewFactory(xerrors.WithDomain("billing")).New(1042, "quota exceeded").
+		WithKind(xerrors.KindDeadlineExceeded).WithOp("billing.Charge").WithSeverity(xerrors.SeverityCritical).
+		WithAttr("user", "alice").WithGroup("db").WithAttr("query", "SELECT 1")
+}
+
+func TestHandlerExpandsErrors(t *testing.T) {
+	logger, buf := newTestLogger()
+	logger.Error("charge failed", "err", testError(), "tenant", "acme")
+
+	record := decodeRecord(t, buf)
+	group, ok := record["err"].(map[string]any)
+	if !ok {
+		t.Fatalf("the error was not expanded: %v", record)
+	}
+	want := map[string]any{
+		"code":     1042.0,
+		"message":  "quota exceeded",
+		"domain":   "billing",
+		"kind":     string(xerrors.KindDeadlineExceeded),
+		"op":       "billing.Charge",
+		"severity": "critical",
+	}
+	for k, v := range want {
+		if group[k] != v {
+			t.Errorf("%s = %v, want %v", k, group[k], v)
+		}
+	}
+	attrs, _ := group["attrs"].(map[string]any)
+	if db, _ := attrs["db"].(map[string]any); attrs["user"] != "alice" || db["query"] != "SELECT 1" {
+		t.Errorf("attrs = %v", group["attrs"])
+	}
+	if record["tenant"] != "acme" {
+		t.Errorf("the other attributes were not kept: %v", record)
+	}
+}
+
+func TestHandlerExpandsWrappedErrorsOnly(t *testing.T) {
+	logger, buf := newTestLogger()
+	logger.Error("failed", "err", fmt.Errorf("charging: %w", xerrors.New(7, "declined")), "plain", errors.New("eof"))
+
+	record := decodeRecord(t, buf)
+	group, _ := record["err"].(map[string]any)
+	if group["code"] != 7.0 || group["message"] != "charging: declined" {
+		t.Errorf("the wrapped error was not expanded: %v", record["err"])
+	}
+	if record["plain"] != "eof" {
+		t.Errorf("the standard error was expanded: %v", record["plain"])
+	}
+}
+
+func TestHandlerExpandsErrorsInGroups(t *testing.T) {
+	logger, buf := newTestLogger(WithPromotedCode("error_code"))
+	logger.WithGroup("request").Error("failed", slog.Group("details", "err", xerrors.New(7, "declined")))
+
+	record := decodeRecord(t, buf)
+	request, _ := record["request"].(map[string]any)
+	details, _ := request["details"].(map[string]any)
+	if group, _ := details["err"].(map[string]any); group["code"] != 7.0 {
+		t.Errorf("the error in the group was not expanded: %v", record)
+	}
+	if request["error_code"] != 7.0 {
+		t.Errorf("the promoted code is not in the open group: %v", record)
+	}
+}
+
+func TestHandlerExpandsErrorsAddedUsingWith(t *testing.T) {
+	logger, buf := newTestLogger()
+	logger.With("err", xerrors.New(7, "declined")).Info("retrying")
+
+	if group, _ := decodeRecord(t, buf)["err"].(map[string]any); group["code"] != 7.0 {
+		t.Errorf("the error added using With was not expanded: %s", buf)
+	}
+}
+
+func TestHandlerPromotesCodeAndSeverity(t *testing.T) {
+	logger, buf := newTestLogger(WithPromotedCode("error_code"), WithPromotedSeverity("error_severity"))
+	logger.Error("failed", "first", testError(), "second", xerrors.New(7, "declined"))
+
+	record := decodeRecord(t, buf)
+	if record["error_code"] != 1042.0 || record["error_severity"] != "critical" {
+		t.Errorf("the fields of the first error were not promoted: %v", record)
+	}
+
+	logger.Error("failed", "err", xerrors.New(7, "declined"))
+	record = decodeRecord(t, buf)
+	if _, ok := record["error_severity"]; ok || record["error_code"] != 7.0 {
+		t.Errorf("unexpected promoted fields for an error without severity: %v", record)
+	}
+
+	logger.Info("done", "count", 1)
+	if record = decodeRecord(t, buf); record["error_code"] != nil {
+		t.Errorf("a code was promoted for a record without errors: %v", record)
+	}
+}
+
+func TestHandlerWithErrorSource(t *testing.T) {
+	saved := xerrors.CurrentConfig()
+	xerrors.UpdateConfig(func(c *xerrors.Config) {
+		c.CaptureCaller = true
+	})
+	defer xerrors.UpdateConfig(func(c *xerrors.Config) {
+		*c = saved
+	})
+
+	err := xerrors.New(1, "failed")
+	_, _, line, _ := runtime.Caller(0)
+	wantLine := float64(line - 1)
+
+	logger, buf := newTestLogger(WithErrorSource())
+	logger.Error("failed", "err", err)
+	source, _ := decodeRecord(t, buf)["source"].(map[string]any)
+	const wantFunction = "go.innotegrity.dev/xerrors/slogx.TestHandlerWithErrorSource"
+	if source["line"] != wantLine || source["function"] != wantFunction {
+		t.Errorf("source = %v, want line %v of the test", source, wantLine)
+	}
+
+	logger, buf = newTestLogger()
+	logger.Error("failed", "err", err)
+	_, _, line, _ = runtime.Caller(0)
+	if source, _ := decodeRecord(t, buf)["source"].(map[string]any); source["line"] != float64(line-1) {
+		t.Errorf("source = %v without WithErrorSource, want the logging line %d", source, line-1)
+	}
+}
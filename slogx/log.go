@@ -0,0 +1,77 @@
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"slices"
+	"time"
+
+	"go.innotegrity.dev/xerrors"
+)
+
+var (
+	// _factory attributes the errors created by NewAndLog to its caller.
+	_factory = xerrors.NewFactory(xerrors.WithCallerSkip(1))
+)
+
+// NewAndLog creates a new [xerrors.Error] with the given code, message and attributes, logs it using the given logger
+// (or the default logger if it is nil) and returns it, for the common "log and return" pattern, eg:
+//
+//	if err != nil {
+//		return slogx.NewAndLog(logger, 1042, "quota exceeded", "tenant", tenantID)
+//	}
+//
+// The attributes are given in the same form as those of [slog.Logger.Log], ie: as key-value pairs or [slog.Attr]
+// values, and are added to the error rather than the record.  Both the error and the record are attributed to the
+// code which called NewAndLog, so the message and caller of the error are only rendered once: as the message and
+// source of the record, while the "error" group holds the remaining fields of the error (see [ErrorValue]).  The
+// record is logged at [slog.LevelError].
+func NewAndLog(logger *slog.Logger, code int, message string, attrs ...any) xerrors.Error {
+	err := _factory.New(code, message)
+	var record slog.Record
+	record.Add(attrs...)
+	record.Attrs(func(a slog.Attr) bool {
+		err.WithAttr(a.Key, attrValue(a.Value))
+		return true
+	})
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx := context.Background()
+	if !logger.Enabled(ctx, slog.LevelError) {
+		return err
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	record = slog.NewRecord(time.Now(), slog.LevelError, message, pcs[0])
+	record.AddAttrs(slog.Attr{Key: "error", Value: without(ErrorValue(err), "caller", "message")})
+	logger.Handler().Handle(ctx, record)
+	return err
+}
+
+// attrValue converts the value of a [slog.Attr] into the value of an error attribute, converting groups into
+// [xerrors.AttrGroup] values.
+func attrValue(v slog.Value) any {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	group := xerrors.AttrGroup{}
+	for _, a := range v.Group() {
+		group[a.Key] = attrValue(a.Value)
+	}
+	return group
+}
+
+// without returns the group produced by [ErrorValue] without the attributes with the given keys.
+func without(v slog.Value, keys ...string) slog.Value {
+	attrs := make([]slog.Attr, 0, len(v.Group()))
+	for _, a := range v.Group() {
+		if !slices.Contains(keys, a.Key) {
+			attrs = append(attrs, a)
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
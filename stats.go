@@ -0,0 +1,126 @@
+package xerrors
+
+import (
+	"encoding/json"
+	"expvar"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StatsCollector collects statistics on the errors it records.
+//
+// A collector keeps a count of errors by code and domain along with the most recently recorded errors.  Errors can
+// be recorded as they are created by registering [StatsCollector.Record] as a hook using [RegisterHook].
+//
+// The collector can be published as an expvar variable using [StatsCollector.Publish] or mounted as an
+// [http.Handler].
+type StatsCollector struct {
+	// unexported variables
+	byCode   map[int]uint64     // number of errors recorded by code
+	byDomain map[string]uint64  // number of errors recorded by domain
+	mutex    sync.Mutex         // guards the collector
+	recent   *ring[RecentError] // the most recently recorded errors
+	started  time.Time          // when the collector was created
+	total    uint64             // total number of errors recorded
+}
+
+// RecentError is an error which was recorded by a [StatsCollector] or [Recorder].
+type RecentError struct {
+	// Time is when the error was recorded.
+	Time time.Time `json:"time"`
+
+	// Error is the recorded error.
+	Error Error `json:"error"`
+}
+
+// Stats is a point-in-time snapshot of the statistics collected by a [StatsCollector].
+type Stats struct {
+	// ByCode is the number of errors recorded by code.
+	ByCode map[int]uint64 `json:"byCode"`
+
+	// ByDomain is the number of errors recorded by domain.  Errors without a domain are counted under the empty
+	// string.
+	ByDomain map[string]uint64 `json:"byDomain"`
+
+	// Recent contains the most recently recorded errors, from oldest to newest.
+	Recent []RecentError `json:"recent"`
+
+	// Started is when the collector was created.
+	Started time.Time `json:"started"`
+
+	// Total is the total number of errors recorded.
+	Total uint64 `json:"total"`
+}
+
+// NewStatsCollector creates a new [StatsCollector] which keeps up to the given number of recent errors.
+func NewStatsCollector(recent int) *StatsCollector {
+	return &StatsCollector{
+		byCode:   make(map[int]uint64),
+		byDomain: make(map[string]uint64),
+		recent:   newRing[RecentError](recent),
+		started:  time.Now(),
+	}
+}
+
+// Publish publishes the collector's statistics as an expvar variable with the given name.
+//
+// Like [expvar.Publish], this function panics if a variable with the same name has already been published.
+func (c *StatsCollector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Stats()
+	}))
+}
+
+// Record records the given error, keeping a copy of it among the recent errors so that the statistics can be served
+// safely while the error is still being modified; attributes added to the error afterwards are not included.
+//
+// This call is thread-safe.
+func (c *StatsCollector) Record(err Error) {
+	if err == nil {
+		return
+	}
+	now := time.Now()
+	err = snapshotError(err)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.total++
+	c.byCode[err.Code()]++
+	c.byDomain[err.Domain()]++
+	c.recent.add(RecentError{
+		Time:  now,
+		Error: err,
+	})
+}
+
+// ServeHTTP writes the collector's statistics to the response as JSON.
+func (c *StatsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(c.Stats())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// Stats returns a snapshot of the statistics collected so far.
+//
+// This call is thread-safe.
+func (c *StatsCollector) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := Stats{
+		ByCode:   make(map[int]uint64, len(c.byCode)),
+		ByDomain: make(map[string]uint64, len(c.byDomain)),
+		Recent:   c.recent.slice(),
+		Started:  c.started,
+		Total:    c.total,
+	}
+	maps.Copy(stats.ByCode, c.byCode)
+	maps.Copy(stats.ByDomain, c.byDomain)
+	return stats
+}
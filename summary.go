@@ -0,0 +1,64 @@
+package xerrors
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Summarize returns a single-line summary of the chain of the given error which is at most maxLen characters long,
+// eg: for UI toasts or chat alerts.
+//
+// The summary holds the messages of the errors in the chain, separated by ": ", followed by the code and unique ID
+// (if any) of the first [Error] in the chain, eg: "saving user: connection refused (code 12, id 01J9...)".  Line
+// breaks are replaced with spaces.  If the summary is too long, the messages are cut at a word boundary and end with
+// "…", while the code and ID are always kept.  A maxLen of 0 or less does not limit the length.
+func Summarize(err error, maxLen int) string {
+	if err == nil {
+		return ""
+	}
+	var parts []string
+	truncated := walkChain(err, func(err error) bool {
+		xerr, ok := err.(*xerr)
+		if !ok {
+			parts = append(parts, err.Error())
+			return false
+		}
+		if xerr.message != "" {
+			parts = append(parts, xerr.message)
+		}
+		return true
+	})
+	if truncated {
+		parts = append(parts, TruncationMarker)
+	}
+	message := strings.Join(strings.Fields(strings.Join(parts, ": ")), " ")
+
+	suffix := ""
+	var xerr Error
+	if errors.As(err, &xerr) {
+		suffix = " (code " + strconv.Itoa(xerr.Code())
+		if id := xerr.ID(); id != "" {
+			suffix += ", id " + id
+		}
+		suffix += ")"
+	}
+	if maxLen <= 0 || utf8.RuneCountInString(message)+utf8.RuneCountInString(suffix) <= maxLen {
+		return message + suffix
+	}
+
+	available := maxLen - utf8.RuneCountInString(suffix) - 1
+	if available < 1 {
+		runes := []rune(strings.TrimSpace(message + suffix))
+		return string(runes[:min(len(runes), maxLen)])
+	}
+	runes := []rune(message)
+	cut := string(runes[:available])
+	if runes[available] != ' ' {
+		if i := strings.LastIndexByte(cut, ' '); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, " :") + "…" + suffix
+}
@@ -0,0 +1,63 @@
+package xerrors
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	_swallowEnabled atomic.Bool // set while detection is enabled, so creating errors can skip the mutex
+	_swallowHandler func(Error)
+	_swallowMutex   sync.Mutex
+)
+
+// DetectSwallowedErrors enables a debug mode which calls the given handler for each error which is garbage-collected
+// without ever having been inspected, helping to find errors which are silently dropped.
+//
+// An error counts as inspected once its Error(), FullMessage(), Code(), Is() or Unwrap() method is called, it is
+// marshaled, or it is wrapped by another error or added to a [MultiError].  Inspection by hooks while the error is
+// being created does not count.  Enabling caller capture using [CaptureCallerInfo] makes it possible to tell where
+// a swallowed error was created.
+//
+// Detection relies on finalizers, so the handler is called from a separate goroutine at an unspecified time after the
+// error becomes unreachable, and may never be called for errors which are still reachable when the program exits.
+// Errors are only tracked if they are created while detection is enabled.  Passing nil disables detection.  This
+// is intended for development only.  This call is thread-safe.
+func DetectSwallowedErrors(handler func(err Error)) {
+	_swallowMutex.Lock()
+	_swallowHandler = handler
+	_swallowEnabled.Store(handler != nil)
+	_swallowMutex.Unlock()
+}
+
+// trackSwallowed starts tracking whether or not the given error is inspected if detection is enabled.
+func trackSwallowed(e *xerr) {
+	if !_swallowEnabled.Load() {
+		return
+	}
+	e.inspected.Store(false)
+	_swallowMutex.Lock()
+	handler := _swallowHandler
+	_swallowMutex.Unlock()
+	if handler == nil {
+		return
+	}
+	runtime.SetFinalizer(e, func(e *xerr) {
+		if !e.inspected.Load() {
+			handler(e)
+		}
+	})
+}
+
+// markInspected records that the error has been inspected.
+func (e *xerr) markInspected() {
+	e.inspected.Store(true)
+}
+
+// markWrappedInspected marks the given error as inspected if it was created by this package.
+func markWrappedInspected(err error) {
+	if xerr, ok := err.(*xerr); ok {
+		xerr.markInspected()
+	}
+}
@@ -0,0 +1,245 @@
+package xerrors
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// _buildIDMarker precedes the quoted build ID at the start of the text segment of Go binaries.
+	_buildIDMarker = "\xff Go build ID: \""
+
+	// _buildIDScanSize is the number of bytes of the binary searched for the build ID marker.
+	_buildIDScanSize = 64 << 10
+)
+
+var (
+	_buildID     string
+	_buildIDOnce sync.Once
+)
+
+// BuildID returns the Go build ID of the running binary, as reported by "go tool buildid", or an empty string if it
+// cannot be determined.
+//
+// The build ID identifies the binary whose symbol table is needed to symbolicate the stacks serialized with a
+// [MarshalProfile] which has Symbolicate set.  It is read from the executable once and cached.
+func BuildID() string {
+	_buildIDOnce.Do(func() {
+		if exe, err := os.Executable(); err == nil {
+			_buildID, _ = readBuildID(exe)
+		}
+	})
+	return _buildID
+}
+
+// readBuildID reads the Go build ID from the binary with the given name.
+func readBuildID(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// ELF binaries keep the build ID in a note, which is cheaper to find than scanning the text segment
+	if f, err := elf.NewFile(file); err == nil {
+		if section := f.Section(".note.go.buildid"); section != nil {
+			if data, err := section.Data(); err == nil && len(data) > 16 {
+				nameSize := f.ByteOrder.Uint32(data)
+				descSize := f.ByteOrder.Uint32(data[4:])
+				start := 12 + (int(nameSize)+3)&^3
+				end := start + int(descSize)
+				if end <= len(data) && string(bytes.TrimRight(data[12:12+nameSize], "\x00")) == "Go" {
+					return string(data[start:end]), nil
+				}
+			}
+		}
+	}
+
+	data := make([]byte, _buildIDScanSize)
+	n, err := io.ReadFull(file, data)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	data = data[:n]
+	i := bytes.Index(data, []byte(_buildIDMarker))
+	if i < 0 {
+		return "", errors.New("build ID not found")
+	}
+	id := data[i+len(_buildIDMarker):]
+	end := bytes.IndexByte(id, '"')
+	if end < 0 {
+		return "", errors.New("build ID not found")
+	}
+	return string(id[:end]), nil
+}
+
+// symbol is a function in a [SymbolTable].
+type symbol struct {
+	// addr is the entry address of the function.
+	addr uint64
+
+	// name is the fully-qualified name of the function.
+	name string
+}
+
+// SymbolTable maps the program counters of a binary to the functions, files and lines they belong to, so that the
+// stacks of stripped binaries can be symbolicated offline.
+//
+// Stacks must have been serialized with a [MarshalProfile] which has Symbolicate set, since only those frames carry
+// their program counters, and the table must have been loaded from an unstripped copy of the binary with the same
+// [BuildID].  The addresses of position-independent executables (eg: the default build mode on macOS) depend on
+// where the binary was loaded, so their stacks can only be symbolicated if the binary was built with
+// -buildmode=exe.
+type SymbolTable struct {
+	// unexported variables
+	buildID string       // build ID of the binary, if known
+	funcs   []symbol     // functions sorted by address
+	lines   *gosym.Table // line table, if loaded from a binary
+}
+
+// LoadSymbolTable loads the symbol table of the ELF or Mach-O binary with the given name.
+//
+// Tables loaded from a binary resolve the file and line of each frame as well as its function.
+func LoadSymbolTable(name string) (*SymbolTable, error) {
+	var text uint64
+	var symtab, pclntab []byte
+	if f, err := elf.Open(name); err == nil {
+		defer f.Close()
+		if section := f.Section(".text"); section != nil {
+			text = section.Addr
+		}
+		if section := f.Section(".gosymtab"); section != nil {
+			symtab, _ = section.Data()
+		}
+		if section := f.Section(".gopclntab"); section != nil {
+			if pclntab, err = section.Data(); err != nil {
+				return nil, fmt.Errorf("failed to read the line table of %s: %w", name, err)
+			}
+		}
+	} else if f, err := macho.Open(name); err == nil {
+		defer f.Close()
+		if section := f.Section("__text"); section != nil {
+			text = section.Addr
+		}
+		if section := f.Section("__gosymtab"); section != nil {
+			symtab, _ = section.Data()
+		}
+		if section := f.Section("__gopclntab"); section != nil {
+			if pclntab, err = section.Data(); err != nil {
+				return nil, fmt.Errorf("failed to read the line table of %s: %w", name, err)
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("%s is not an ELF or Mach-O binary", name)
+	}
+	if pclntab == nil {
+		return nil, fmt.Errorf("%s has no Go line table", name)
+	}
+	lines, err := gosym.NewTable(symtab, gosym.NewLineTable(pclntab, text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the line table of %s: %w", name, err)
+	}
+
+	t := &SymbolTable{lines: lines, funcs: make([]symbol, 0, len(lines.Funcs))}
+	t.buildID, _ = readBuildID(name)
+	for _, fn := range lines.Funcs {
+		t.funcs = append(t.funcs, symbol{addr: fn.Entry, name: fn.Name})
+	}
+	slices.SortFunc(t.funcs, func(a, b symbol) int {
+		return cmp.Compare(a.addr, b.addr)
+	})
+	return t, nil
+}
+
+// ParseSymbolTable parses a symbol table in the format written by "go tool nm" (with or without the -size and -n
+// flags), eg:
+//
+//	go tool nm -n app > app.syms
+//
+// Tables parsed from nm output only resolve the function of each frame, so the file and line written with the frame
+// are kept.  Lines which do not describe text symbols are skipped.
+func ParseSymbolTable(r io.Reader) (*SymbolTable, error) {
+	t := &SymbolTable{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 4 {
+			// the -size flag adds the size of the symbol after its address
+			fields = append(fields[:1], fields[2:]...)
+		}
+		if len(fields) != 3 || (fields[1] != "T" && fields[1] != "t") {
+			continue
+		}
+		addr, err := strconv.ParseUint(fields[0], 16, 64)
+		if err != nil {
+			continue
+		}
+		// assembly functions are listed with the suffix of their ABI, eg: runtime.goexit.abi0
+		t.funcs = append(t.funcs, symbol{addr: addr, name: strings.TrimSuffix(fields[2], ".abi0")})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read symbol table: %w", err)
+	}
+	slices.SortFunc(t.funcs, func(a, b symbol) int {
+		return cmp.Compare(a.addr, b.addr)
+	})
+	return t, nil
+}
+
+// BuildID returns the build ID of the binary the table was loaded from or an empty string if it is not known, eg:
+// for tables parsed from nm output.
+func (t *SymbolTable) BuildID() string {
+	return t.buildID
+}
+
+// Symbolicate returns a copy of the stack with the function, package and receiver of each frame resolved from its
+// program counter and, if the table was loaded from a binary, its file and line.
+//
+// Frames without a program counter, whose program counter is not in the table or whose function entry does not match
+// the one in the table (eg: because the table belongs to a different binary) are returned unchanged.  File names
+// resolved from a binary are formatted in the same way as those of captured frames (see [StripCallerFilePrefixes]).
+func (t *SymbolTable) Symbolicate(stack []CallerInfo) []CallerInfo {
+	if stack == nil {
+		return nil
+	}
+	resolved := make([]CallerInfo, len(stack))
+	for i, frame := range stack {
+		resolved[i] = frame
+		if frame.PC == 0 {
+			continue
+		}
+		pc := uint64(frame.PC)
+		if t.lines != nil {
+			file, line, fn := t.lines.PCToLine(pc)
+			if fn != nil && (frame.Entry == 0 || uint64(frame.Entry) == fn.Entry) {
+				resolved[i] = *newCallerInfo(fn.Name, file, line, frame.PC, uintptr(fn.Entry))
+				continue
+			}
+		}
+		j, found := slices.BinarySearchFunc(t.funcs, pc, func(s symbol, pc uint64) int {
+			return cmp.Compare(s.addr, pc)
+		})
+		if !found {
+			j--
+		}
+		if j < 0 || (frame.Entry != 0 && uint64(frame.Entry) != t.funcs[j].addr) {
+			continue
+		}
+		info := newCallerInfo(t.funcs[j].name, frame.File, frame.Line, frame.PC, uintptr(t.funcs[j].addr))
+		info.File = frame.File
+		resolved[i] = *info
+	}
+	return resolved
+}
@@ -0,0 +1,260 @@
+package xerrors
+
+import (
+	"encoding/binary"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSyslogSDID is the default ID of the structured data element holding the error details in syslog
+	// messages.
+	//
+	// The ID uses the private enterprise number reserved for documentation (see RFC 5612); set the ID for your own
+	// organization using [WithSyslogSDID].
+	DefaultSyslogSDID = "xerrors@32473"
+
+	// SyslogFacilityUser is the syslog facility for user-level messages, which is used by default.
+	SyslogFacilityUser = 1
+
+	// _syslogNil is the value of syslog header fields which are not known.
+	_syslogNil = "-"
+)
+
+// SyslogPriority returns the syslog severity level (see RFC 5424) of the severity, eg: 3 (error) for
+// [SeverityError].
+//
+// Unknown severities are mapped to the error level.
+func (s Severity) SyslogPriority() int {
+	switch s {
+	case SeverityDebug:
+		return 7
+	case SeverityInfo:
+		return 6
+	case SeverityWarning:
+		return 4
+	case SeverityCritical:
+		return 2
+	}
+	return 3
+}
+
+// SyslogFormatter renders errors as RFC 5424 syslog messages, for services deployed on hosts without a log shipper.
+//
+// The details of the error are written as a structured data element, eg:
+//
+//	<11>1 2024-05-01T10:00:00.000000Z host app 1234 12 [xerrors@32473 code="12" domain="billing" user="bob"] not found
+//
+// The attributes use dotted keys for groups and are written with the marshal profile of the factory which created
+// the error.  Keys are cut to 32 characters and the characters which are not allowed in parameter names are replaced
+// with underscores.
+type SyslogFormatter struct {
+	// unexported variables
+	appName  string // name of the application
+	facility int    // syslog facility
+	hostname string // name of the host
+	sdID     string // ID of the structured data element
+}
+
+// SyslogOption is a function which configures a [SyslogFormatter].
+type SyslogOption func(*SyslogFormatter)
+
+// WithSyslogAppName sets the name of the application in the messages.  The default is the name of the executable.
+func WithSyslogAppName(name string) SyslogOption {
+	return func(f *SyslogFormatter) {
+		f.appName = name
+	}
+}
+
+// WithSyslogFacility sets the syslog facility of the messages (0 to 23).  The default is [SyslogFacilityUser].
+func WithSyslogFacility(facility int) SyslogOption {
+	return func(f *SyslogFormatter) {
+		f.facility = min(max(facility, 0), 23)
+	}
+}
+
+// WithSyslogHostname sets the name of the host in the messages.  The default is the name reported by the kernel.
+func WithSyslogHostname(hostname string) SyslogOption {
+	return func(f *SyslogFormatter) {
+		f.hostname = hostname
+	}
+}
+
+// WithSyslogSDID sets the ID of the structured data element holding the error details.  The default is
+// [DefaultSyslogSDID].
+func WithSyslogSDID(id string) SyslogOption {
+	return func(f *SyslogFormatter) {
+		f.sdID = id
+	}
+}
+
+// NewSyslogFormatter creates a new [SyslogFormatter] with the given options.
+func NewSyslogFormatter(opts ...SyslogOption) *SyslogFormatter {
+	f := &SyslogFormatter{
+		facility: SyslogFacilityUser,
+		sdID:     DefaultSyslogSDID,
+	}
+	if exe, err := os.Executable(); err == nil {
+		f.appName = exe[strings.LastIndexByte(exe, os.PathSeparator)+1:]
+	}
+	f.hostname, _ = os.Hostname()
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Format returns the syslog message for the given error, without a trailing newline.
+func (f *SyslogFormatter) Format(err Error) []byte {
+	dst := []byte{'<'}
+	dst = strconv.AppendInt(dst, int64(f.facility*8+err.Severity().SyslogPriority()), 10)
+	dst = append(dst, ">1 "...)
+	dst = time.Now().UTC().AppendFormat(dst, "2006-01-02T15:04:05.000000Z07:00")
+	dst = append(dst, ' ')
+	dst = appendSyslogHeader(dst, f.hostname, 255)
+	dst = append(dst, ' ')
+	dst = appendSyslogHeader(dst, f.appName, 48)
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, int64(os.Getpid()), 10)
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, int64(err.Code()), 10)
+	dst = append(dst, " ["...)
+	dst = append(dst, f.sdID...)
+	forEachField(err, func(key, value string) {
+		dst = append(dst, ' ')
+		dst = append(dst, syslogParamName(key)...)
+		dst = append(dst, `="`...)
+		dst = append(dst, syslogParamValue(value)...)
+		dst = append(dst, '"')
+	})
+	dst = append(dst, "] "...)
+	return append(dst, strings.Join(strings.Fields(err.Error()), " ")...)
+}
+
+// MarshalJournald marshals the given error to the native protocol of the systemd journal, so that it can be sent to
+// the journal socket (/run/systemd/journal/socket) as a single datagram.
+//
+// The error message and the severity are written in the MESSAGE and PRIORITY fields and the caller in the CODE_FILE,
+// CODE_LINE and CODE_FUNC fields.  The code, domain, id, kind, op and attributes of the error are written in fields
+// prefixed with "ERROR_", eg: ERROR_CODE or ERROR_DB_QUERY, with the characters which are not allowed in field names
+// replaced with underscores.
+func MarshalJournald(err Error) []byte {
+	dst := appendJournaldField(nil, "MESSAGE", err.Error())
+	dst = appendJournaldField(dst, "PRIORITY", strconv.Itoa(err.Severity().SyslogPriority()))
+	if caller := err.Caller(); caller != *DefaultCallerInfo() {
+		dst = appendJournaldField(dst, "CODE_FILE", caller.File)
+		dst = appendJournaldField(dst, "CODE_LINE", strconv.Itoa(caller.Line))
+		dst = appendJournaldField(dst, "CODE_FUNC", caller.Func)
+	}
+	forEachField(err, func(key, value string) {
+		dst = appendJournaldField(dst, journaldFieldName(key), value)
+	})
+	return dst
+}
+
+// forEachField calls fn for the code, domain, id, kind, op and severity of the error, followed by its attributes.
+func forEachField(err Error, fn func(key, value string)) {
+	fn("code", strconv.Itoa(err.Code()))
+	if domain := err.Domain(); domain != "" {
+		fn("domain", domain)
+	}
+	if id := err.ID(); id != "" {
+		fn("id", id)
+	}
+	if kind := err.Kind(); kind != "" {
+		fn("kind", string(kind))
+	}
+	if op := opPath(err); op != "" {
+		fn("op", op)
+	}
+	if severity := err.Severity(); severity != SeverityUnknown {
+		fn("severity", severity.String())
+	}
+	xerr, ok := err.(*xerr)
+	if !ok {
+		return
+	}
+	profile := xerr.profile.resolve()
+	if len(xerr.attrs) > 0 && !profile.OmitAttrs {
+		visitAttrs(profile, xerr.classes, "", "", xerr.attrs, func(key string, value any) {
+			fn(key, logfmtValue(value))
+		})
+	}
+}
+
+// appendSyslogHeader appends a syslog header field to dst, cut to the given length and with any characters which
+// are not printable US-ASCII removed.  Empty fields are written as "-".
+func appendSyslogHeader(dst []byte, value string, limit int) []byte {
+	start := len(dst)
+	for i := 0; i < len(value) && len(dst)-start < limit; i++ {
+		if c := value[i]; c > ' ' && c < 0x7f {
+			dst = append(dst, c)
+		}
+	}
+	if len(dst) == start {
+		dst = append(dst, _syslogNil...)
+	}
+	return dst
+}
+
+// syslogParamName returns the key as a structured data parameter name, which consists of up to 32 printable
+// US-ASCII characters other than '=', ' ', ']' and '"'.
+func syslogParamName(key string) string {
+	name := []byte(key)
+	for i, c := range name {
+		if c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"' {
+			name[i] = '_'
+		}
+	}
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return string(name)
+}
+
+// syslogParamValue returns the value with the '"', '\' and ']' characters escaped and line breaks replaced with spaces.
+func syslogParamValue(value string) string {
+	var sb strings.Builder
+	for _, r := range value {
+		switch r {
+		case '"', '\\', ']':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n', '\r':
+			sb.WriteByte(' ')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// journaldFieldName returns the key as a journal field name prefixed with "ERROR_", which consists of upper case
+// letters, digits and underscores.
+func journaldFieldName(key string) string {
+	name := []byte("ERROR_" + strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
+
+// appendJournaldField appends a field to dst in the native journal protocol.
+//
+// Values containing line breaks are written in the binary form, ie: the name followed by a line break, the length
+// of the value as a 64-bit little-endian integer and the value itself.
+func appendJournaldField(dst []byte, name, value string) []byte {
+	dst = append(dst, name...)
+	if strings.ContainsRune(value, '\n') {
+		dst = append(dst, '\n')
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(len(value)))
+	} else {
+		dst = append(dst, '=')
+	}
+	dst = append(dst, value...)
+	return append(dst, '\n')
+}
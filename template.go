@@ -0,0 +1,109 @@
+package xerrors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	_maxTemplates = 4096
+)
+
+var (
+	_templates templateCache
+)
+
+// Template creates errors which share the same code and message.
+//
+// Templates are interned: interning the same code and message again returns the same template.  Creating an error
+// from a template does not format the message and shares it, along with the settings of the factory, with the
+// other errors created from the template, so it allocates no more than [New] does.
+//
+// Each cache (the global one and the one of each factory) holds up to 4096 templates.  Once a cache is full, new
+// templates are returned without being interned, so that interning messages built from unbounded input (eg: request
+// data) cannot grow memory without bound.
+type Template struct {
+	// unexported variables
+	code    int      // the error code
+	factory *Factory // factory which creates the errors or nil for the package-level defaults
+	message string   // the error message
+}
+
+// templateCache holds interned templates.
+type templateCache struct {
+	// unexported variables
+	entries sync.Map     // templates keyed by templateKey
+	size    atomic.Int64 // number of interned templates
+}
+
+// templateKey identifies an interned template.
+type templateKey struct {
+	code    int
+	message string
+}
+
+// Intern returns the [Template] for the given code and message, creating it if necessary.
+//
+// Errors created from the template behave like errors created by [New].  This call is thread-safe.
+func Intern(code int, message string) *Template {
+	return intern(&_templates, nil, code, message)
+}
+
+// Intern returns the [Template] for the given code and message, creating it if necessary.
+//
+// Errors created from the template behave like errors created by the factory.  This call is thread-safe.
+func (f *Factory) Intern(code int, message string) *Template {
+	return intern(f.templates, f, code, message)
+}
+
+// intern returns the template for the given code and message from the cache, creating it if necessary.
+//
+// The template is not stored if the cache is full.
+func intern(cache *templateCache, f *Factory, code int, message string) *Template {
+	key := templateKey{code: code, message: message}
+	if t, ok := cache.entries.Load(key); ok {
+		return t.(*Template)
+	}
+	t := &Template{
+		code:    code,
+		factory: f,
+		message: message,
+	}
+	if cache.size.Add(1) > _maxTemplates {
+		cache.size.Add(-1)
+		return t
+	}
+	actual, loaded := cache.entries.LoadOrStore(key, t)
+	if loaded {
+		cache.size.Add(-1)
+	}
+	return actual.(*Template)
+}
+
+// Code returns the code of the errors created from the template.
+func (t *Template) Code() int {
+	return t.code
+}
+
+// Message returns the message of the errors created from the template.
+func (t *Template) Message() string {
+	return t.message
+}
+
+// New creates a new [Error] from the template.
+func (t *Template) New() Error {
+	return newError(nil, t.factory, t.callerSkip(), t.code, t.message, nil)
+}
+
+// Wrap wraps the given error in a new [Error] created from the template.
+func (t *Template) Wrap(err error) Error {
+	return newError(nil, t.factory, t.callerSkip(), t.code, t.message, err)
+}
+
+// callerSkip returns the number of additional stack frames skipped by the template's factory.
+func (t *Template) callerSkip() int {
+	if t.factory == nil {
+		return 0
+	}
+	return t.factory.callerSkip
+}
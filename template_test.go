@@ -0,0 +1,65 @@
+package xerrors
+
+import (
+	"testing"
+)
+
+var (
+	_benchTemplate = Intern(1042, "quota exceeded")
+)
+
+func BenchmarkTemplateNew(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = _benchTemplate.New()
+	}
+}
+
+func BenchmarkTemplateWrap(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchErr = _benchTemplate.Wrap(_benchCause)
+	}
+}
+
+func BenchmarkIntern(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_benchTemplate = Intern(1042, "quota exceeded")
+	}
+}
+
+func TestInternReturnsSameTemplate(t *testing.T) {
+	f := NewFactory(WithDomain("billing"))
+	if f.Intern(1, "message") != f.Intern(1, "message") {
+		t.Error("interning the same code and message returned different templates")
+	}
+	if f.Intern(1, "message") == Intern(1, "message") {
+		t.Error("factory and global templates share a cache")
+	}
+	if err := f.Intern(1, "message").New(); err.Domain() != "billing" || err.Code() != 1 || err.Error() != "message" {
+		t.Errorf("unexpected error created from template: %s", err.String())
+	}
+}
+
+func TestInternIsBounded(t *testing.T) {
+	f := NewFactory()
+	for i := range _maxTemplates {
+		f.Intern(i, "message")
+	}
+	if size := f.templates.size.Load(); size != _maxTemplates {
+		t.Fatalf("expected %d interned templates, got %d", _maxTemplates, size)
+	}
+	if f.Intern(_maxTemplates, "message") == f.Intern(_maxTemplates, "message") {
+		t.Error("full cache interned a new template")
+	}
+	if f.Intern(0, "message") != f.Intern(0, "message") {
+		t.Error("full cache did not return an interned template")
+	}
+	if err := f.Intern(_maxTemplates, "message").New(); err.Code() != _maxTemplates {
+		t.Errorf("expected code %d, got %d", _maxTemplates, err.Code())
+	}
+	if size := f.templates.size.Load(); size != _maxTemplates {
+		t.Errorf("expected %d interned templates, got %d", _maxTemplates, size)
+	}
+}
@@ -0,0 +1,337 @@
+package xerrors
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LinkStyle controls how caller and stack frame locations are linked in the output of a [TextFormatter].
+type LinkStyle int
+
+const (
+	// LinkNone renders locations as plain "file:line" text, which most terminals and IDEs already recognize.
+	LinkNone LinkStyle = iota
+
+	// LinkOSC8 renders locations as OSC 8 terminal hyperlinks to file:// URLs.
+	LinkOSC8
+
+	// LinkVSCode renders locations as OSC 8 terminal hyperlinks to vscode://file URIs, which open the location in
+	// Visual Studio Code.
+	LinkVSCode
+)
+
+const (
+	_ansiReset = "\x1b[0m"
+)
+
+// Theme holds the ANSI escape sequences used by a [TextFormatter] to colorize the parts of its output.
+//
+// An empty sequence leaves the corresponding part uncolored.
+type Theme struct {
+	// Code is used for the header line holding the error code.
+	Code string
+
+	// Label is used for field labels and "caused by" prefixes.
+	Label string
+
+	// Location is used for caller and stack frame locations.
+	Location string
+
+	// SeverityLow is used for debug and info severities.
+	SeverityLow string
+
+	// SeverityWarning is used for the warning severity.
+	SeverityWarning string
+
+	// SeverityHigh is used for error and critical severities.
+	SeverityHigh string
+}
+
+// DefaultTheme returns the theme used when no other theme has been configured.
+func DefaultTheme() Theme {
+	return Theme{
+		Code:            "\x1b[1;31m",
+		Label:           "\x1b[2m",
+		Location:        "\x1b[36m",
+		SeverityLow:     "\x1b[34m",
+		SeverityWarning: "\x1b[33m",
+		SeverityHigh:    "\x1b[31m",
+	}
+}
+
+// TextFormatter renders errors as human-readable, multi-line text for terminals, eg: when debugging locally or in
+// CLI output.
+type TextFormatter struct {
+	// unexported variables
+	color    bool      // whether or not ANSI colors are used
+	links    LinkStyle // how locations are linked
+	registry *Registry // registry used to look up documentation URLs, if any
+	theme    Theme     // colors used when colors are enabled
+	width    int       // maximum width of the output lines or 0 for no wrapping
+}
+
+// TextOption is a function which configures a [TextFormatter].
+type TextOption func(*TextFormatter)
+
+// WithAutoColor enables colors if the given writer is a terminal and colors have not been disabled using the NO_COLOR
+// environment variable (see https://no-color.org) or a TERM of "dumb".
+//
+// The width of the output is also set from the COLUMNS environment variable, if it is set and the writer is a
+// terminal.
+func WithAutoColor(w io.Writer) TextOption {
+	return func(f *TextFormatter) {
+		file, ok := w.(*os.File)
+		if !ok {
+			return
+		}
+		info, err := file.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return
+		}
+		_, noColor := os.LookupEnv("NO_COLOR")
+		f.color = !noColor && os.Getenv("TERM") != "dumb"
+		if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+			f.width = columns
+		}
+	}
+}
+
+// WithColor controls whether the formatter colorizes codes, severities and locations using ANSI escape sequences.
+func WithColor(enable bool) TextOption {
+	return func(f *TextFormatter) {
+		f.color = enable
+	}
+}
+
+// WithLinks sets how the formatter links caller and stack frame locations.
+func WithLinks(style LinkStyle) TextOption {
+	return func(f *TextFormatter) {
+		f.links = style
+	}
+}
+
+// WithRegistry sets the registry used to look up the documentation URLs of the error codes (see
+// [Definition.DocsURL]), which are rendered as "see https://..." lines.
+func WithRegistry(registry *Registry) TextOption {
+	return func(f *TextFormatter) {
+		f.registry = registry
+	}
+}
+
+// WithTheme sets the colors used by the formatter when colors are enabled.
+func WithTheme(theme Theme) TextOption {
+	return func(f *TextFormatter) {
+		f.theme = theme
+	}
+}
+
+// WithWidth wraps messages and attribute values so that the output lines are at most the given width, where
+// possible.  Continuation lines are indented to line up with the start of the wrapped text.  A width of 0 disables
+// wrapping.
+func WithWidth(width int) TextOption {
+	return func(f *TextFormatter) {
+		f.width = max(width, 0)
+	}
+}
+
+// NewTextFormatter creates a new [TextFormatter] with the given options.
+//
+// By default, the output is not colorized or wrapped and locations are not linked.
+func NewTextFormatter(opts ...TextOption) *TextFormatter {
+	f := &TextFormatter{
+		theme: DefaultTheme(),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Format renders the given error and the errors in its chain.
+//
+// Each [Error] is rendered as a header line with its code, position (eg: "config.yaml:12:3") and message followed by
+// indented lines holding its domain, kind, op, severity, caller, hints (under "try:"), documentation URL, attributes
+// and stack trace.  Wrapped errors follow on "caused by" lines.  Other errors end the output, since they are
+// expected to include the messages of the errors they wrap.
+func (f *TextFormatter) Format(err error) string {
+	var sb strings.Builder
+	truncated := walkChain(err, func(err error) bool {
+		prefix := ""
+		if sb.Len() > 0 {
+			prefix = "caused by: "
+			sb.WriteString(f.paint(f.theme.Label, prefix))
+		}
+		xerr, ok := err.(Error)
+		if !ok {
+			sb.WriteString(f.wrap(err.Error(), len(prefix)))
+			sb.WriteByte('\n')
+			return false
+		}
+		f.writeError(&sb, len(prefix), xerr)
+		return true
+	})
+	if truncated {
+		sb.WriteString(f.paint(f.theme.Label, "caused by: "+TruncationMarker))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// writeError writes a single error of the chain.
+//
+// The offset is the width of any text which precedes the header line.
+func (f *TextFormatter) writeError(sb *strings.Builder, offset int, err Error) {
+	message := err.Error()
+	if xerr, ok := err.(*xerr); ok {
+		message = xerr.message
+	}
+	header := "error " + strconv.Itoa(err.Code())
+	sb.WriteString(f.paint(f.theme.Code, header))
+	if position := err.Position(); position != (Position{}) {
+		message = position.String() + ": " + message
+	}
+	if message != "" {
+		sb.WriteString(": ")
+		sb.WriteString(f.wrap(message, offset+len(header)+2))
+	}
+	sb.WriteByte('\n')
+
+	if domain := err.Domain(); domain != "" {
+		f.writeField(sb, "domain", domain)
+	}
+	if kind := err.Kind(); kind != "" {
+		f.writeField(sb, "kind", string(kind))
+	}
+	if op := err.Op(); op != "" {
+		f.writeField(sb, "op", op)
+	}
+	if severity := err.Severity(); severity != SeverityUnknown {
+		f.writeField(sb, "severity", f.paint(f.severityColor(severity), severity.String()))
+	}
+	if caller := err.Caller(); caller != *DefaultCallerInfo() {
+		f.writeField(sb, "caller", f.location(caller))
+	}
+	if hints := err.Hints(); len(hints) > 0 {
+		sb.WriteString("  " + f.paint(f.theme.Label, "try:") + "\n")
+		for _, hint := range hints {
+			sb.WriteString("    - " + f.wrap(hint, 6) + "\n")
+		}
+	}
+	if f.registry != nil {
+		if def, ok := f.registry.Lookup(err.Code()); ok && def.DocsURL != "" {
+			sb.WriteString("  " + f.paint(f.theme.Label, "see") + " " + def.DocsURL + "\n")
+		}
+	}
+	if attrs := err.Attrs(); len(attrs) > 0 {
+		sb.WriteString("  " + f.paint(f.theme.Label, "attrs:") + "\n")
+		f.writeAttrs(sb, "    ", attrs)
+	}
+	if stack := err.StackTrace(); len(stack) > 0 {
+		sb.WriteString("  " + f.paint(f.theme.Label, "stack:") + "\n")
+		for _, frame := range stack {
+			sb.WriteString("    " + f.location(frame) + "\n")
+		}
+	}
+}
+
+// writeField writes an indented "name: value" line.
+func (f *TextFormatter) writeField(sb *strings.Builder, name, value string) {
+	sb.WriteString("  " + f.paint(f.theme.Label, name+":") + " " + value + "\n")
+}
+
+// location renders the location of a stack frame as "file:line (func)", linked according to the link style.
+func (f *TextFormatter) location(c CallerInfo) string {
+	text := f.paint(f.theme.Location, c.File+":"+strconv.Itoa(c.Line))
+	file := filepath.ToSlash(c.File)
+	if !strings.HasPrefix(file, "/") {
+		file = "/" + file
+	}
+	var target string
+	switch f.links {
+	case LinkOSC8:
+		target = (&url.URL{Scheme: "file", Path: file}).String()
+	case LinkVSCode:
+		target = "vscode://file" + (&url.URL{Path: file}).EscapedPath() + ":" + strconv.Itoa(c.Line)
+	}
+	if target != "" {
+		text = "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+	}
+	return text + " (" + c.shortFunc() + ")"
+}
+
+// paint wraps the text in the given ANSI escape sequence if colors are enabled.
+func (f *TextFormatter) paint(style, text string) string {
+	if !f.color || style == "" {
+		return text
+	}
+	return style + text + _ansiReset
+}
+
+// wrap wraps the text so that its lines fit within the width, given that the first line starts at the given column.
+//
+// Continuation lines, including those of text which already spans several lines, are indented to the same column.
+// Words which are longer than the available width are broken.
+func (f *TextFormatter) wrap(text string, column int) string {
+	available := f.width - column
+	if f.width == 0 || available < 1 || utf8.RuneCountInString(text) <= available {
+		return strings.ReplaceAll(text, "\n", "\n"+strings.Repeat(" ", column))
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line []rune
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+			if len(line) > 0 && len(line)+1+len(runes) > available {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			for len(line)+len(runes) > available {
+				n := available - len(line)
+				lines = append(lines, string(append(line, runes[:n]...)))
+				line, runes = nil, runes[n:]
+			}
+			line = append(line, runes...)
+		}
+		lines = append(lines, string(line))
+	}
+	return strings.Join(lines, "\n"+strings.Repeat(" ", column))
+}
+
+// severityColor returns the ANSI color used for the given severity.
+func (f *TextFormatter) severityColor(severity Severity) string {
+	switch {
+	case severity >= SeverityError:
+		return f.theme.SeverityHigh
+	case severity == SeverityWarning:
+		return f.theme.SeverityWarning
+	}
+	return f.theme.SeverityLow
+}
+
+// writeAttrs writes the attributes in sorted order, one "key = value" line each, with groups indented further.
+func (f *TextFormatter) writeAttrs(sb *strings.Builder, indent string, attrs map[string]any) {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if group, ok := attrs[k].(AttrGroup); ok {
+			sb.WriteString(indent + k + ":\n")
+			f.writeAttrs(sb, indent+"  ", group)
+			continue
+		}
+		prefix := indent + k + " = "
+		sb.WriteString(prefix + f.wrap(fmt.Sprintf("%v", attrs[k]), utf8.RuneCountInString(prefix)) + "\n")
+	}
+}
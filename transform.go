@@ -0,0 +1,136 @@
+package xerrors
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	_transformers        = []Transformer{}
+	_transformersEnabled atomic.Bool // set while any transformers are registered, so boundaries can skip the mutex
+	_transformersMutex   sync.Mutex
+)
+
+// Transformer is a function which rewrites an [Error] before it leaves the process, eg: to redact attributes,
+// translate messages or add deployment details.
+//
+// The error passed to a transformer created by this package is a copy of the original error, so the transformer may
+// modify it (eg: using WithAttr) and return it without affecting the code which still holds the original.  The values
+// of the attributes are shared with the original, so they must be replaced rather than modified.
+type Transformer func(Error) Error
+
+// RegisterTransformer adds a transformer to the chain applied by [Transform], so that cross-cutting policies which
+// apply at every boundary are implemented in one place.  Transformers are applied in the order they are registered.
+//
+// The chain is applied by [Encode], [Sink], [Dispatcher], [ReportAndWrap] and the writers of the httpx package, but
+// not by the marshaling methods of the errors themselves, which transformers may call.  This function affects all
+// errors globally.  This call is thread-safe.
+func RegisterTransformer(transformer Transformer) {
+	_transformersMutex.Lock()
+	_transformers = append(_transformers, transformer)
+	_transformersEnabled.Store(true)
+	_transformersMutex.Unlock()
+}
+
+// ResetTransformers removes all transformers which were added using [RegisterTransformer].
+//
+// This call is thread-safe.
+func ResetTransformers() {
+	_transformersMutex.Lock()
+	_transformers = []Transformer{}
+	_transformersEnabled.Store(false)
+	_transformersMutex.Unlock()
+}
+
+// Transform applies the registered transformers (see [RegisterTransformer]) to a copy of the given error and returns
+// the result, for use by custom boundaries such as reporters and encoders.
+//
+// The error is returned unchanged if no transformers are registered, if it is nil or if it is already the result of
+// Transform, so that errors which pass through several boundaries (eg: [ReportAndWrap] and a [Dispatcher]) are only
+// transformed once.  A transformer returning nil leaves the error unchanged.
+func Transform(err Error) Error {
+	if err == nil || !_transformersEnabled.Load() {
+		return err
+	}
+	if e, ok := err.(*xerr); ok {
+		if e.transformed {
+			return err
+		}
+		err = e.clone()
+	}
+
+	_transformersMutex.Lock()
+	transformers := _transformers
+	_transformersMutex.Unlock()
+	for _, transformer := range transformers {
+		if transformed := transformer(err); transformed != nil {
+			err = transformed
+		}
+	}
+	if e, ok := err.(*xerr); ok {
+		e.transformed = true
+	}
+	return err
+}
+
+// clone returns a copy of the error whose attributes, classifications and slices can be modified independently of
+// the original.  Hooks are not called for the copy and it is not tracked as a swallowed error.
+func (e *xerr) clone() *xerr {
+	c := &xerr{
+		xerrExt:    e.xerrExt,
+		attrs:      maps.Clone(e.attrs),
+		code:       e.code,
+		compose:    e.compose,
+		message:    e.message,
+		wrappedErr: e.wrappedErr,
+	}
+	if !e.xerrExt.shared {
+		c.xerrExt = e.xerrExt.copy()
+	}
+	c.inspected.Store(true)
+	return c
+}
+
+// snapshot returns a deep copy of the error, including its attribute groups and the errors created by this package
+// in its chain and in its attributes, so that the copy can be read on another goroutine while the original is still
+// being modified, eg: using WithAttr.
+//
+// Up to depth errors of the chain are copied; the errors beyond them are shared with the original.
+func (e *xerr) snapshot(depth int) *xerr {
+	c := e.clone()
+	for k, v := range c.attrs {
+		c.attrs[k] = snapshotAttr(v, depth)
+	}
+	if wrapped, ok := c.wrappedErr.(*xerr); ok && depth > 1 {
+		c.wrappedErr = wrapped.snapshot(depth - 1)
+	}
+	return c
+}
+
+// snapshotAttr returns a deep copy of the given attribute value if it is a group or an error created by this
+// package, or the value itself otherwise.
+func snapshotAttr(value any, depth int) any {
+	switch v := value.(type) {
+	case AttrGroup:
+		group := make(AttrGroup, len(v))
+		for k, gv := range v {
+			group[k] = snapshotAttr(gv, depth)
+		}
+		return group
+	case *xerr:
+		if depth > 1 {
+			return v.snapshot(depth - 1)
+		}
+	}
+	return value
+}
+
+// snapshotError returns a snapshot of the given error (see snapshot) if it was created by this package or the error
+// itself otherwise.
+func snapshotError(err Error) Error {
+	if xerr, ok := err.(*xerr); ok {
+		return xerr.snapshot(loadConfig().MaxChainDepth)
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package xerrors
+
+import (
+	"sync"
+)
+
+// Translation is the externally published form of an internal error code.
+type Translation struct {
+	// Code is the externally published code (eg: "E-API-400").
+	Code string `json:"code"`
+
+	// Message replaces the internal error message if it is not empty.
+	Message string `json:"message,omitempty"`
+}
+
+// Translator rewrites internal error codes and messages into their externally published forms before errors are
+// serialized, so that internal refactors do not leak through an API contract.
+//
+// A translator is applied by setting it on the [MarshalProfile] used to marshal errors for external consumers.
+type Translator struct {
+	// unexported variables
+	fallback     *Translation        // translation used for codes without a translation, if any
+	mutex        sync.RWMutex        // guards the translator
+	translations map[int]Translation // translations by internal code
+}
+
+// NewTranslator creates a new empty [Translator].
+func NewTranslator() *Translator {
+	return &Translator{
+		translations: make(map[int]Translation),
+	}
+}
+
+// Add adds the translation for the given internal code and returns the translator.
+//
+// This call is thread-safe.
+func (t *Translator) Add(code int, translation Translation) *Translator {
+	t.mutex.Lock()
+	t.translations[code] = translation
+	t.mutex.Unlock()
+	return t
+}
+
+// SetFallback sets the translation used for internal codes which do not have a translation and returns the
+// translator.
+//
+// Without a fallback, such codes are emitted unchanged.  This call is thread-safe.
+func (t *Translator) SetFallback(translation Translation) *Translator {
+	t.mutex.Lock()
+	t.fallback = &translation
+	t.mutex.Unlock()
+	return t
+}
+
+// Translate returns the translation for the given error's code or the fallback translation.
+//
+// The second return value is false if the code has no translation and there is no fallback.  This call is
+// thread-safe.
+func (t *Translator) Translate(err Error) (Translation, bool) {
+	return t.translate(err.Code())
+}
+
+// translate returns the translation for the given code or the fallback translation.
+func (t *Translator) translate(code int) (Translation, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if translation, ok := t.translations[code]; ok {
+		return translation, true
+	}
+	if t.fallback != nil {
+		return *t.fallback, true
+	}
+	return Translation{}, false
+}
@@ -0,0 +1,119 @@
+package xerrors
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"unicode/utf8"
+)
+
+const (
+	_maxTruncateDepth = 32
+)
+
+var (
+	_jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	_textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// truncateAttr returns the attribute value cut down to the limits set by the profile.
+//
+// Only strings, byte slices, maps with string keys and slices are truncated, including those nested inside maps and
+// slices.  Values which implement [json.Marshaler] or [encoding.TextMarshaler] are returned unchanged.
+func (p *MarshalProfile) truncateAttr(value any) any {
+	if p.MaxAttrBytes <= 0 && p.MaxAttrEntries <= 0 {
+		return value
+	}
+	return p.truncateValue(value, 0)
+}
+
+// truncateValue truncates the given value, descending into maps and slices up to a fixed depth.
+func (p *MarshalProfile) truncateValue(value any, depth int) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return p.truncateString(v)
+	case []byte:
+		return p.truncateBytes(v)
+	}
+	if depth >= _maxTruncateDepth {
+		return value
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Type().Implements(_jsonMarshalerType) || rv.Type().Implements(_textMarshalerType) {
+		return value
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || rv.IsNil() {
+			return value
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		slices.Sort(keys)
+		dropped := 0
+		if p.MaxAttrEntries > 0 && len(keys) > p.MaxAttrEntries {
+			dropped = len(keys) - p.MaxAttrEntries
+			keys = keys[:p.MaxAttrEntries]
+		}
+		m := make(map[string]any, len(keys)+1)
+		for _, k := range keys {
+			m[k] = p.truncateValue(rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface(), depth+1)
+		}
+		if dropped > 0 {
+			m[TruncationMarker] = dropped
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return value
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return p.truncateBytes(rv.Bytes())
+		}
+		n := rv.Len()
+		truncated := p.MaxAttrEntries > 0 && n > p.MaxAttrEntries
+		if truncated {
+			n = p.MaxAttrEntries
+		}
+		s := make([]any, 0, n+1)
+		for i := range n {
+			s = append(s, p.truncateValue(rv.Index(i).Interface(), depth+1))
+		}
+		if truncated {
+			s = append(s, TruncationMarker)
+		}
+		return s
+	case reflect.String:
+		return p.truncateString(rv.String())
+	}
+	return value
+}
+
+// truncateBytes cuts the byte slice down to the maximum attribute length.
+//
+// Since byte slices are marshaled using base64, a truncated slice is returned as its base64 encoding ending with the
+// [TruncationMarker].  Otherwise the slice is returned unchanged.
+func (p *MarshalProfile) truncateBytes(b []byte) any {
+	if p.MaxAttrBytes <= 0 || len(b) <= p.MaxAttrBytes {
+		return b
+	}
+	return base64.StdEncoding.EncodeToString(b[:p.MaxAttrBytes]) + TruncationMarker
+}
+
+// truncateString cuts the string down to the maximum attribute length, ending it with the [TruncationMarker].
+func (p *MarshalProfile) truncateString(s string) string {
+	if p.MaxAttrBytes <= 0 || len(s) <= p.MaxAttrBytes {
+		return s
+	}
+	n := p.MaxAttrBytes
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + TruncationMarker
+}
@@ -0,0 +1,21 @@
+package xerrors
+
+import (
+	"time"
+)
+
+// Expired returns true if the TTL set on the given error using WithTTL has passed, eg: so that a cache layer which
+// stores errors (negative caching) knows when to retry the underlying operation.
+//
+// The first [Error] in the chain which has a TTL decides the result, so an outer error can extend or shorten the TTL
+// of the errors it wraps.  Errors without a TTL never expire.  The TTL is not included when the error is marshaled.
+func Expired(err error) bool {
+	var expires time.Time
+	walkChain(err, func(err error) bool {
+		if xerr, ok := err.(Error); ok {
+			expires = xerr.ExpiresAt()
+		}
+		return expires.IsZero()
+	})
+	return !expires.IsZero() && !time.Now().Before(expires)
+}
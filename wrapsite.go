@@ -0,0 +1,36 @@
+package xerrors
+
+// TraceWrapSites controls whether the location at which each error is created is recorded even when the capture of
+// the caller information is disabled (see [CaptureCallerInfo]), so that [WrapSites] can show where the layers of a
+// chain were added, eg: to find the code which wraps the same error repeatedly with redundant messages.
+//
+// The recorded locations are only available using WrapSites: they are not marshaled and do not change the Caller of
+// the errors.  Recording them costs about as much as capturing the caller information, so this mode is meant for
+// debugging.  This function enables or disables the mode globally for this package.  This call is thread-safe.
+func TraceWrapSites(enable bool) {
+	UpdateConfig(func(c *Config) {
+		c.TraceWrapSites = enable
+	})
+}
+
+// WrapSites returns the locations at which the errors in the chain of the given error were created, from the
+// outermost to the innermost.
+//
+// The location of each [Error] is its caller information if it was captured or, otherwise, the location recorded
+// while [TraceWrapSites] was enabled.  Errors without either, including errors not created by this package (eg: those
+// created using fmt.Errorf), are skipped.
+func WrapSites(err error) []CallerInfo {
+	var sites []CallerInfo
+	walkChain(err, func(err error) bool {
+		if e, ok := err.(*xerr); ok {
+			switch {
+			case e.caller != nil:
+				sites = append(sites, *e.caller)
+			case e.site != nil:
+				sites = append(sites, *e.site)
+			}
+		}
+		return true
+	})
+	return sites
+}
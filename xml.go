@@ -0,0 +1,240 @@
+package xerrors
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// xmlCaller is a version of [CallerInfo] that is used to marshal the object to XML.
+type xmlCaller struct {
+	// File is the name of the file in which the error occurred.
+	File string `xml:"file,attr"`
+
+	// Line is the line number at which the error occurred.
+	Line int `xml:"line,attr"`
+
+	// Func is the name of the function in which the error occurred.
+	Func string `xml:"func,attr"`
+
+	// Package is the import path of the package containing the function.
+	Package string `xml:"package,attr,omitempty"`
+
+	// Receiver is the receiver type of the method, if any.
+	Receiver string `xml:"receiver,attr,omitempty"`
+
+	// Synthetic is true if the frame does not correspond to a location in the source code.
+	Synthetic bool `xml:"synthetic,attr,omitempty"`
+}
+
+// newXMLCaller converts the given caller information into its XML representation.
+func newXMLCaller(info *CallerInfo) xmlCaller {
+	return xmlCaller{
+		File:      info.File,
+		Line:      info.Line,
+		Func:      info.Func,
+		Package:   info.Package,
+		Receiver:  info.Receiver,
+		Synthetic: info.Synthetic,
+	}
+}
+
+// xmlAttr is a single error attribute that is used to marshal the attributes to XML.
+type xmlAttr struct {
+	// Name is the name of the attribute.
+	Name string `xml:"name,attr"`
+
+	// Value is the attribute value formatted as a string.
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML marshals the error to XML.
+//
+// The document has the following structure, where the cause element contains the wrapped error (if any) using the
+// same structure:
+//
+//	<error code="1" domain="billing" id="01J9...">
+//	  <message>the error message</message>
+//	  <caller file="main.go" line="10" func="main.main"/>
+//	  <stack>
+//	    <frame file="main.go" line="10" func="main.main"/>
+//	  </stack>
+//	  <attrs>
+//	    <attr name="key">value</attr>
+//	  </attrs>
+//	  <cause>...</cause>
+//	</error>
+//
+// The domain, id, kind, op, position and severity attributes are omitted if they have not been set and the stack
+// element is omitted if no stack trace was captured.  The attributes are prepared using the marshal profile of the
+// factory which created the error in the same way as for JSON (see [PrepareAttrs]), so attributes omitted due to their
+// classification are left out, and their values are formatted using the %v verb.  The errors aggregated by a wrapped
+// [MultiError] are marshaled as error elements within the cause element, and other wrapped errors which do not
+// implement [xml.Marshaler] only include their message.
+//
+// Chains which are too deep or contain a cycle end with an empty cause element whose truncated attribute is true.
+func (e *xerr) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	return e.marshalXML(enc, start, loadConfig().MaxChainDepth, map[*xerr]struct{}{})
+}
+
+// marshalXML marshals the error to XML, including up to depth errors from its chain.  The seen map contains the
+// errors which have already been marshaled.
+func (e *xerr) marshalXML(enc *xml.Encoder, start xml.StartElement, depth int, seen map[*xerr]struct{}) error {
+	seen[e] = struct{}{}
+	e.markInspected()
+
+	// only use the name given by the parent if it does not come from the type name
+	if start.Name.Local == "" || start.Name.Local == "xerr" {
+		start.Name = xml.Name{Local: "error"}
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "code"}, Value: strconv.Itoa(e.code)})
+	if e.domain != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "domain"}, Value: e.domain})
+	}
+	if e.id != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: e.id})
+	}
+	if e.kind != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "kind"}, Value: string(e.kind)})
+	}
+	if e.op != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "op"}, Value: e.op})
+	}
+	if e.position != nil {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "position"}, Value: e.position.String()})
+	}
+	if e.severity != SeverityUnknown {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "severity"}, Value: e.severity.String()})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := enc.EncodeElement(e.message, xml.StartElement{Name: xml.Name{Local: "message"}}); err != nil {
+		return err
+	}
+	if e.caller != nil {
+		if err := enc.EncodeElement(newXMLCaller(e.caller), xml.StartElement{Name: xml.Name{Local: "caller"}}); err != nil {
+			return err
+		}
+	}
+	if len(e.stack) > 0 {
+		stackStart := xml.StartElement{Name: xml.Name{Local: "stack"}}
+		if err := enc.EncodeToken(stackStart); err != nil {
+			return err
+		}
+		for _, frame := range e.stack {
+			if err := enc.EncodeElement(newXMLCaller(&frame), xml.StartElement{Name: xml.Name{Local: "frame"}}); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(stackStart.End()); err != nil {
+			return err
+		}
+	}
+	if prepared := PrepareAttrs(e, nil); len(prepared) > 0 {
+		attrs := make([]xmlAttr, 0, len(prepared))
+		for k, v := range prepared {
+			attrs = append(attrs, xmlAttr{Name: k, Value: fmt.Sprintf("%v", v)})
+		}
+		slices.SortFunc(attrs, func(a, b xmlAttr) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		attrsStart := xml.StartElement{Name: xml.Name{Local: "attrs"}}
+		if err := enc.EncodeToken(attrsStart); err != nil {
+			return err
+		}
+		for _, attr := range attrs {
+			if err := enc.EncodeElement(attr, xml.StartElement{Name: xml.Name{Local: "attr"}}); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(attrsStart.End()); err != nil {
+			return err
+		}
+	}
+	if e.wrappedErr != nil {
+		causeStart := xml.StartElement{Name: xml.Name{Local: "cause"}}
+		wrapped, isXErr := e.wrappedErr.(*xerr)
+		_, isSeen := seen[wrapped]
+		if depth <= 1 || (isXErr && isSeen) {
+			causeStart.Attr = []xml.Attr{{Name: xml.Name{Local: "truncated"}, Value: "true"}}
+			if err := enc.EncodeToken(causeStart); err != nil {
+				return err
+			}
+			if err := enc.EncodeToken(causeStart.End()); err != nil {
+				return err
+			}
+		} else if isXErr {
+			if err := wrapped.marshalXML(enc, causeStart, depth-1, seen); err != nil {
+				return err
+			}
+		} else if multi, ok := e.wrappedErr.(*MultiError); ok {
+			if err := marshalMultiErrorXML(enc, causeStart, multi, depth-1, seen); err != nil {
+				return err
+			}
+		} else if marshaler, ok := e.wrappedErr.(xml.Marshaler); ok {
+			if err := enc.EncodeElement(marshaler, causeStart); err != nil {
+				return err
+			}
+		} else {
+			if err := enc.EncodeToken(causeStart); err != nil {
+				return err
+			}
+			msgStart := xml.StartElement{Name: xml.Name{Local: "message"}}
+			if err := enc.EncodeElement(e.wrappedErr.Error(), msgStart); err != nil {
+				return err
+			}
+			if err := enc.EncodeToken(causeStart.End()); err != nil {
+				return err
+			}
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// marshalMultiErrorXML marshals the errors aggregated by a [MultiError] as error elements within the given element,
+// including up to depth errors from the chain of each of them.  If the number of errors exceeds the limit set by
+// [WithMaxRenderedErrors], the omitted attribute of the element holds the number of errors which were left out.
+func marshalMultiErrorXML(enc *xml.Encoder, start xml.StartElement, m *MultiError, depth int,
+	seen map[*xerr]struct{}) error {
+	errs, omitted := m.rendered()
+	if omitted > 0 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "omitted"}, Value: strconv.Itoa(omitted)})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		errorStart := xml.StartElement{Name: xml.Name{Local: "error"}}
+		wrapped, isXErr := err.(*xerr)
+		if _, isSeen := seen[wrapped]; isXErr && isSeen {
+			errorStart.Attr = []xml.Attr{{Name: xml.Name{Local: "truncated"}, Value: "true"}}
+			if err := enc.EncodeToken(errorStart); err != nil {
+				return err
+			}
+			if err := enc.EncodeToken(errorStart.End()); err != nil {
+				return err
+			}
+			continue
+		}
+		if isXErr {
+			if err := wrapped.marshalXML(enc, errorStart, depth, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := enc.EncodeToken(errorStart); err != nil {
+			return err
+		}
+		msgStart := xml.StartElement{Name: xml.Name{Local: "message"}}
+		if err := enc.EncodeElement(err.Error(), msgStart); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(errorStart.End()); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}